@@ -27,17 +27,7 @@ func Int(target *int64) expect.Predicate {
 		return expect.Int()
 	}
 
-	return expect.Int().Then(func(actual any) error {
-		if v, ok := actual.(int64); ok {
-			*target = v
-		} else if v, ok := actual.(float64); ok {
-			*target = int64(v)
-		} else {
-			return fmt.Errorf("expected to bind int64, found %T", actual)
-		}
-
-		return nil
-	})
+	return expect.Int().Then(To(target))
 }
 
 // Float creates a predicate requiring a value to be a floating point number,
@@ -0,0 +1,209 @@
+package bind
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/jefflinse/melatonin/expect"
+	mtjson "github.com/jefflinse/melatonin/json"
+)
+
+// To creates a predicate requiring a value to be convertible to the type of a
+// target variable, binding the converted value to the target variable.
+//
+// Values are converted according to the kind of the target:
+//
+//   - any integer or floating point type, from a JSON number that fits the type
+//   - any string or bool type, from a JSON string or bool
+//   - time.Time, from an RFC 3339 string or a number of seconds since the epoch
+//   - any type implementing encoding.TextUnmarshaler, from a JSON string
+//   - any other type, by unmarshaling the JSON representation of the value
+//
+// Any transforms are applied to the value, in order, before it is converted,
+// allowing raw fields to be bound directly as the type that is needed:
+//
+//	bind.To(&id, expect.Trim(), expect.ParseInt())
+func To[T any](target *T, transforms ...expect.Transform) expect.Predicate {
+	return func(actual any) error {
+		if target == nil {
+			return fmt.Errorf("cannot bind %T to nil target", actual)
+		}
+
+		actual, err := expect.ApplyTransforms(actual, transforms...)
+		if err != nil {
+			return fmt.Errorf("failed to transform value to bind: %w", err)
+		}
+
+		var v T
+		if err := convert(actual, &v); err != nil {
+			return fmt.Errorf("failed to bind %T to %T: %w", actual, v, err)
+		}
+
+		*target = v
+		return nil
+	}
+}
+
+// convert converts a decoded JSON value into a target variable.
+func convert(actual any, target any) error {
+	if t, ok := target.(*time.Time); ok {
+		return convertTime(actual, t)
+	}
+
+	if u, ok := target.(encoding.TextUnmarshaler); ok {
+		s, ok := actual.(string)
+		if !ok {
+			return fmt.Errorf("expected string, got %T: %+v", actual, actual)
+		}
+		return u.UnmarshalText([]byte(s))
+	}
+
+	v := reflect.ValueOf(target).Elem()
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := toWholeNumber(actual)
+		if err != nil {
+			return err
+		}
+		if n < math.MinInt64 || n > math.MaxInt64 || v.OverflowInt(int64(n)) {
+			return fmt.Errorf("%g overflows %s", n, v.Type())
+		}
+		v.SetInt(int64(n))
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := toWholeNumber(actual)
+		if err != nil {
+			return err
+		}
+		if n < 0 || n > math.MaxUint64 || v.OverflowUint(uint64(n)) {
+			return fmt.Errorf("%g overflows %s", n, v.Type())
+		}
+		v.SetUint(uint64(n))
+
+	case reflect.Float32, reflect.Float64:
+		n, ok := toNumber(actual)
+		if !ok {
+			return fmt.Errorf("expected number, got %T: %+v", actual, actual)
+		}
+		if v.OverflowFloat(n) {
+			return fmt.Errorf("%g overflows %s", n, v.Type())
+		}
+		v.SetFloat(n)
+
+	case reflect.String:
+		s, ok := actual.(string)
+		if !ok {
+			return fmt.Errorf("expected string, got %T: %+v", actual, actual)
+		}
+		v.SetString(s)
+
+	case reflect.Bool:
+		b, ok := actual.(bool)
+		if !ok {
+			return fmt.Errorf("expected bool, got %T: %+v", actual, actual)
+		}
+		v.SetBool(b)
+
+	default:
+		if actual != nil && reflect.TypeOf(actual).AssignableTo(v.Type()) {
+			v.Set(reflect.ValueOf(actual))
+			return nil
+		}
+
+		b, err := json.Marshal(actual)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(b, target)
+	}
+
+	return nil
+}
+
+// convertTime converts an RFC 3339 string or a number of seconds since the
+// epoch into a time.
+func convertTime(actual any, target *time.Time) error {
+	switch v := actual.(type) {
+	case time.Time:
+		*target = v
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return err
+		}
+		*target = t
+	case float64:
+		sec, frac := math.Modf(v)
+		*target = time.Unix(int64(sec), int64(frac*1e9)).UTC()
+	case int64:
+		*target = time.Unix(v, 0).UTC()
+	default:
+		return fmt.Errorf("expected RFC 3339 string or Unix timestamp, got %T: %+v", actual, actual)
+	}
+
+	return nil
+}
+
+// toNumber returns the value of a decoded JSON number.
+func toNumber(actual any) (float64, bool) {
+	switch v := actual.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// toWholeNumber returns the value of a decoded JSON number that has no
+// fractional part.
+func toWholeNumber(actual any) (float64, error) {
+	n, ok := toNumber(actual)
+	if !ok {
+		return 0, fmt.Errorf("expected number, got %T: %+v", actual, actual)
+	}
+
+	if n != math.Trunc(n) {
+		return 0, fmt.Errorf("expected whole number, got %g", n)
+	}
+
+	return n, nil
+}
+
+// All creates a predicate that binds every value matched by a path within a
+// JSON value to a target slice, converting each value as To() does, for
+// example:
+//
+//	tc.ExpectInvariant(bind.All("items[*].id", &ids))
+//
+// The path is relative to the value the predicate is applied to and uses the
+// syntax of json.ParsePath(). The predicate fails if the path matches nothing.
+func All[T any](path string, target *[]T) expect.Predicate {
+	return func(actual any) error {
+		if target == nil {
+			return fmt.Errorf("cannot bind %s to nil target", path)
+		}
+
+		values := mtjson.Lookup(actual, path)
+		if len(values) == 0 {
+			return fmt.Errorf("expected values at %s to bind, got nothing", path)
+		}
+
+		bound := make([]T, len(values))
+		for i, value := range values {
+			if err := convert(value, &bound[i]); err != nil {
+				return fmt.Errorf("failed to bind %s[%d] (%T) to %T: %w", path, i, value, bound[i], err)
+			}
+		}
+
+		*target = bound
+		return nil
+	}
+}
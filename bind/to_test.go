@@ -0,0 +1,109 @@
+package bind_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jefflinse/melatonin/bind"
+	"github.com/jefflinse/melatonin/expect"
+	"github.com/stretchr/testify/assert"
+)
+
+type level string
+
+func TestTo(t *testing.T) {
+	t.Run("integers", func(t *testing.T) {
+		var i8 int8
+		assert.NoError(t, bind.To(&i8)(float64(42)))
+		assert.Equal(t, int8(42), i8)
+		assert.EqualError(t, bind.To(&i8)(float64(300)), "failed to bind float64 to int8: 300 overflows int8")
+		assert.EqualError(t, bind.To(&i8)(1.5), "failed to bind float64 to int8: expected whole number, got 1.5")
+
+		var u uint
+		assert.EqualError(t, bind.To(&u)(float64(-1)), "failed to bind float64 to uint: -1 overflows uint")
+		assert.EqualError(t, bind.To(&u)("1"), `failed to bind string to uint: expected number, got string: 1`)
+	})
+
+	t.Run("floats", func(t *testing.T) {
+		var f float32
+		assert.NoError(t, bind.To(&f)(2.5))
+		assert.Equal(t, float32(2.5), f)
+	})
+
+	t.Run("strings and bools", func(t *testing.T) {
+		var l level
+		assert.NoError(t, bind.To(&l)("debug"))
+		assert.Equal(t, level("debug"), l)
+
+		var b bool
+		assert.NoError(t, bind.To(&b)(true))
+		assert.True(t, b)
+		assert.EqualError(t, bind.To(&b)("true"), "failed to bind string to bool: expected bool, got string: true")
+	})
+
+	t.Run("time", func(t *testing.T) {
+		var ts time.Time
+		assert.NoError(t, bind.To(&ts)("2022-01-02T03:04:05Z"))
+		assert.Equal(t, time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC), ts)
+		assert.NoError(t, bind.To(&ts)(float64(1641092645)))
+		assert.Equal(t, time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC), ts)
+	})
+
+	t.Run("text unmarshaler", func(t *testing.T) {
+		var ip net.IP
+		assert.NoError(t, bind.To(&ip)("10.0.0.1"))
+		assert.Equal(t, "10.0.0.1", ip.String())
+	})
+
+	t.Run("structs and collections", func(t *testing.T) {
+		var s struct {
+			Name string `json:"name"`
+		}
+		assert.NoError(t, bind.To(&s)(map[string]any{"name": "a"}))
+		assert.Equal(t, "a", s.Name)
+
+		var ids []string
+		assert.NoError(t, bind.To(&ids)([]any{"a", "b"}))
+		assert.Equal(t, []string{"a", "b"}, ids)
+
+		var v any
+		assert.NoError(t, bind.To(&v)(float64(1)))
+		assert.Equal(t, float64(1), v)
+	})
+}
+
+func TestAll(t *testing.T) {
+	doc := map[string]any{
+		"items": []any{
+			map[string]any{"id": "a", "n": float64(1)},
+			map[string]any{"id": "b", "n": float64(2)},
+		},
+	}
+
+	var ids []string
+	assert.NoError(t, bind.All("items[*].id", &ids)(doc))
+	assert.Equal(t, []string{"a", "b"}, ids)
+
+	var ns []int
+	assert.NoError(t, bind.All("items.*.n", &ns)(doc))
+	assert.Equal(t, []int{1, 2}, ns)
+
+	assert.EqualError(t, bind.All("items[*].id", &ns)(doc), "failed to bind items[*].id[0] (string) to int: expected number, got string: a")
+	assert.EqualError(t, bind.All("missing[*]", &ids)(doc), "expected values at missing[*] to bind, got nothing")
+}
+
+func TestToWithTransforms(t *testing.T) {
+	var id int
+	assert.NoError(t, bind.To(&id, expect.Trim(), expect.ParseInt())(" 42 "))
+	assert.Equal(t, 42, id)
+
+	var ok bool
+	assert.NoError(t, bind.To(&ok, expect.Lower(), expect.ParseBool())("TRUE"))
+	assert.True(t, ok)
+
+	assert.EqualError(t, bind.To(&id, expect.ParseInt())("forty-two"),
+		`failed to transform value to bind: strconv.ParseInt: parsing "forty-two": invalid syntax`)
+	assert.EqualError(t, bind.To(&id, expect.Trim())(float64(1)),
+		"failed to transform value to bind: expected string, got float64: 1")
+}
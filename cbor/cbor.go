@@ -0,0 +1,435 @@
+// Package cbor encodes and decodes CBOR documents to and from the same values as
+// decoded JSON, for testing services with CBOR bodies.
+package cbor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+)
+
+const (
+	majorUint = iota << 5
+	majorNegativeInt
+	majorBytes
+	majorText
+	majorArray
+	majorMap
+	majorTag
+	majorSimple
+)
+
+// Marshal returns the CBOR encoding of a value.
+//
+// Booleans, numbers, strings, byte slices, slices, arrays, and maps are
+// encoded as their CBOR equivalents, and map keys are sorted so that the
+// encoding is deterministic. Other values, such as structs, are encoded as
+// their JSON representation would be.
+func Marshal(v any) ([]byte, error) {
+	e := &encoder{}
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+
+	return e.buf, nil
+}
+
+// Unmarshal decodes a CBOR document into the value pointed to by v.
+//
+// If v is a pointer to an empty interface, the document is decoded into the
+// same values as a decoded JSON document, except that integers are int64 and
+// byte strings are []byte. Tags are ignored, leaving their tagged values.
+// Otherwise, the decoded document is converted into v as JSON would be.
+func Unmarshal(data []byte, v any) error {
+	d := &decoder{data: data}
+	value, err := d.decode()
+	if err != nil {
+		return err
+	}
+
+	if d.pos != len(data) {
+		return fmt.Errorf("cbor: %d unexpected bytes after document", len(data)-d.pos)
+	}
+
+	if p, ok := v.(*any); ok {
+		*p = value
+		return nil
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("cbor: %w", err)
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.buf = append(e.buf, majorSimple|22)
+		return nil
+	}
+
+	if v.Kind() != reflect.Interface && v.Kind() != reflect.Ptr && v.Type().Implements(jsonMarshalerType) {
+		return e.encodeJSON(v)
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			e.buf = append(e.buf, majorSimple|22)
+			return nil
+		}
+		return e.encode(v.Elem())
+
+	case reflect.Bool:
+		if v.Bool() {
+			e.buf = append(e.buf, majorSimple|21)
+		} else {
+			e.buf = append(e.buf, majorSimple|20)
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n := v.Int(); n < 0 {
+			e.encodeHeader(majorNegativeInt, uint64(-(n + 1)))
+		} else {
+			e.encodeHeader(majorUint, uint64(n))
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.encodeHeader(majorUint, v.Uint())
+
+	case reflect.Float32, reflect.Float64:
+		e.buf = append(e.buf, majorSimple|27)
+		e.buf = appendUint64(e.buf, math.Float64bits(v.Float()))
+
+	case reflect.String:
+		e.encodeHeader(majorText, uint64(len(v.String())))
+		e.buf = append(e.buf, v.String()...)
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			e.buf = append(e.buf, majorSimple|22)
+			return nil
+		}
+
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			e.encodeHeader(majorBytes, uint64(len(b)))
+			e.buf = append(e.buf, b...)
+			return nil
+		}
+
+		e.encodeHeader(majorArray, uint64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			if err := e.encode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		if v.IsNil() {
+			e.buf = append(e.buf, majorSimple|22)
+			return nil
+		}
+
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+
+		e.encodeHeader(majorMap, uint64(len(keys)))
+		for _, key := range keys {
+			if err := e.encode(key); err != nil {
+				return err
+			}
+			if err := e.encode(v.MapIndex(key)); err != nil {
+				return err
+			}
+		}
+
+	default:
+		return e.encodeJSON(v)
+	}
+
+	return nil
+}
+
+// encodeJSON encodes a value, such as a struct, as its JSON representation.
+func (e *encoder) encodeJSON(v reflect.Value) error {
+	if t, ok := v.Interface().(time.Time); ok {
+		return e.encode(reflect.ValueOf(t.Format(time.RFC3339Nano)))
+	}
+
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return fmt.Errorf("cbor: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var generic any
+	if err := decoder.Decode(&generic); err != nil {
+		return fmt.Errorf("cbor: %w", err)
+	}
+
+	return e.encode(reflect.ValueOf(fromJSONNumbers(generic)))
+}
+
+// encodeHeader encodes the major type of a data item along with its argument,
+// such as the value of an integer or the length of a string, in the fewest
+// bytes possible.
+func (e *encoder) encodeHeader(major byte, n uint64) {
+	switch {
+	case n < 24:
+		e.buf = append(e.buf, major|byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, major|25, byte(n>>8), byte(n))
+	case n <= math.MaxUint32:
+		e.buf = appendUint32(append(e.buf, major|26), uint32(n))
+	default:
+		e.buf = appendUint64(append(e.buf, major|27), n)
+	}
+}
+
+func appendUint32(b []byte, n uint32) []byte {
+	return append(b, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+func appendUint64(b []byte, n uint64) []byte {
+	return appendUint32(appendUint32(b, uint32(n>>32)), uint32(n))
+}
+
+// fromJSONNumbers converts the numbers of a JSON value decoded with UseNumber
+// into integers where possible, and floats otherwise.
+func fromJSONNumbers(v any) any {
+	switch value := v.(type) {
+	case json.Number:
+		if n, err := value.Int64(); err == nil {
+			return n
+		}
+		f, _ := value.Float64()
+		return f
+
+	case map[string]any:
+		for key, element := range value {
+			value[key] = fromJSONNumbers(element)
+		}
+
+	case []any:
+		for i, element := range value {
+			value[i] = fromJSONNumbers(element)
+		}
+	}
+
+	return v
+}
+
+var (
+	errUnexpectedEnd = errors.New("cbor: unexpected end of document")
+	errBreak         = errors.New("cbor: unexpected break")
+)
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) read(n uint64) ([]byte, error) {
+	if uint64(len(d.data)-d.pos) < n {
+		return nil, errUnexpectedEnd
+	}
+
+	b := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
+
+// readHeader reads the major type, additional information, and argument of a
+// data item. The additional information of an item of indefinite length is -1.
+func (d *decoder) readHeader() (byte, int64, uint64, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	major, info := b[0]&0xe0, b[0]&0x1f
+	switch {
+	case info < 24:
+		return major, int64(info), uint64(info), nil
+	case info == 31:
+		return major, -1, 0, nil
+	case info > 27:
+		return 0, 0, 0, fmt.Errorf("cbor: invalid additional information %d", info)
+	}
+
+	arg, err := d.read(1 << (info - 24))
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	var n uint64
+	for _, c := range arg {
+		n = n<<8 | uint64(c)
+	}
+
+	return major, int64(info), n, nil
+}
+
+func (d *decoder) decode() (any, error) {
+	major, info, n, err := d.readHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case majorUint:
+		if n > math.MaxInt64 {
+			return float64(n), nil
+		}
+		return int64(n), nil
+
+	case majorNegativeInt:
+		if n > math.MaxInt64 {
+			return -1 - float64(n), nil
+		}
+		return -1 - int64(n), nil
+
+	case majorBytes, majorText:
+		b, err := d.decodeString(major, info, n)
+		if err != nil {
+			return nil, err
+		}
+		if major == majorText {
+			return string(b), nil
+		}
+		return b, nil
+
+	case majorArray:
+		array := []any{}
+		for i := uint64(0); info == -1 || i < n; i++ {
+			element, err := d.decode()
+			if err == errBreak && info == -1 {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			array = append(array, element)
+		}
+		return array, nil
+
+	case majorMap:
+		m := map[string]any{}
+		for i := uint64(0); info == -1 || i < n; i++ {
+			key, err := d.decode()
+			if err == errBreak && info == -1 {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+
+			value, err := d.decode()
+			if err != nil {
+				return nil, err
+			}
+
+			if s, ok := key.(string); ok {
+				m[s] = value
+			} else {
+				m[fmt.Sprint(key)] = value
+			}
+		}
+		return m, nil
+
+	case majorTag:
+		return d.decode()
+	}
+
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 25:
+		return halfFloat(uint16(n)), nil
+	case 26:
+		return float64(math.Float32frombits(uint32(n))), nil
+	case 27:
+		return math.Float64frombits(n), nil
+	case -1:
+		return nil, errBreak
+	}
+
+	return nil, fmt.Errorf("cbor: unsupported simple value %d", n)
+}
+
+// decodeString decodes the contents of a byte or text string, concatenating the
+// chunks of a string of indefinite length.
+func (d *decoder) decodeString(major byte, info int64, n uint64) ([]byte, error) {
+	if info != -1 {
+		b, err := d.read(n)
+		return append([]byte(nil), b...), err
+	}
+
+	var b []byte
+	for {
+		chunkMajor, chunkInfo, chunkLength, err := d.readHeader()
+		if err != nil {
+			return nil, err
+		}
+
+		if chunkMajor == majorSimple && chunkInfo == -1 {
+			return b, nil
+		}
+
+		if chunkMajor != major || chunkInfo == -1 {
+			return nil, errors.New("cbor: invalid chunk of indefinite-length string")
+		}
+
+		chunk, err := d.read(chunkLength)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, chunk...)
+	}
+}
+
+// halfFloat converts an IEEE 754 half-precision float to a float64.
+func halfFloat(h uint16) float64 {
+	exp, mant := int(h>>10)&0x1f, float64(h&0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+
+	if h&0x8000 != 0 {
+		return -f
+	}
+
+	return f
+}
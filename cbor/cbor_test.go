@@ -0,0 +1,101 @@
+package cbor_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jefflinse/melatonin/cbor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshal(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		value any
+		want  []byte
+	}{
+		{"nil", nil, []byte{0xf6}},
+		{"true", true, []byte{0xf5}},
+		{"false", false, []byte{0xf4}},
+		{"small integer", 10, []byte{0x0a}},
+		{"uint8", 100, []byte{0x18, 0x64}},
+		{"uint16", 1000, []byte{0x19, 0x03, 0xe8}},
+		{"negative integer", -10, []byte{0x29}},
+		{"negative uint16", -1000, []byte{0x39, 0x03, 0xe7}},
+		{"float", 1.5, []byte{0xfb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"text", "IETF", []byte{0x64, 'I', 'E', 'T', 'F'}},
+		{"bytes", []byte{1, 2, 3, 4}, []byte{0x44, 0x01, 0x02, 0x03, 0x04}},
+		{"array", []any{1, []int{2, 3}}, []byte{0x82, 0x01, 0x82, 0x02, 0x03}},
+		{"map with sorted keys", map[string]any{"b": 2, "a": 1}, []byte{0xa2, 0x61, 'a', 0x01, 0x61, 'b', 0x02}},
+		{"struct", struct {
+			ID int `json:"id"`
+		}{5}, []byte{0xa1, 0x62, 'i', 'd', 0x05}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := cbor.Marshal(test.value)
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, b)
+		})
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		data []byte
+		want any
+		err  string
+	}{
+		{"null", []byte{0xf6}, nil, ""},
+		{"undefined", []byte{0xf7}, nil, ""},
+		{"uint32", []byte{0x1a, 0x00, 0x0f, 0x42, 0x40}, int64(1000000), ""},
+		{"negative integer", []byte{0x38, 0x63}, int64(-100), ""},
+		{"half float", []byte{0xf9, 0x3e, 0x00}, 1.5, ""},
+		{"negative half float", []byte{0xf9, 0xc4, 0x00}, -4.0, ""},
+		{"half float infinity", []byte{0xf9, 0x7c, 0x00}, math.Inf(1), ""},
+		{"float32", []byte{0xfa, 0x47, 0xc3, 0x50, 0x00}, 100000.0, ""},
+		{"text", []byte{0x62, 'h', 'i'}, "hi", ""},
+		{"indefinite text", []byte{0x7f, 0x62, 's', 't', 0x63, 'r', 'e', 'a', 0xff}, "strea", ""},
+		{"bytes", []byte{0x42, 0x01, 0xff}, []byte{0x01, 0xff}, ""},
+		{"nested", []byte{0xa1, 0x64, 'u', 's', 'e', 'r', 0x82, 0xf5, 0x61, 'x'},
+			map[string]any{"user": []any{true, "x"}}, ""},
+		{"indefinite array", []byte{0x9f, 0x01, 0x02, 0xff}, []any{int64(1), int64(2)}, ""},
+		{"indefinite map", []byte{0xbf, 0x61, 'a', 0x01, 0xff}, map[string]any{"a": int64(1)}, ""},
+		{"integer map keys", []byte{0xa1, 0x01, 0x61, 'a'}, map[string]any{"1": "a"}, ""},
+		{"tag is ignored", []byte{0xc0, 0x64, '2', '0', '2', '4'}, "2024", ""},
+		{"truncated", []byte{0x82, 0x01}, nil, "cbor: unexpected end of document"},
+		{"trailing data", []byte{0x01, 0x02}, nil, "cbor: 1 unexpected bytes after document"},
+		{"unexpected break", []byte{0xff}, nil, "cbor: unexpected break"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var got any
+			err := cbor.Unmarshal(test.data, &got)
+			if test.err != "" {
+				if assert.Error(t, err) {
+					assert.Equal(t, test.err, err.Error())
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	type user struct {
+		ID    int      `json:"id"`
+		Name  string   `json:"name"`
+		Tags  []string `json:"tags"`
+		Score float64  `json:"score"`
+	}
+
+	want := user{ID: -70000, Name: "alice", Tags: []string{"admin"}, Score: 2.25}
+	b, err := cbor.Marshal(want)
+	assert.NoError(t, err)
+
+	var got user
+	assert.NoError(t, cbor.Unmarshal(b, &got))
+	assert.Equal(t, want, got)
+}
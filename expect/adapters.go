@@ -0,0 +1,104 @@
+package expect
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// A GomegaMatcher is a matcher of the Gomega matcher library, such as those
+// returned by gomega.HaveLen() or gomega.ContainSubstring(). Gomega matchers
+// satisfy it without this package importing Gomega.
+type GomegaMatcher interface {
+	Match(actual any) (success bool, err error)
+	FailureMessage(actual any) (message string)
+}
+
+// Gomega creates a predicate requiring a value to satisfy a Gomega matcher, for
+// example:
+//
+//	tc.ExpectBody(json.Object{"name": expect.Gomega(gomega.HavePrefix("al"))})
+//
+// Numbers in response bodies are float64 values, so they are best matched with
+// gomega.BeNumerically() rather than gomega.Equal().
+func Gomega(matcher GomegaMatcher) Predicate {
+	return func(actual any) error {
+		success, err := matcher.Match(actual)
+		if err != nil {
+			return err
+		}
+
+		if !success {
+			return errors.New(strings.TrimSpace(matcher.FailureMessage(actual)))
+		}
+
+		return nil
+	}
+}
+
+// Testify creates a predicate requiring a value to satisfy a testify assertion,
+// such as assert.NotEmpty or a function of the form of assert.ValueAssertionFunc,
+// for example:
+//
+//	tc.ExpectBody(json.Object{
+//		"tags": expect.Testify(func(t assert.TestingT, actual any, msgAndArgs ...any) bool {
+//			return assert.Contains(t, actual, "admin", msgAndArgs...)
+//		}),
+//	})
+//
+// The type parameter is testify's assert.TestingT, which is inferred from the
+// assertion, so that this package doesn't import testify.
+func Testify[T any](assertion func(t T, actual any, msgAndArgs ...any) bool) Predicate {
+	return func(actual any) error {
+		recorder := &testifyRecorder{}
+		t, ok := any(recorder).(T)
+		if !ok {
+			return fmt.Errorf("unsupported testify assertion type %T", assertion)
+		}
+
+		if assertion(t, actual) && len(recorder.failures) == 0 {
+			return nil
+		}
+
+		if len(recorder.failures) == 0 {
+			return fmt.Errorf("assertion failed for %+v", actual)
+		}
+
+		return errors.New(strings.Join(recorder.failures, "; "))
+	}
+}
+
+// testifyRecorder is a testify assert.TestingT that records the failures of testify
+// assertions.
+type testifyRecorder struct {
+	failures []string
+}
+
+// Errorf records the error message of a failed assertion.
+func (r *testifyRecorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, testifyErrorMessage(fmt.Sprintf(format, args...)))
+}
+
+// testifyErrorMessage extracts the "Error" content of a testify failure, which
+// also contains the stack trace of the assertion.
+func testifyErrorMessage(output string) string {
+	var lines []string
+	inError := false
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(line, "\tError:"):
+			inError = true
+			lines = append(lines, strings.TrimSpace(strings.TrimPrefix(line, "\tError:")))
+		case inError && strings.HasPrefix(line, "\t "):
+			lines = append(lines, strings.TrimSpace(line))
+		default:
+			inError = false
+		}
+	}
+
+	if len(lines) == 0 {
+		return strings.TrimSpace(output)
+	}
+
+	return strings.Join(lines, "\n")
+}
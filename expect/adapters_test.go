@@ -0,0 +1,80 @@
+package expect_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jefflinse/melatonin/expect"
+	"github.com/stretchr/testify/assert"
+)
+
+// prefixMatcher mimics a Gomega matcher.
+type prefixMatcher struct {
+	prefix string
+}
+
+func (m prefixMatcher) Match(actual any) (bool, error) {
+	s, ok := actual.(string)
+	if !ok {
+		return false, fmt.Errorf("prefix matcher expects a string, got %T", actual)
+	}
+
+	return len(s) >= len(m.prefix) && s[:len(m.prefix)] == m.prefix, nil
+}
+
+func (m prefixMatcher) FailureMessage(actual any) string {
+	return fmt.Sprintf("Expected\n    %v\nto have prefix %q\n", actual, m.prefix)
+}
+
+func TestGomega(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		actual any
+		want   []string
+	}{
+		{"matches", "alice", nil},
+		{"does not match", "bob", []string{"name: Expected\n    bob\nto have prefix \"al\""}},
+		{"matcher error", float64(1), []string{"name: prefix matcher expects a string, got float64"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			expected := map[string]any{"name": expect.Gomega(prefixMatcher{"al"})}
+			var got []string
+			for _, err := range expect.CompareValues(expected, map[string]any{"name": test.actual}, false) {
+				got = append(got, err.Error())
+			}
+
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestTestify(t *testing.T) {
+	containsAdmin := expect.Testify(func(t assert.TestingT, actual any, msgAndArgs ...any) bool {
+		return assert.Contains(t, actual, "admin", msgAndArgs...)
+	})
+
+	for _, test := range []struct {
+		name      string
+		predicate expect.Predicate
+		actual    any
+		want      string
+	}{
+		{"passes", containsAdmin, []any{"admin", "ops"}, ""},
+		{"fails", containsAdmin, []any{"ops"}, "[]interface {}{\"ops\"} does not contain \"admin\""},
+		{"value assertion", expect.Testify(assert.NotEmpty), []any{}, "Should NOT be empty, but was []"},
+		{"returns false without failing", expect.Testify(func(assert.TestingT, any, ...any) bool { return false }), "x",
+			"assertion failed for x"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.predicate(test.actual)
+			if test.want == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			if assert.Error(t, err) {
+				assert.Equal(t, test.want, err.Error())
+			}
+		})
+	}
+}
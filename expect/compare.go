@@ -0,0 +1,81 @@
+package expect
+
+import (
+	"fmt"
+	"sort"
+)
+
+// An UnknownFieldPolicy determines how fields present in an actual JSON object
+// but not in the expected object are treated when the object is not matched
+// exactly.
+type UnknownFieldPolicy int
+
+const (
+	// AllowUnknownFields ignores unknown fields. This is the default.
+	AllowUnknownFields UnknownFieldPolicy = iota
+
+	// WarnUnknownFields reports unknown fields as warnings.
+	WarnUnknownFields
+
+	// FailUnknownFields reports unknown fields as failures.
+	FailUnknownFields
+)
+
+// CompareOptions control how an expected value is compared to an actual value.
+type CompareOptions struct {
+	// ExactJSON indicates whether JSON objects and arrays must match exactly,
+	// rather than the expected value being treated as a subset of the actual
+	// value.
+	ExactJSON bool
+
+	// NullIsNotMissing indicates whether an expected null value requires the
+	// field to be present with a null value, rather than also matching a
+	// missing field.
+	NullIsNotMissing bool
+
+	// UnknownFields determines how unknown fields are treated when JSON objects
+	// are not matched exactly.
+	UnknownFields UnknownFieldPolicy
+}
+
+// A Comparison is the outcome of comparing an expected value to an actual value.
+type Comparison struct {
+	// Failures are the differences that cause the comparison to fail.
+	Failures []*FailedPredicateError
+
+	// Warnings are the differences that are reported but tolerated.
+	Warnings []*FailedPredicateError
+}
+
+// comparer holds the state of a single comparison.
+type comparer struct {
+	opts     CompareOptions
+	path     []string
+	warnings []*FailedPredicateError
+}
+
+// unknownField reports an unknown field of the object currently being compared,
+// either as a warning or by returning a failure.
+func (c *comparer) unknownField(key string, value any) *FailedPredicateError {
+	err := failedPredicate(fmt.Errorf("unexpected field: %+v", value))
+	err.PushField(key)
+	if c.opts.UnknownFields == FailUnknownFields {
+		return err
+	}
+
+	// unlike failures, warnings are not returned up through the comparison, so
+	// they must be given their full field path here
+	err.FieldStack = append(append([]string{}, c.path...), err.FieldStack...)
+	c.warnings = append(c.warnings, err)
+	return nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
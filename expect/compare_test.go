@@ -0,0 +1,59 @@
+package expect_test
+
+import (
+	"testing"
+
+	"github.com/jefflinse/melatonin/expect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	actual := map[string]any{
+		"id":    "a",
+		"extra": "b",
+		"nested": []any{
+			map[string]any{"x": float64(1), "y": float64(2)},
+		},
+	}
+
+	expected := map[string]any{
+		"id":      "a",
+		"nested":  []any{map[string]any{"x": float64(1)}},
+		"deleted": nil,
+	}
+
+	errorStrings := func(errs []*expect.FailedPredicateError) []string {
+		s := []string{}
+		for _, err := range errs {
+			s = append(s, err.Error())
+		}
+		return s
+	}
+
+	t.Run("defaults allow unknown fields and missing nulls", func(t *testing.T) {
+		c := expect.Compare(expected, actual, expect.CompareOptions{})
+		assert.Empty(t, c.Failures)
+		assert.Empty(t, c.Warnings)
+	})
+
+	t.Run("unknown fields as warnings", func(t *testing.T) {
+		c := expect.Compare(expected, actual, expect.CompareOptions{UnknownFields: expect.WarnUnknownFields})
+		assert.Empty(t, c.Failures)
+		assert.ElementsMatch(t, []string{"extra: unexpected field: b", "nested[0].y: unexpected field: 2"}, errorStrings(c.Warnings))
+	})
+
+	t.Run("unknown fields as failures", func(t *testing.T) {
+		c := expect.Compare(expected, actual, expect.CompareOptions{UnknownFields: expect.FailUnknownFields})
+		assert.ElementsMatch(t, []string{"extra: unexpected field: b", "nested[0].y: unexpected field: 2"}, errorStrings(c.Failures))
+		assert.Empty(t, c.Warnings)
+	})
+
+	t.Run("null is not missing", func(t *testing.T) {
+		c := expect.Compare(expected, actual, expect.CompareOptions{NullIsNotMissing: true})
+		assert.Equal(t, []string{"deleted: expected null, got nothing"}, errorStrings(c.Failures))
+
+		actual["deleted"] = nil
+		defer delete(actual, "deleted")
+		assert.Empty(t, expect.Compare(expected, actual, expect.CompareOptions{NullIsNotMissing: true}).Failures)
+	})
+}
@@ -0,0 +1,82 @@
+package expect
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An OptionalValue is an expected value that also matches an absent or null
+// value. See Optional().
+type OptionalValue struct {
+	expected any
+}
+
+// AllOf creates a predicate requiring a value to match every one of a set of
+// expected values, each of which can be anything that can be expected of a
+// value, such as a literal, a predicate, or a JSON object or array, for example:
+//
+//	json.Object{"homepage": expect.AllOf(expect.String(), expect.URL("https"))}
+func AllOf(expected ...any) Predicate {
+	expected = normalizeElements(expected)
+	return func(actual any) error {
+		for _, e := range expected {
+			if err := match(e, actual); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// AnyOf creates a predicate requiring a value to match at least one of a set of
+// expected values, each of which can be anything that can be expected of a
+// value, such as a literal, a predicate, or a JSON object or array.
+func AnyOf(expected ...any) Predicate {
+	expected = normalizeElements(expected)
+	return func(actual any) error {
+		if len(expected) == 0 {
+			return nil
+		}
+
+		messages := make([]string, 0, len(expected))
+		for _, e := range expected {
+			err := match(e, actual)
+			if err == nil {
+				return nil
+			}
+			messages = append(messages, err.Error())
+		}
+
+		return fmt.Errorf("expected any of the conditions to match, got %+v: %s", actual, strings.Join(messages, "; "))
+	}
+}
+
+// Optional creates an expected value requiring a value to be absent, null, or
+// otherwise to match an expected value, which can be anything that can be
+// expected of a value, such as a literal, a predicate, or a JSON object or
+// array. An optional field that is absent does not count toward the fields of
+// an exactly matched object.
+func Optional(expected any) OptionalValue {
+	return OptionalValue{expected: normalizeExpected(expected)}
+}
+
+// match compares an expected value to an actual value, returning an error
+// describing every failure of the comparison, or nil if the value matches.
+func match(expected, actual any) error {
+	failures := CompareValues(expected, actual, false)
+	if len(failures) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(failures))
+	for i, failure := range failures {
+		if len(failure.FieldStack) == 0 {
+			messages[i] = failure.Cause.Error()
+		} else {
+			messages[i] = failure.Error()
+		}
+	}
+
+	return fmt.Errorf("%s", strings.Join(messages, "; "))
+}
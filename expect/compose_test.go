@@ -0,0 +1,93 @@
+package expect_test
+
+import (
+	"testing"
+
+	"github.com/jefflinse/melatonin/expect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllOf(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		expected []any
+		actual   any
+		wantErr  string
+	}{
+		{"all match", []any{expect.String(), expect.URL("https")}, "https://example.com", ""},
+		{"first fails", []any{expect.String(), expect.URL("https")}, float64(1), "expected string, got float64: 1"},
+		{"second fails", []any{expect.String(), expect.URL("https")}, "nope", `expected absolute URL, got "nope"`},
+		{"literals", []any{expect.Float(), 3}, float64(3), ""},
+		{"object", []any{map[string]any{"id": "a"}}, map[string]any{"id": "b"}, "id: expected a, got b"},
+		{"none", nil, "anything", ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := expect.AllOf(test.expected...)(test.actual)
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAnyOf(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		expected []any
+		actual   any
+		wantErr  string
+	}{
+		{"first matches", []any{"a", "b"}, "a", ""},
+		{"last matches", []any{"a", expect.Float()}, float64(1), ""},
+		{"none match", []any{"a", "b"}, "c", "expected any of the conditions to match, got c: expected a, got c; expected b, got c"},
+		{"null", []any{nil, expect.String()}, nil, ""},
+		{"none", nil, "anything", ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := expect.AnyOf(test.expected...)(test.actual)
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestOptional(t *testing.T) {
+	expected := map[string]any{"nickname": expect.Optional(expect.String())}
+
+	for _, test := range []struct {
+		name    string
+		actual  map[string]any
+		exact   bool
+		wantErr string
+	}{
+		{"absent", map[string]any{}, false, ""},
+		{"null", map[string]any{"nickname": nil}, false, ""},
+		{"matching", map[string]any{"nickname": "bob"}, false, ""},
+		{"not matching", map[string]any{"nickname": float64(1)}, false, "expected string, got float64: 1"},
+		{"absent exactly", map[string]any{}, true, ""},
+		{"present exactly", map[string]any{"nickname": "bob"}, true, ""},
+		{"other field exactly", map[string]any{"name": "bob"}, true, "expected 0 fields, got 1:\n{\n  \"name\": \"bob\"\n}"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			errs := expect.CompareValues(expected, test.actual, test.exact)
+			if test.wantErr != "" {
+				if assert.Len(t, errs, 1) {
+					assert.Equal(t, test.wantErr, errs[0].Cause.Error())
+				}
+			} else {
+				assert.Empty(t, errs)
+			}
+		})
+	}
+}
+
+func TestOptionalElement(t *testing.T) {
+	assert.Empty(t, expect.CompareValues([]any{expect.Optional(1)}, []any{nil}, false))
+	assert.Empty(t, expect.CompareValues([]any{expect.Optional(1)}, []any{float64(1)}, false))
+	assert.Len(t, expect.CompareValues([]any{expect.Optional(1)}, []any{float64(2)}, false), 1)
+}
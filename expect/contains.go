@@ -0,0 +1,69 @@
+package expect
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Containment is an expected value requiring an array to contain a set of
+// elements, regardless of their positions or of any other elements, or a
+// string to contain a set of substrings.
+type Containment []any
+
+// Contains creates an expected value requiring an array to contain an element
+// matching an expected value, without specifying the rest of the array's
+// contents or its length. The expected element is compared to each actual
+// element like any other expected value, so it can be a literal, a predicate,
+// or a JSON object or array, which is matched as a subset of the element unless
+// the body is matched exactly.
+//
+// If the expected element is a string and the actual value is a string, such
+// as a field holding a long message or an entire plain-text body, the actual
+// value must instead contain the expected string as a substring.
+func Contains(element any) Containment {
+	return Containment{element}
+}
+
+// ContainsAll creates an expected value requiring an array to contain elements
+// matching each of a set of expected values. Each expected value must match a
+// different actual element. See Contains().
+func ContainsAll(elements ...any) Containment {
+	return Containment(elements)
+}
+
+// compareContainedValues compares an expected containment to an actual array.
+func (c *comparer) compareContainedValues(expected Containment, actual any) []*FailedPredicateError {
+	if s, ok := actual.(string); ok {
+		if substrings, ok := containedStrings(expected); ok {
+			var errs []*FailedPredicateError
+			for _, substring := range substrings {
+				if !strings.Contains(s, substring) {
+					errs = append(errs, failedPredicate(fmt.Errorf("expected string containing %q, got %q", substring, s)))
+				}
+			}
+			return errs
+		}
+	}
+
+	a, ok := actual.([]any)
+	if !ok {
+		return []*FailedPredicateError{wrongTypeError([]any(expected), actual)}
+	}
+
+	return c.compareElementSet(expected, a)
+}
+
+// containedStrings returns the expected elements of a containment if they are
+// all strings.
+func containedStrings(expected Containment) ([]string, bool) {
+	substrings := make([]string, len(expected))
+	for i, element := range expected {
+		s, ok := element.(string)
+		if !ok {
+			return nil, false
+		}
+		substrings[i] = s
+	}
+
+	return substrings, true
+}
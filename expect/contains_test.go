@@ -0,0 +1,59 @@
+package expect_test
+
+import (
+	"testing"
+
+	"github.com/jefflinse/melatonin/expect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContains(t *testing.T) {
+	users := []any{
+		map[string]any{"id": float64(1), "name": "alice", "admin": true},
+		map[string]any{"id": float64(2), "name": "bob"},
+	}
+
+	for _, test := range []struct {
+		name     string
+		expected any
+		actual   any
+		exact    bool
+		want     []string
+	}{
+		{"contains literal", expect.Contains("b"), []any{"a", "b", "c"}, false, nil},
+		{"contains literal when exact", expect.Contains("b"), []any{"a", "b", "c"}, true, nil},
+		{"does not contain literal", expect.Contains("x"), []any{"a", "b"}, false,
+			[]string{": expected an element matching x, got none"}},
+		{"contains predicate match", expect.Contains(expect.Float(2)), []any{float64(1), float64(2)}, false, nil},
+		{"contains object subset", expect.Contains(map[string]any{"name": "bob"}), users, false, nil},
+		{"object subset not matched exactly", expect.Contains(map[string]any{"name": "bob"}), users, true,
+			[]string{": expected an element matching map[name:bob], got none"}},
+		{"contains all", expect.ContainsAll("c", "a"), []any{"a", "b", "c"}, false, nil},
+		{"contains all requires distinct elements", expect.ContainsAll("a", "a"), []any{"a", "b"}, false,
+			[]string{": expected an element matching a, got none"}},
+		{"contains all reports each missing element", expect.ContainsAll("x", "a", "y"), []any{"a"}, false,
+			[]string{": expected an element matching x, got none", ": expected an element matching y, got none"}},
+		{"nested in an object", map[string]any{"users": expect.Contains(map[string]any{"admin": true})},
+			map[string]any{"users": users[1:]}, false,
+			[]string{"users: expected an element matching map[admin:true], got none"}},
+		{"substring", expect.Contains("b"), "abc", false, nil},
+		{"substring when exact", expect.Contains("b"), "abc", true, nil},
+		{"missing substring", expect.Contains("x"), "abc", false,
+			[]string{`: expected string containing "x", got "abc"`}},
+		{"all substrings", expect.ContainsAll("a", "c"), "abc", false, nil},
+		{"substring of a field", map[string]any{"message": expect.Contains("not found")},
+			map[string]any{"message": "user 42 not found in tenant 7"}, false, nil},
+		{"not an array or string", expect.Contains("a"), float64(1), false,
+			[]string{": expected type []interface {}, got float64: 1"}},
+		{"non-string element in a string", expect.Contains(float64(1)), "abc", false,
+			[]string{": expected type []interface {}, got string: abc"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, err := range expect.CompareValues(test.expected, test.actual, test.exact) {
+				got = append(got, err.Error())
+			}
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
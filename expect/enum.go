@@ -0,0 +1,63 @@
+package expect
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// InEnum creates a predicate requiring a value to match one of a slice of Go
+// values, typically the typed constants of an enumeration, for example:
+//
+//	expect.InEnum([]OrderStatus{StatusPending, StatusShipped})
+//
+// Each value is converted to its JSON representation for comparison, so enum
+// types implementing json.Marshaler are matched by their marshaled form. Values
+// implementing fmt.Stringer additionally match their String() form.
+func InEnum(values any) Predicate {
+	v := reflect.ValueOf(values)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return func(any) error {
+			return fmt.Errorf("invalid enum: expected slice, got %T", values)
+		}
+	}
+
+	candidates := []any{}
+	names := []string{}
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i).Interface()
+		b, err := json.Marshal(elem)
+		if err != nil {
+			return func(any) error {
+				return fmt.Errorf("invalid enum value %+v: %w", elem, err)
+			}
+		}
+
+		var decoded any
+		if err := json.Unmarshal(b, &decoded); err != nil {
+			return func(any) error {
+				return fmt.Errorf("invalid enum value %+v: %w", elem, err)
+			}
+		}
+
+		candidates = append(candidates, decoded)
+		names = append(names, string(b))
+		if stringer, ok := elem.(fmt.Stringer); ok {
+			candidates = append(candidates, stringer.String())
+		}
+	}
+
+	return func(actual any) error {
+		for _, candidate := range candidates {
+			if reflect.DeepEqual(candidate, actual) {
+				return nil
+			}
+
+			if n, ok := toFloat(actual); ok && reflect.DeepEqual(candidate, n) {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("expected one of %s, got %+v", names, actual)
+	}
+}
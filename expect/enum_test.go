@@ -0,0 +1,51 @@
+package expect_test
+
+import (
+	"testing"
+
+	"github.com/jefflinse/melatonin/expect"
+	"github.com/stretchr/testify/assert"
+)
+
+type status string
+
+const (
+	statusPending status = "pending"
+	statusShipped status = "shipped"
+)
+
+type priority int
+
+const (
+	priorityLow priority = iota
+	priorityHigh
+)
+
+func (p priority) String() string {
+	return [...]string{"low", "high"}[p]
+}
+
+func TestInEnum(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		predicate expect.Predicate
+		actual    any
+		wantErr   string
+	}{
+		{"matches string constant", expect.InEnum([]status{statusPending, statusShipped}), "shipped", ""},
+		{"fails on unknown string", expect.InEnum([]status{statusPending, statusShipped}), "lost", `expected one of ["pending" "shipped"], got lost`},
+		{"matches numeric constant", expect.InEnum([]priority{priorityLow, priorityHigh}), float64(1), ""},
+		{"matches Stringer form", expect.InEnum([]priority{priorityLow, priorityHigh}), "high", ""},
+		{"fails on unknown number", expect.InEnum([]priority{priorityLow, priorityHigh}), float64(7), "expected one of [0 1], got 7"},
+		{"fails on non-slice enum", expect.InEnum(statusPending), "pending", "invalid enum: expected slice, got expect_test.status"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.predicate(test.actual)
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
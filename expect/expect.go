@@ -139,8 +139,49 @@ func Map(expected ...map[string]any) Predicate {
 	}
 }
 
+// Not creates a predicate requiring a value not to match an expected value,
+// which can be anything that can be expected of a value, such as a literal, a
+// predicate, or a JSON object or array. A pointer to a value, such as one
+// captured from an earlier response, is dereferenced when the predicate is
+// evaluated, for example:
+//
+//	tc.ExpectBody(json.Object{"id": expect.Not(&previousID)})
+func Not(expected any) Predicate {
+	return func(actual any) error {
+		// integers other than int64 are matched by the comparison as int64
+		switch v := expected.(type) {
+		case int:
+			expected = int64(v)
+		case int32:
+			expected = int64(v)
+		}
+
+		if len(CompareValues(expected, actual, false)) > 0 {
+			return nil
+		}
+
+		switch expected.(type) {
+		case Predicate, func(any) error:
+			return fmt.Errorf("expected value not to match, got %+v", actual)
+		}
+
+		resolved, err := mtjson.ResolveDeferred(expected)
+		if err != nil {
+			resolved = expected
+		}
+
+		return fmt.Errorf("expected anything but %+v, got %+v", resolved, actual)
+	}
+}
+
 // Pattern creates a predicate requiring a value to be a string that matches a
-// regular expression, optionally matching against a set of values.
+// regular expression, such as a field of an expected JSON body with a generated
+// value:
+//
+//	json.Object{
+//		"id":         expect.Pattern(`^usr_[a-z0-9]{12}$`),
+//		"created_at": expect.Pattern(`^\d{4}-\d{2}-\d{2}T`),
+//	}
 func Pattern(regex string) Predicate {
 	r, err := regexp.Compile(regex)
 	if err != nil {
@@ -213,6 +254,26 @@ func String(expected ...string) Predicate {
 
 // CompareValues compares an expected value to an actual value.
 func CompareValues(expected, actual any, exactJSON bool) []*FailedPredicateError {
+	return Compare(expected, actual, CompareOptions{ExactJSON: exactJSON}).Failures
+}
+
+// Compare compares an expected value to an actual value using a set of
+// comparison options.
+func Compare(expected, actual any, opts CompareOptions) *Comparison {
+	c := &comparer{opts: opts}
+	failures := c.compare(expected, actual)
+	if len(failures) == 0 {
+		failures = nil
+	}
+
+	return &Comparison{
+		Failures: failures,
+		Warnings: c.warnings,
+	}
+}
+
+// compare compares an expected value to an actual value.
+func (c *comparer) compare(expected, actual any) []*FailedPredicateError {
 	errs := []*FailedPredicateError{}
 
 	if expected == nil && actual != nil {
@@ -274,14 +335,32 @@ func CompareValues(expected, actual any, exactJSON bool) []*FailedPredicateError
 		if !ok {
 			ev = map[string]any(expectedValue.(mtjson.Object))
 		}
-		return compareMapValues(ev, actual, exactJSON)
+		return c.compareMapValues(ev, actual)
 
 	case mtjson.Array, []any:
 		ev, ok := expectedValue.([]any)
 		if !ok {
 			ev = []any(expectedValue.(mtjson.Array))
 		}
-		return compareSliceValues(ev, actual, exactJSON)
+		return c.compareSliceValues(ev, actual)
+
+	case UnorderedArray:
+		return c.compareUnorderedValues(expectedValue, actual)
+
+	case Containment:
+		return c.compareContainedValues(expectedValue, actual)
+
+	case OptionalValue:
+		if actual == nil {
+			return nil
+		}
+		return c.compare(expectedValue.expected, actual)
+
+	case Presence:
+		if err := expectedValue.matchValue(actual); err != nil {
+			errs = append(errs, err)
+			return errs
+		}
 
 	case Predicate, func(any) error:
 		f, ok := expectedValue.(Predicate)
@@ -351,7 +430,7 @@ func compareInt64Values(expected int64, actual any) *FailedPredicateError {
 }
 
 // compareMapValues compares an expected JSON object to an actual JSON object.
-func compareMapValues(expected map[string]any, actual any, exact bool) []*FailedPredicateError {
+func (c *comparer) compareMapValues(expected map[string]any, actual any) []*FailedPredicateError {
 	errs := []*FailedPredicateError{}
 
 	m, ok := actual.(map[string]any)
@@ -360,22 +439,32 @@ func compareMapValues(expected map[string]any, actual any, exact bool) []*Failed
 		return errs
 	}
 
-	if exact {
-		if len(m) != len(expected) {
+	if c.opts.ExactJSON {
+		// fields expected to be missing, and optional fields that are absent,
+		// don't count toward the expected fields
+		expectedKeys := make([]string, 0, len(expected))
+		for k, v := range expected {
+			if p, ok := v.(Presence); ok && p == presenceMissing {
+				continue
+			}
+			if _, ok := v.(OptionalValue); ok {
+				if _, present := m[k]; !present {
+					continue
+				}
+			}
+			expectedKeys = append(expectedKeys, k)
+		}
+
+		if len(m) != len(expectedKeys) {
 			j, err := json.MarshalIndent(m, "", "  ")
 			if err != nil {
 				errs = append(errs, failedPredicate(err))
 			}
 
-			errs = append(errs, failedPredicate(fmt.Errorf("expected %d fields, got %d:\n%+v", len(expected), len(m), string(j))))
+			errs = append(errs, failedPredicate(fmt.Errorf("expected %d fields, got %d:\n%+v", len(expectedKeys), len(m), string(j))))
 			return errs
 		}
 
-		expectedKeys := make([]string, 0, len(expected))
-		for k := range expected {
-			expectedKeys = append(expectedKeys, k)
-		}
-
 		actualKeys := make([]string, 0, len(m))
 		for k := range m {
 			actualKeys = append(actualKeys, k)
@@ -392,17 +481,47 @@ func compareMapValues(expected map[string]any, actual any, exact bool) []*Failed
 	}
 
 	for k, v := range expected {
-		for _, err := range CompareValues(v, m[k], exact) {
+		if p, ok := v.(Presence); ok {
+			actual, present := m[k]
+			if err := p.matchField(actual, present); err != nil {
+				err.PushField(k)
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		if _, present := m[k]; v == nil && !present && c.opts.NullIsNotMissing {
+			err := failedPredicate(fmt.Errorf("expected null, got nothing"))
+			err.PushField(k)
+			errs = append(errs, err)
+			continue
+		}
+
+		c.path = append(c.path, k)
+		for _, err := range c.compare(v, m[k]) {
 			err.PushField(k)
 			errs = append(errs, err)
 		}
+		c.path = c.path[:len(c.path)-1]
+	}
+
+	if !c.opts.ExactJSON && c.opts.UnknownFields != AllowUnknownFields {
+		for _, k := range sortedKeys(m) {
+			if _, ok := expected[k]; ok {
+				continue
+			}
+
+			if err := c.unknownField(k, m[k]); err != nil {
+				errs = append(errs, err)
+			}
+		}
 	}
 
 	return errs
 }
 
 // compareSliceValues compares an expected slice to an actual slice.
-func compareSliceValues(expected []any, actual any, exact bool) []*FailedPredicateError {
+func (c *comparer) compareSliceValues(expected []any, actual any) []*FailedPredicateError {
 	errs := []*FailedPredicateError{}
 
 	a, ok := actual.([]any)
@@ -411,27 +530,38 @@ func compareSliceValues(expected []any, actual any, exact bool) []*FailedPredica
 		return errs
 	}
 
-	if len(a) < len(expected) {
-		j, err := json.MarshalIndent(a, "", "  ")
-		if err != nil {
-			errs = append(errs, failedPredicate(err))
-		}
-		errs = append(errs, failedPredicate(fmt.Errorf("expected at least %d elements, got %d: %+v", len(expected), len(a), string(j))))
-		return errs
-	} else if exact && len(a) > len(expected) {
-		j, err := json.MarshalIndent(a, "", "  ")
-		if err != nil {
-			errs = append(errs, failedPredicate(err))
-		}
-		errs = append(errs, failedPredicate(fmt.Errorf("expected %d elements, got %d: %+v", len(expected), len(a), string(j))))
-		return errs
+	if lengthErrs := c.compareSliceLengths(len(expected), a); len(lengthErrs) > 0 {
+		return lengthErrs
 	}
 
 	for i, v := range expected {
-		for _, err := range CompareValues(v, a[i], exact) {
+		c.path = append(c.path, fmt.Sprintf("[%d]", i))
+		for _, err := range c.compare(v, a[i]) {
 			err.PushField(fmt.Sprintf("[%d]", i))
 			errs = append(errs, err)
 		}
+		c.path = c.path[:len(c.path)-1]
+	}
+
+	return errs
+}
+
+// compareSliceLengths checks that an actual slice has enough elements to match
+// an expected slice of the given length.
+func (c *comparer) compareSliceLengths(expected int, actual []any) []*FailedPredicateError {
+	errs := []*FailedPredicateError{}
+	if len(actual) < expected {
+		j, err := json.MarshalIndent(actual, "", "  ")
+		if err != nil {
+			errs = append(errs, failedPredicate(err))
+		}
+		errs = append(errs, failedPredicate(fmt.Errorf("expected at least %d elements, got %d: %+v", expected, len(actual), string(j))))
+	} else if c.opts.ExactJSON && len(actual) > expected {
+		j, err := json.MarshalIndent(actual, "", "  ")
+		if err != nil {
+			errs = append(errs, failedPredicate(err))
+		}
+		errs = append(errs, failedPredicate(fmt.Errorf("expected %d elements, got %d: %+v", expected, len(actual), string(j))))
 	}
 
 	return errs
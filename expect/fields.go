@@ -0,0 +1,84 @@
+package expect
+
+import (
+	"fmt"
+	"reflect"
+
+	mtjson "github.com/jefflinse/melatonin/json"
+)
+
+// FieldsEqual creates a predicate requiring a JSON document to contain equal
+// values at two paths, for example:
+//
+//	expect.FieldsEqual("data.id", "meta.id")
+//
+// Paths use the syntax of json.ParsePath(). Each path must match exactly one
+// value.
+func FieldsEqual(path, otherPath string) Predicate {
+	return func(actual any) error {
+		a, err := lookupOne(actual, path)
+		if err != nil {
+			return err
+		}
+
+		b, err := lookupOne(actual, otherPath)
+		if err != nil {
+			return err
+		}
+
+		if !reflect.DeepEqual(a, b) {
+			return fmt.Errorf("expected %s (%+v) to equal %s (%+v)", path, a, otherPath, b)
+		}
+
+		return nil
+	}
+}
+
+// SumEquals creates a predicate requiring the numbers matched by a path within
+// a JSON document to add up to the number at another path, for example:
+//
+//	expect.SumEquals("items[*].amount", "total")
+//
+// Paths use the syntax of json.ParsePath(). The total path must match exactly
+// one value.
+func SumEquals(path, totalPath string) Predicate {
+	return func(actual any) error {
+		total, err := lookupOne(actual, totalPath)
+		if err != nil {
+			return err
+		}
+
+		want, ok := toFloat(total)
+		if !ok {
+			return fmt.Errorf("expected %s to be a number, got %T: %+v", totalPath, total, total)
+		}
+
+		sum := float64(0)
+		for _, value := range mtjson.Lookup(actual, path) {
+			n, ok := toFloat(value)
+			if !ok {
+				return fmt.Errorf("expected %s to contain only numbers, got %T: %+v", path, value, value)
+			}
+			sum += n
+		}
+
+		if sum != want {
+			return fmt.Errorf("expected sum of %s (%g) to equal %s (%g)", path, sum, totalPath, want)
+		}
+
+		return nil
+	}
+}
+
+// lookupOne returns the single value matched by a path within a document.
+func lookupOne(doc any, path string) (any, error) {
+	values := mtjson.Lookup(doc, path)
+	switch len(values) {
+	case 0:
+		return nil, fmt.Errorf("expected a value at %s, got nothing", path)
+	case 1:
+		return values[0], nil
+	default:
+		return nil, fmt.Errorf("expected a single value at %s, got %d", path, len(values))
+	}
+}
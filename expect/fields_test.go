@@ -0,0 +1,41 @@
+package expect_test
+
+import (
+	"testing"
+
+	"github.com/jefflinse/melatonin/expect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrossFieldMatchers(t *testing.T) {
+	doc := map[string]any{
+		"data":  map[string]any{"id": "a1"},
+		"meta":  map[string]any{"id": "a1", "other": "b2"},
+		"items": []any{map[string]any{"amount": float64(2.5)}, map[string]any{"amount": float64(4)}},
+		"total": float64(6.5),
+		"wrong": float64(7),
+	}
+
+	for _, test := range []struct {
+		name      string
+		predicate expect.Predicate
+		wantErr   string
+	}{
+		{"FieldsEqual matches", expect.FieldsEqual("data.id", "meta.id"), ""},
+		{"FieldsEqual fails on different values", expect.FieldsEqual("data.id", "meta.other"), "expected data.id (a1) to equal meta.other (b2)"},
+		{"FieldsEqual fails on missing value", expect.FieldsEqual("data.id", "meta.missing"), "expected a value at meta.missing, got nothing"},
+		{"FieldsEqual fails on multiple values", expect.FieldsEqual("data.id", "items[*].amount"), "expected a single value at items[*].amount, got 2"},
+		{"SumEquals matches", expect.SumEquals("items[*].amount", "total"), ""},
+		{"SumEquals fails on wrong total", expect.SumEquals("items[*].amount", "wrong"), "expected sum of items[*].amount (6.5) to equal wrong (7)"},
+		{"SumEquals fails on non-numeric values", expect.SumEquals("meta.*", "total"), "expected meta.* to contain only numbers, got string: a1"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.predicate(doc)
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package expect
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Email creates a predicate requiring a value to be a string containing a
+// single bare email address, such as "user@example.com".
+func Email() Predicate {
+	return String().Then(func(actual any) error {
+		s, _ := actual.(string)
+		addr, err := mail.ParseAddress(s)
+		if err != nil || addr.Address != s {
+			return fmt.Errorf("expected email address, got %q", s)
+		}
+
+		return nil
+	})
+}
+
+// IP creates a predicate requiring a value to be a string containing an IPv4 or
+// IPv6 address.
+func IP() Predicate {
+	return String().Then(func(actual any) error {
+		s, _ := actual.(string)
+		if net.ParseIP(s) == nil {
+			return fmt.Errorf("expected IP address, got %q", s)
+		}
+
+		return nil
+	})
+}
+
+// IPv4 creates a predicate requiring a value to be a string containing an IPv4
+// address in dotted decimal form.
+func IPv4() Predicate {
+	return String().Then(func(actual any) error {
+		s, _ := actual.(string)
+		if ip := net.ParseIP(s); ip == nil || ip.To4() == nil || !isDottedDecimal(s) {
+			return fmt.Errorf("expected IPv4 address, got %q", s)
+		}
+
+		return nil
+	})
+}
+
+// IPv6 creates a predicate requiring a value to be a string containing an IPv6
+// address.
+func IPv6() Predicate {
+	return String().Then(func(actual any) error {
+		s, _ := actual.(string)
+		if ip := net.ParseIP(s); ip == nil || isDottedDecimal(s) {
+			return fmt.Errorf("expected IPv6 address, got %q", s)
+		}
+
+		return nil
+	})
+}
+
+// URL creates a predicate requiring a value to be a string containing an
+// absolute URL, optionally restricted to a set of schemes.
+func URL(schemes ...string) Predicate {
+	return String().Then(func(actual any) error {
+		s, _ := actual.(string)
+		u, err := url.Parse(s)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("expected absolute URL, got %q", s)
+		}
+
+		if len(schemes) > 0 {
+			for _, scheme := range schemes {
+				if u.Scheme == scheme {
+					return nil
+				}
+			}
+
+			return fmt.Errorf("expected URL with scheme in %+v, got %q", schemes, s)
+		}
+
+		return nil
+	})
+}
+
+// UUID creates a predicate requiring a value to be a string containing a UUID
+// in its canonical hyphenated form.
+func UUID() Predicate {
+	return String().Then(func(actual any) error {
+		s, _ := actual.(string)
+		if !uuidRegex.MatchString(s) {
+			return fmt.Errorf("expected UUID, got %q", s)
+		}
+
+		return nil
+	})
+}
+
+// isDottedDecimal returns true if an address string contains no colons, which
+// distinguishes IPv4 addresses from IPv6 addresses such as "::ffff:1.2.3.4".
+func isDottedDecimal(s string) bool {
+	return !strings.Contains(s, ":")
+}
@@ -0,0 +1,44 @@
+package expect_test
+
+import (
+	"testing"
+
+	"github.com/jefflinse/melatonin/expect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatMatchers(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		predicate expect.Predicate
+		actual    any
+		wantErr   string
+	}{
+		{"UUID matches", expect.UUID(), "3f2504e0-4f89-11d3-9a0c-0305e82c3301", ""},
+		{"UUID fails on malformed value", expect.UUID(), "3f2504e0-4f89", `expected UUID, got "3f2504e0-4f89"`},
+		{"UUID fails on non-string", expect.UUID(), 42, "expected string, got int: 42"},
+		{"Email matches", expect.Email(), "user@example.com", ""},
+		{"Email fails on display name", expect.Email(), "User <user@example.com>", `expected email address, got "User <user@example.com>"`},
+		{"Email fails on malformed value", expect.Email(), "user", `expected email address, got "user"`},
+		{"URL matches", expect.URL(), "https://example.com/a?b=c", ""},
+		{"URL fails on relative URL", expect.URL(), "/a/b", `expected absolute URL, got "/a/b"`},
+		{"URL matches scheme", expect.URL("https"), "https://example.com", ""},
+		{"URL fails on unexpected scheme", expect.URL("https"), "http://example.com", `expected URL with scheme in [https], got "http://example.com"`},
+		{"IP matches IPv4", expect.IP(), "10.0.0.1", ""},
+		{"IP matches IPv6", expect.IP(), "::1", ""},
+		{"IPv4 matches", expect.IPv4(), "192.168.1.1", ""},
+		{"IPv4 fails on IPv6", expect.IPv4(), "::ffff:192.168.1.1", `expected IPv4 address, got "::ffff:192.168.1.1"`},
+		{"IPv4 fails on malformed value", expect.IPv4(), "256.1.1.1", `expected IPv4 address, got "256.1.1.1"`},
+		{"IPv6 matches", expect.IPv6(), "2001:db8::1", ""},
+		{"IPv6 fails on IPv4", expect.IPv6(), "192.168.1.1", `expected IPv6 address, got "192.168.1.1"`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.predicate(test.actual)
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package expect
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+var (
+	predicateType     = reflect.TypeOf(Predicate(nil))
+	predicateFuncType = reflect.TypeOf(func(any) error { return nil })
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Like derives an expected JSON value from a Go value, such as an instance of a
+// domain struct, so that it can double as an expected payload.
+//
+// Struct fields are named according to their json tags, and fields tagged "-"
+// are skipped. Fields holding zero values are skipped, so only the fields that
+// are set are expected; to expect a zero value explicitly, or to match a field
+// more loosely, declare the field as a Predicate and set it to a predicate.
+// Values implementing json.Marshaler or encoding.TextMarshaler are expected in
+// their marshaled form.
+func Like(v any) any {
+	return like(reflect.ValueOf(v))
+}
+
+func like(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+
+	if v.Type() == predicateType || v.Type() == predicateFuncType {
+		if v.IsNil() {
+			return nil
+		}
+		return Predicate(v.Convert(predicateFuncType).Interface().(func(any) error))
+	}
+
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+			return nil
+		}
+		return marshaled(v.Interface())
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return like(v.Elem())
+
+	case reflect.Struct:
+		m := map[string]any{}
+		likeStruct(v, m)
+		return m
+
+	case reflect.Map:
+		m := map[string]any{}
+		iter := v.MapRange()
+		for iter.Next() {
+			if value := like(iter.Value()); value != nil {
+				m[iter.Key().String()] = value
+			}
+		}
+		return m
+
+	case reflect.Slice, reflect.Array:
+		s := make([]any, v.Len())
+		for i := range s {
+			s[i] = like(v.Index(i))
+		}
+		return s
+
+	case reflect.Bool:
+		return v.Bool()
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+
+	case reflect.String:
+		return v.String()
+
+	default:
+		return marshaled(v.Interface())
+	}
+}
+
+// likeStruct adds the expected values of a struct's fields to a map, promoting
+// the fields of embedded structs as encoding/json does.
+func likeStruct(v reflect.Value, m map[string]any) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			if tagName := strings.Split(tag, ",")[0]; tagName != "" {
+				name = tagName
+			} else if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				likeStruct(v.Field(i), m)
+				continue
+			}
+		} else if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			likeStruct(v.Field(i), m)
+			continue
+		}
+
+		fv := v.Field(i)
+		if fv.IsZero() {
+			continue
+		}
+
+		if value := like(fv); value != nil {
+			m[name] = value
+		}
+	}
+}
+
+// marshaled returns the JSON representation of a value as decoded by
+// encoding/json, or nil if the value cannot be marshaled.
+func marshaled(v any) any {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	var decoded any
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return nil
+	}
+
+	return decoded
+}
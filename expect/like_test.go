@@ -0,0 +1,73 @@
+package expect_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jefflinse/melatonin/expect"
+	"github.com/stretchr/testify/assert"
+)
+
+type address struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type account struct {
+	ID        expect.Predicate `json:"id"`
+	Name      string           `json:"name"`
+	Age       int              `json:"age"`
+	Admin     bool             `json:"admin"`
+	Secret    string           `json:"-"`
+	Address   *address         `json:"address"`
+	Tags      []string         `json:"tags"`
+	CreatedAt time.Time        `json:"created_at"`
+	Untagged  float64
+}
+
+func TestLike(t *testing.T) {
+	created := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	expected := expect.Like(account{
+		ID:        expect.UUID(),
+		Name:      "alice",
+		Secret:    "hunter2",
+		Address:   &address{City: "Paris"},
+		Tags:      []string{"a", "b"},
+		CreatedAt: created,
+		Untagged:  1.5,
+	})
+
+	m, ok := expected.(map[string]any)
+	if !assert.True(t, ok) {
+		return
+	}
+
+	assert.ElementsMatch(t, []string{"id", "name", "address", "tags", "created_at", "Untagged"}, keys(m))
+	assert.Equal(t, "alice", m["name"])
+	assert.Equal(t, map[string]any{"city": "Paris"}, m["address"])
+	assert.Equal(t, []any{"a", "b"}, m["tags"])
+	assert.Equal(t, "2022-01-02T03:04:05Z", m["created_at"])
+	assert.Equal(t, 1.5, m["Untagged"])
+
+	actual := map[string]any{
+		"id":         "3f2504e0-4f89-11d3-9a0c-0305e82c3301",
+		"name":       "alice",
+		"age":        float64(30),
+		"address":    map[string]any{"city": "Paris", "zip": "75001"},
+		"tags":       []any{"a", "b"},
+		"created_at": "2022-01-02T03:04:05Z",
+		"Untagged":   1.5,
+	}
+	assert.Empty(t, expect.CompareValues(expected, actual, false))
+
+	actual["id"] = "not-a-uuid"
+	assert.Len(t, expect.CompareValues(expected, actual, false), 1)
+}
+
+func keys(m map[string]any) []string {
+	k := []string{}
+	for key := range m {
+		k = append(k, key)
+	}
+	return k
+}
@@ -0,0 +1,53 @@
+package expect_test
+
+import (
+	"testing"
+
+	"github.com/jefflinse/melatonin/expect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNot(t *testing.T) {
+	previous := "usr_1"
+
+	for _, test := range []struct {
+		name     string
+		expected any
+		actual   any
+		wantErr  string
+	}{
+		{"different string", "a", "b", ""},
+		{"same string", "a", "a", "expected anything but a, got a"},
+		{"different int", 500, float64(200), ""},
+		{"same int", 500, float64(500), "expected anything but 500, got 500"},
+		{"different type", "1", float64(1), ""},
+		{"pointer resolved when evaluated", &previous, "usr_1", "expected anything but usr_1, got usr_1"},
+		{"predicate not matched", expect.String(), float64(1), ""},
+		{"predicate matched", expect.String(), "a", "expected value not to match, got a"},
+		{"object not matched", map[string]any{"id": "a"}, map[string]any{"id": "b"}, ""},
+		{"object matched as subset", map[string]any{"id": "a"}, map[string]any{"id": "a", "n": float64(1)},
+			"expected anything but map[id:a], got map[id:a n:1]"},
+		{"null", nil, nil, "expected anything but <nil>, got <nil>"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := expect.Not(test.expected)(test.actual)
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNotInBody(t *testing.T) {
+	previous := "usr_1"
+	expected := map[string]any{"id": expect.Not(&previous)}
+
+	assert.Empty(t, expect.CompareValues(expected, map[string]any{"id": "usr_2"}, false))
+
+	errs := expect.CompareValues(expected, map[string]any{"id": "usr_1"}, false)
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, "id: expected anything but usr_1, got usr_1", errs[0].Error())
+	}
+}
@@ -0,0 +1,44 @@
+package expect_test
+
+import (
+	"testing"
+
+	"github.com/jefflinse/melatonin/expect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPattern(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		pattern string
+		actual  any
+		wantErr string
+	}{
+		{"matches pattern", `^usr_[0-9]+$`, "usr_123", ""},
+		{"fails on mismatch", `^usr_[0-9]+$`, "grp_123", `expected to match pattern "^usr_[0-9]+$", got "grp_123"`},
+		{"fails on non-string", `^[0-9]+$`, 123, "expected string, got int: 123"},
+		{"fails on invalid pattern", `[`, "x", `invalid regex: "["`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := expect.Pattern(test.pattern)(test.actual)
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPatternInBody(t *testing.T) {
+	expected := map[string]any{
+		"user": map[string]any{"id": expect.Pattern(`^usr_[0-9]+$`)},
+	}
+
+	assert.Empty(t, expect.CompareValues(expected, map[string]any{"user": map[string]any{"id": "usr_42"}}, false))
+
+	errs := expect.CompareValues(expected, map[string]any{"user": map[string]any{"id": "grp_42"}}, false)
+	if assert.Len(t, errs, 1) {
+		assert.EqualError(t, errs[0], `user.id: expected to match pattern "^usr_[0-9]+$", got "grp_42"`)
+	}
+}
@@ -0,0 +1,98 @@
+package expect
+
+import (
+	"fmt"
+)
+
+// A Presence is an expected value that distinguishes a JSON object field that
+// is present with a null value from one that is absent altogether, which
+// predicates cannot do since both are seen as a nil value.
+type Presence int
+
+const (
+	presenceNull Presence = iota + 1
+	presenceMissing
+	presenceExists
+)
+
+// Null creates an expected value requiring a field to be present with a null
+// value.
+func Null() Presence {
+	return presenceNull
+}
+
+// Exists creates an expected value requiring a field to be present with any
+// value, including null.
+func Exists() Presence {
+	return presenceExists
+}
+
+// Anything is a synonym for Exists(), which reads naturally when marking fields
+// with dynamic values, such as IDs and timestamps, whose values are ignored in
+// an exactly matched object, for example:
+//
+//	tc.ExpectExactBody(json.Object{
+//		"id":         expect.Anything(),
+//		"name":       "Ada",
+//		"created_at": expect.Anything(),
+//	})
+func Anything() Presence {
+	return Exists()
+}
+
+// Missing creates an expected value requiring a field to be absent. A field
+// expected to be missing does not count toward the fields of an exactly matched
+// object.
+func Missing() Presence {
+	return presenceMissing
+}
+
+// Absent is a synonym for Missing(), which reads naturally when asserting that
+// sensitive fields don't leak into a response, for example:
+//
+//	json.Object{
+//		"password_hash": expect.Absent(),
+//		"internal_id":   expect.Absent(),
+//	}
+func Absent() Presence {
+	return Missing()
+}
+
+// matchField checks the value of an object field and whether it is present.
+func (p Presence) matchField(actual any, present bool) *FailedPredicateError {
+	switch p {
+	case presenceNull:
+		if !present {
+			return failedPredicate(fmt.Errorf("expected null, got nothing"))
+		}
+		return p.matchValue(actual)
+
+	case presenceMissing:
+		if present {
+			return failedPredicate(fmt.Errorf("expected field to be missing, got %T: %+v", actual, actual))
+		}
+
+	case presenceExists:
+		if !present {
+			return failedPredicate(fmt.Errorf("expected field to be present, got nothing"))
+		}
+	}
+
+	return nil
+}
+
+// matchValue checks a value that is not an object field, such as an element of
+// an array, whose presence is implied.
+func (p Presence) matchValue(actual any) *FailedPredicateError {
+	switch p {
+	case presenceNull:
+		if actual != nil {
+			return failedPredicate(fmt.Errorf("expected null, got %T: %+v", actual, actual))
+		}
+
+	case presenceMissing:
+		return failedPredicate(fmt.Errorf("expected nothing, got %T: %+v", actual, actual))
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package expect_test
+
+import (
+	"testing"
+
+	"github.com/jefflinse/melatonin/expect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresence(t *testing.T) {
+	actual := map[string]any{"id": "a", "deleted_at": nil}
+
+	for _, test := range []struct {
+		name     string
+		expected map[string]any
+		exact    bool
+		wantErrs []string
+	}{
+		{"Null matches present null", map[string]any{"deleted_at": expect.Null()}, false, nil},
+		{"Null fails on missing field", map[string]any{"other": expect.Null()}, false, []string{"other: expected null, got nothing"}},
+		{"Null fails on non-null value", map[string]any{"id": expect.Null()}, false, []string{"id: expected null, got string: a"}},
+		{"Missing matches absent field", map[string]any{"other": expect.Missing()}, false, nil},
+		{"Missing fails on present null", map[string]any{"deleted_at": expect.Missing()}, false, []string{"deleted_at: expected field to be missing, got <nil>: <nil>"}},
+		{"Absent matches absent field", map[string]any{"password": expect.Absent()}, false, nil},
+		{"Absent fails on present field", map[string]any{"id": expect.Absent()}, false, []string{"id: expected field to be missing, got string: a"}},
+		{"Exists matches present value", map[string]any{"id": expect.Exists()}, false, nil},
+		{"Exists matches present null", map[string]any{"deleted_at": expect.Exists()}, false, nil},
+		{"Exists fails on missing field", map[string]any{"other": expect.Exists()}, false, []string{"other: expected field to be present, got nothing"}},
+		{"Exists counts toward exact fields", map[string]any{"id": expect.Exists()}, true, []string{": expected 1 fields, got 2:\n{\n  \"deleted_at\": null,\n  \"id\": \"a\"\n}"}},
+		{"Anything matches present value", map[string]any{"id": expect.Anything()}, false, nil},
+		{"Anything fails on missing field", map[string]any{"other": expect.Anything()}, false, []string{"other: expected field to be present, got nothing"}},
+		{"Anything ignores values of exact fields", map[string]any{"id": expect.Anything(), "deleted_at": expect.Anything()}, true, nil},
+		{"Missing does not count toward exact fields", map[string]any{"id": "a", "deleted_at": expect.Null(), "other": expect.Missing()}, true, nil},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			errs := []string{}
+			for _, err := range expect.CompareValues(test.expected, actual, test.exact) {
+				errs = append(errs, err.Error())
+			}
+			if test.wantErrs == nil {
+				assert.Empty(t, errs)
+			} else {
+				assert.Equal(t, test.wantErrs, errs)
+			}
+		})
+	}
+
+	assert.Empty(t, expect.CompareValues([]any{expect.Null()}, []any{nil}, false))
+	assert.Len(t, expect.CompareValues([]any{expect.Null()}, []any{"a"}, false), 1)
+	assert.Empty(t, expect.CompareValues([]any{expect.Exists()}, []any{nil}, false))
+	assert.Empty(t, expect.CompareValues([]any{expect.Anything(), "b"}, []any{"a", "b"}, true))
+}
@@ -0,0 +1,450 @@
+package expect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// schemaFormats are the predicates used to validate the "format" keyword.
+var schemaFormats = map[string]Predicate{
+	"date-time": func(actual any) error {
+		s, _ := actual.(string)
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			return fmt.Errorf("expected RFC 3339 date-time, got %q", s)
+		}
+		return nil
+	},
+	"email": Email(),
+	"ipv4":  IPv4(),
+	"ipv6":  IPv6(),
+	"uri":   URL(),
+	"uuid":  UUID(),
+}
+
+// Schema creates a predicate requiring a value to be valid against a JSON Schema.
+// The schema is either a decoded JSON document, such as a map[string]any, or the
+// JSON text of the schema as a string or []byte.
+//
+// A subset of JSON Schema is supported, covering the keywords used to describe
+// the structure of typical API payloads: type, enum, const, the numeric
+// (minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf), string
+// (minLength, maxLength, pattern, format), array (items, minItems, maxItems,
+// uniqueItems), and object (properties, required, additionalProperties,
+// patternProperties, minProperties, maxProperties) keywords, the allOf, anyOf,
+// oneOf, and not combinators, and local references such as
+// "#/definitions/user" or "#/$defs/user". Supported formats are date-time,
+// email, ipv4, ipv6, uri, and uuid; other formats are not validated.
+func Schema(schema any) Predicate {
+	return func(actual any) error {
+		violations, err := SchemaViolations(schema, actual)
+		if err != nil {
+			return err
+		}
+
+		if len(violations) > 0 {
+			messages := make([]string, len(violations))
+			for i, violation := range violations {
+				messages[i] = violation.Error()
+			}
+			return errors.New(strings.Join(messages, "; "))
+		}
+
+		return nil
+	}
+}
+
+// SchemaViolations validates a value against a JSON Schema, returning an error
+// for each violation of the schema, each identifying the location of the
+// violating value within the value. See Schema() for the supported keywords.
+//
+// An error is returned if the schema itself is invalid.
+func SchemaViolations(schema, value any) ([]*FailedPredicateError, error) {
+	switch s := schema.(type) {
+	case string:
+		schema = []byte(s)
+	case json.RawMessage:
+		schema = []byte(s)
+	}
+
+	if b, ok := schema.([]byte); ok {
+		if err := json.Unmarshal(b, &schema); err != nil {
+			return nil, fmt.Errorf("invalid schema: %w", err)
+		}
+	}
+
+	v := &schemaValidator{root: schema}
+	violations := v.validate(schema, value)
+	if v.err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", v.err)
+	}
+
+	return violations, nil
+}
+
+// A schemaValidator validates values against the subschemas of a root schema.
+type schemaValidator struct {
+	root any
+	err  error
+}
+
+func (v *schemaValidator) validate(schema, value any) []*FailedPredicateError {
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			return []*FailedPredicateError{failedPredicate(fmt.Errorf("expected nothing, got %+v", value))}
+		}
+		return nil
+
+	case map[string]any:
+		return v.validateObjectSchema(s, value)
+
+	default:
+		v.fail(fmt.Errorf("expected schema to be an object or boolean, got %T", schema))
+		return nil
+	}
+}
+
+func (v *schemaValidator) validateObjectSchema(schema map[string]any, value any) []*FailedPredicateError {
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved, err := v.resolve(ref)
+		if err != nil {
+			v.fail(err)
+			return nil
+		}
+		return v.validate(resolved, value)
+	}
+
+	var errs []*FailedPredicateError
+	violation := func(format string, args ...any) {
+		errs = append(errs, failedPredicate(fmt.Errorf(format, args...)))
+	}
+
+	if t, ok := schema["type"]; ok {
+		types := []any{t}
+		if list, ok := t.([]any); ok {
+			types = list
+		}
+
+		matched := false
+		names := make([]string, len(types))
+		for i, t := range types {
+			names[i], _ = t.(string)
+			if isSchemaType(names[i], value) {
+				matched = true
+			}
+		}
+
+		if !matched {
+			violation("expected type %s, got %s: %+v", strings.Join(names, " or "), schemaTypeOf(value), value)
+			return errs
+		}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, allowed := range enum {
+			if reflect.DeepEqual(allowed, value) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			violation("expected one of %+v, got %+v", enum, value)
+		}
+	}
+
+	if expected, ok := schema["const"]; ok && !reflect.DeepEqual(expected, value) {
+		violation("expected %+v, got %+v", expected, value)
+	}
+
+	switch actual := value.(type) {
+	case float64:
+		errs = append(errs, v.validateNumber(schema, actual)...)
+	case string:
+		errs = append(errs, v.validateString(schema, actual)...)
+	case []any:
+		errs = append(errs, v.validateArray(schema, actual)...)
+	case map[string]any:
+		errs = append(errs, v.validateObject(schema, actual)...)
+	}
+
+	if all, ok := schema["allOf"].([]any); ok {
+		for _, subschema := range all {
+			errs = append(errs, v.validate(subschema, value)...)
+		}
+	}
+
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		if v.countMatches(anyOf, value) == 0 {
+			violation("expected to match at least one schema of anyOf")
+		}
+	}
+
+	if oneOf, ok := schema["oneOf"].([]any); ok {
+		if n := v.countMatches(oneOf, value); n != 1 {
+			violation("expected to match exactly one schema of oneOf, matched %d", n)
+		}
+	}
+
+	if not, ok := schema["not"]; ok {
+		if len(v.validate(not, value)) == 0 {
+			violation("expected not to match schema of not")
+		}
+	}
+
+	return errs
+}
+
+func (v *schemaValidator) validateNumber(schema map[string]any, actual float64) []*FailedPredicateError {
+	var errs []*FailedPredicateError
+	if min, ok := schema["minimum"].(float64); ok && actual < min {
+		errs = append(errs, failedPredicate(fmt.Errorf("expected at least %v, got %v", min, actual)))
+	}
+
+	if max, ok := schema["maximum"].(float64); ok && actual > max {
+		errs = append(errs, failedPredicate(fmt.Errorf("expected at most %v, got %v", max, actual)))
+	}
+
+	if min, ok := schema["exclusiveMinimum"].(float64); ok && actual <= min {
+		errs = append(errs, failedPredicate(fmt.Errorf("expected greater than %v, got %v", min, actual)))
+	}
+
+	if max, ok := schema["exclusiveMaximum"].(float64); ok && actual >= max {
+		errs = append(errs, failedPredicate(fmt.Errorf("expected less than %v, got %v", max, actual)))
+	}
+
+	if divisor, ok := schema["multipleOf"].(float64); ok && divisor > 0 {
+		if quotient := actual / divisor; quotient != math.Trunc(quotient) {
+			errs = append(errs, failedPredicate(fmt.Errorf("expected multiple of %v, got %v", divisor, actual)))
+		}
+	}
+
+	return errs
+}
+
+func (v *schemaValidator) validateString(schema map[string]any, actual string) []*FailedPredicateError {
+	var errs []*FailedPredicateError
+	length := utf8.RuneCountInString(actual)
+	if min, ok := schema["minLength"].(float64); ok && float64(length) < min {
+		errs = append(errs, failedPredicate(fmt.Errorf("expected length of at least %v, got %d", min, length)))
+	}
+
+	if max, ok := schema["maxLength"].(float64); ok && float64(length) > max {
+		errs = append(errs, failedPredicate(fmt.Errorf("expected length of at most %v, got %d", max, length)))
+	}
+
+	if pattern, ok := schema["pattern"].(string); ok {
+		r, err := regexp.Compile(pattern)
+		if err != nil {
+			v.fail(fmt.Errorf("invalid pattern %q: %w", pattern, err))
+		} else if !r.MatchString(actual) {
+			errs = append(errs, failedPredicate(fmt.Errorf("expected to match pattern %q, got %q", pattern, actual)))
+		}
+	}
+
+	if format, ok := schema["format"].(string); ok {
+		if predicate, ok := schemaFormats[format]; ok {
+			if err := predicate(actual); err != nil {
+				errs = append(errs, failedPredicate(err))
+			}
+		}
+	}
+
+	return errs
+}
+
+func (v *schemaValidator) validateArray(schema map[string]any, actual []any) []*FailedPredicateError {
+	var errs []*FailedPredicateError
+	if min, ok := schema["minItems"].(float64); ok && float64(len(actual)) < min {
+		errs = append(errs, failedPredicate(fmt.Errorf("expected at least %v items, got %d", min, len(actual))))
+	}
+
+	if max, ok := schema["maxItems"].(float64); ok && float64(len(actual)) > max {
+		errs = append(errs, failedPredicate(fmt.Errorf("expected at most %v items, got %d", max, len(actual))))
+	}
+
+	if unique, ok := schema["uniqueItems"].(bool); ok && unique {
+	outer:
+		for i := range actual {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(actual[i], actual[j]) {
+					errs = append(errs, failedPredicate(fmt.Errorf("expected unique items, item %d duplicates item %d", i, j)))
+					break outer
+				}
+			}
+		}
+	}
+
+	if items, ok := schema["items"]; ok {
+		for i, item := range actual {
+			itemSchema := items
+			if tuple, ok := items.([]any); ok {
+				if i >= len(tuple) {
+					break
+				}
+				itemSchema = tuple[i]
+			}
+
+			for _, err := range v.validate(itemSchema, item) {
+				err.PushField("[" + strconv.Itoa(i) + "]")
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errs
+}
+
+func (v *schemaValidator) validateObject(schema map[string]any, actual map[string]any) []*FailedPredicateError {
+	var errs []*FailedPredicateError
+	if min, ok := schema["minProperties"].(float64); ok && float64(len(actual)) < min {
+		errs = append(errs, failedPredicate(fmt.Errorf("expected at least %v properties, got %d", min, len(actual))))
+	}
+
+	if max, ok := schema["maxProperties"].(float64); ok && float64(len(actual)) > max {
+		errs = append(errs, failedPredicate(fmt.Errorf("expected at most %v properties, got %d", max, len(actual))))
+	}
+
+	if required, ok := schema["required"].([]any); ok {
+		for _, key := range required {
+			if name, ok := key.(string); ok {
+				if _, present := actual[name]; !present {
+					err := failedPredicate(errors.New("expected required property, got nothing"))
+					err.PushField(name)
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	patterns, _ := schema["patternProperties"].(map[string]any)
+	additional, hasAdditional := schema["additionalProperties"]
+
+	keys := make([]string, 0, len(actual))
+	for key := range actual {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var subschemas []any
+		if subschema, ok := properties[key]; ok {
+			subschemas = append(subschemas, subschema)
+		}
+
+		for pattern, subschema := range patterns {
+			r, err := regexp.Compile(pattern)
+			if err != nil {
+				v.fail(fmt.Errorf("invalid pattern %q: %w", pattern, err))
+				continue
+			}
+
+			if r.MatchString(key) {
+				subschemas = append(subschemas, subschema)
+			}
+		}
+
+		if len(subschemas) == 0 && hasAdditional {
+			subschemas = append(subschemas, additional)
+		}
+
+		for _, subschema := range subschemas {
+			for _, err := range v.validate(subschema, actual[key]) {
+				err.PushField(key)
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errs
+}
+
+// countMatches returns the number of schemas that a value is valid against.
+func (v *schemaValidator) countMatches(schemas []any, value any) int {
+	n := 0
+	for _, schema := range schemas {
+		if len(v.validate(schema, value)) == 0 {
+			n++
+		}
+	}
+
+	return n
+}
+
+// resolve resolves a local reference, such as "#/definitions/user", to the
+// subschema it refers to.
+func (v *schemaValidator) resolve(ref string) (any, error) {
+	if ref != "#" && !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported reference %q: only local references are supported", ref)
+	}
+
+	doc := v.root
+	for _, token := range strings.Split(strings.TrimPrefix(strings.TrimPrefix(ref, "#"), "/"), "/") {
+		if token == "" {
+			continue
+		}
+
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		object, ok := doc.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unresolvable reference %q", ref)
+		}
+
+		if doc, ok = object[token]; !ok {
+			return nil, fmt.Errorf("unresolvable reference %q", ref)
+		}
+	}
+
+	return doc, nil
+}
+
+func (v *schemaValidator) fail(err error) {
+	if v.err == nil {
+		v.err = err
+	}
+}
+
+// isSchemaType returns true if a decoded JSON value is of a JSON Schema type.
+func isSchemaType(t string, value any) bool {
+	switch t {
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return schemaTypeOf(value) == t
+	}
+}
+
+// schemaTypeOf returns the JSON Schema type of a decoded JSON value.
+func schemaTypeOf(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,106 @@
+package expect_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jefflinse/melatonin/expect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const userSchema = `{
+	"type": "object",
+	"required": ["id", "email", "roles"],
+	"additionalProperties": false,
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"email": {"type": "string", "format": "email"},
+		"name": {"type": ["string", "null"], "maxLength": 5},
+		"roles": {"type": "array", "items": {"$ref": "#/$defs/role"}, "minItems": 1, "uniqueItems": true}
+	},
+	"$defs": {
+		"role": {"enum": ["admin", "viewer"]}
+	}
+}`
+
+func decode(t *testing.T, s string) any {
+	var v any
+	require.NoError(t, json.Unmarshal([]byte(s), &v))
+	return v
+}
+
+func TestSchemaViolations(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{"valid document", `{"id": 1, "email": "a@example.com", "name": null, "roles": ["admin"]}`, nil},
+		{"wrong root type", `[]`, []string{": expected type object, got array: []"}},
+		{"missing required property", `{"id": 1, "email": "a@example.com"}`, []string{"roles: expected required property, got nothing"}},
+		{"additional property", `{"id": 1, "email": "a@example.com", "roles": ["admin"], "x": 1}`, []string{"x: expected nothing, got 1"}},
+		{"wrong property type", `{"id": 1.5, "email": "a@example.com", "roles": ["admin"]}`, []string{"id: expected type integer, got number: 1.5"}},
+		{"below minimum", `{"id": 0, "email": "a@example.com", "roles": ["admin"]}`, []string{"id: expected at least 1, got 0"}},
+		{"invalid format", `{"id": 1, "email": "nope", "roles": ["admin"]}`, []string{`email: expected email address, got "nope"`}},
+		{"too long", `{"id": 1, "email": "a@example.com", "name": "abcdef", "roles": ["admin"]}`, []string{"name: expected length of at most 5, got 6"}},
+		{"item not in enum", `{"id": 1, "email": "a@example.com", "roles": ["admin", "root"]}`, []string{"roles[1]: expected one of [admin viewer], got root"}},
+		{"duplicate items", `{"id": 1, "email": "a@example.com", "roles": ["admin", "admin"]}`, []string{"roles: expected unique items, item 1 duplicates item 0"}},
+		{"too few items", `{"id": 1, "email": "a@example.com", "roles": []}`, []string{"roles: expected at least 1 items, got 0"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			violations, err := expect.SchemaViolations(userSchema, decode(t, test.value))
+			require.NoError(t, err)
+
+			var got []string
+			for _, violation := range violations {
+				got = append(got, violation.Error())
+			}
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestSchemaCombinators(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		schema  string
+		value   string
+		wantErr string
+	}{
+		{"anyOf matches", `{"anyOf": [{"type": "string"}, {"type": "integer"}]}`, `3`, ""},
+		{"anyOf fails", `{"anyOf": [{"type": "string"}, {"type": "integer"}]}`, `true`, ": expected to match at least one schema of anyOf"},
+		{"oneOf fails on several matches", `{"oneOf": [{"type": "number"}, {"type": "integer"}]}`, `3`, ": expected to match exactly one schema of oneOf, matched 2"},
+		{"allOf fails", `{"allOf": [{"minimum": 1}, {"maximum": 2}]}`, `3`, ": expected at most 2, got 3"},
+		{"not fails", `{"not": {"type": "null"}}`, `null`, ": expected not to match schema of not"},
+		{"const matches", `{"const": {"a": [1]}}`, `{"a": [1]}`, ""},
+		{"pattern fails", `{"pattern": "^[a-z]+$"}`, `"ABC"`, `: expected to match pattern "^[a-z]+$", got "ABC"`},
+		{"exclusive maximum fails", `{"exclusiveMaximum": 3}`, `3`, ": expected less than 3, got 3"},
+		{"multipleOf fails", `{"multipleOf": 0.5}`, `0.75`, ": expected multiple of 0.5, got 0.75"},
+		{"patternProperties applies", `{"patternProperties": {"^x-": {"type": "string"}}}`, `{"x-a": 1, "b": 1}`, "x-a: expected type string, got number: 1"},
+		{"false schema fails", `false`, `1`, ": expected nothing, got 1"},
+		{"tuple items apply by position", `{"items": [{"type": "string"}, {"type": "integer"}]}`, `["a", "b", 3]`, "[1]: expected type integer, got string: b"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := expect.Schema(test.schema)(decode(t, test.value))
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSchemaInvalid(t *testing.T) {
+	_, err := expect.SchemaViolations(`{"type":`, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid schema")
+	}
+
+	_, err = expect.SchemaViolations(`{"$ref": "#/definitions/missing"}`, nil)
+	assert.EqualError(t, err, `invalid schema: unresolvable reference "#/definitions/missing"`)
+
+	_, err = expect.SchemaViolations(`{"$ref": "http://example.com/schema.json"}`, nil)
+	assert.EqualError(t, err, `invalid schema: unsupported reference "http://example.com/schema.json": only local references are supported`)
+}
@@ -0,0 +1,65 @@
+package expect
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EqualFold creates a predicate requiring a value to be a string that is equal
+// to an expected string under Unicode case folding.
+func EqualFold(expected string) Predicate {
+	return String().Then(func(actual any) error {
+		s, _ := actual.(string)
+		if !strings.EqualFold(expected, s) {
+			return fmt.Errorf("expected %q (case-insensitive), got %q", expected, s)
+		}
+
+		return nil
+	})
+}
+
+// HasPrefix creates a predicate requiring a value to be a string that begins
+// with a prefix.
+func HasPrefix(prefix string) Predicate {
+	return String().Then(func(actual any) error {
+		s, _ := actual.(string)
+		if !strings.HasPrefix(s, prefix) {
+			return fmt.Errorf("expected string with prefix %q, got %q", prefix, s)
+		}
+
+		return nil
+	})
+}
+
+// HasSuffix creates a predicate requiring a value to be a string that ends
+// with a suffix.
+func HasSuffix(suffix string) Predicate {
+	return String().Then(func(actual any) error {
+		s, _ := actual.(string)
+		if !strings.HasSuffix(s, suffix) {
+			return fmt.Errorf("expected string with suffix %q, got %q", suffix, s)
+		}
+
+		return nil
+	})
+}
+
+// OneOf creates a predicate requiring a value to be a string matching one of a
+// set of values.
+func OneOf(values ...string) Predicate {
+	return String(values...)
+}
+
+// TrimmedEqual creates a predicate requiring a value to be a string that is
+// equal to an expected string once leading and trailing whitespace is removed
+// from both.
+func TrimmedEqual(expected string) Predicate {
+	return String().Then(func(actual any) error {
+		s, _ := actual.(string)
+		if strings.TrimSpace(expected) != strings.TrimSpace(s) {
+			return fmt.Errorf("expected %q (ignoring surrounding whitespace), got %q", expected, s)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,39 @@
+package expect_test
+
+import (
+	"testing"
+
+	"github.com/jefflinse/melatonin/expect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringMatchers(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		predicate expect.Predicate
+		actual    any
+		wantErr   string
+	}{
+		{"EqualFold matches different case", expect.EqualFold("Active"), "ACTIVE", ""},
+		{"EqualFold fails on different value", expect.EqualFold("active"), "inactive", `expected "active" (case-insensitive), got "inactive"`},
+		{"EqualFold fails on non-string", expect.EqualFold("active"), 42, "expected string, got int: 42"},
+		{"TrimmedEqual matches padded value", expect.TrimmedEqual("foo"), "  foo\n", ""},
+		{"TrimmedEqual fails on different value", expect.TrimmedEqual("foo"), " bar ", `expected "foo" (ignoring surrounding whitespace), got " bar "`},
+		{"OneOf matches listed value", expect.OneOf("a", "b"), "b", ""},
+		{"OneOf fails on unlisted value", expect.OneOf("a", "b"), "c", `expected one of [a b], got "c"`},
+		{"HasPrefix matches", expect.HasPrefix("usr_"), "usr_42", ""},
+		{"HasPrefix fails", expect.HasPrefix("usr_"), "org_42", `expected string with prefix "usr_", got "org_42"`},
+		{"HasPrefix fails on non-string", expect.HasPrefix("usr_"), 42, "expected string, got int: 42"},
+		{"HasSuffix matches", expect.HasSuffix(".png"), "a.png", ""},
+		{"HasSuffix fails", expect.HasSuffix(".png"), "a.jpg", `expected string with suffix ".png", got "a.jpg"`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.predicate(test.actual)
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
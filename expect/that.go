@@ -0,0 +1,152 @@
+package expect
+
+import (
+	"encoding/json"
+	"reflect"
+
+	mtjson "github.com/jefflinse/melatonin/json"
+)
+
+// That matches an actual value against an expected value, which can be
+// anything that can be expected of a response body, such as a literal, a
+// predicate, or a JSON object or array. It returns the failures of the match,
+// or nil if the actual value matches.
+//
+// That makes the matchers usable outside of HTTP tests, for example:
+//
+//	for _, err := range expect.That(user, map[string]any{"name": expect.String()}) {
+//		t.Error(err)
+//	}
+//
+// Both values are first converted to their JSON representation, so that Go
+// values such as structs, typed slices, and integers are matched the same way
+// as the decoded JSON body of a response.
+func That(actual, expected any) []error {
+	var errs []error
+	for _, err := range CompareValues(normalizeExpected(expected), normalizeActual(actual), false) {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// normalizeActual converts a Go value into the form of a decoded JSON value.
+// Values that cannot be represented as JSON are returned unchanged.
+func normalizeActual(actual any) any {
+	b, err := json.Marshal(actual)
+	if err != nil {
+		return actual
+	}
+
+	var decoded any
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return actual
+	}
+
+	return decoded
+}
+
+// normalizeExpected converts the Go values of an expected value into the types
+// matched by the comparison, leaving predicates and other matchers intact.
+func normalizeExpected(expected any) any {
+	switch v := expected.(type) {
+	case nil, bool, *bool, float64, *float64, int64, *int64, string, *string,
+		Presence, Predicate, func(any) error:
+		return v
+
+	case mtjson.Object:
+		return normalizeExpected(map[string]any(v))
+
+	case mtjson.Array:
+		return normalizeExpected([]any(v))
+
+	case UnorderedArray:
+		return UnorderedArray(normalizeElements(v))
+
+	case Containment:
+		return Containment(normalizeElements(v))
+
+	case OptionalValue:
+		return v
+
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for key, value := range v {
+			m[key] = normalizeExpected(value)
+		}
+		return m
+
+	case []any:
+		return normalizeElements(v)
+
+	case []byte:
+		// bytes are represented in JSON as a base64 string
+		return normalizeActual(v)
+	}
+
+	value := reflect.ValueOf(expected)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int()
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(value.Uint())
+
+	case reflect.Float32, reflect.Float64:
+		return value.Float()
+
+	case reflect.Bool:
+		return value.Bool()
+
+	case reflect.String:
+		return value.String()
+
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return nil
+		}
+
+		elements := make([]any, value.Len())
+		for i := range elements {
+			elements[i] = normalizeExpected(value.Index(i).Interface())
+		}
+		return elements
+
+	case reflect.Map:
+		if value.Type().Key().Kind() == reflect.String {
+			if value.IsNil() {
+				return nil
+			}
+
+			m := make(map[string]any, value.Len())
+			iter := value.MapRange()
+			for iter.Next() {
+				m[iter.Key().String()] = normalizeExpected(iter.Value().Interface())
+			}
+			return m
+		}
+
+	case reflect.Ptr:
+		if value.IsNil() {
+			return nil
+		}
+		return normalizeExpected(value.Elem().Interface())
+	}
+
+	// structs and other values are matched by their JSON representation
+	if normalized := normalizeActual(expected); !reflect.DeepEqual(normalized, expected) {
+		return normalizeExpected(normalized)
+	}
+
+	return expected
+}
+
+// normalizeElements normalizes each of a set of expected elements.
+func normalizeElements(elements []any) []any {
+	normalized := make([]any, len(elements))
+	for i, element := range elements {
+		normalized[i] = normalizeExpected(element)
+	}
+
+	return normalized
+}
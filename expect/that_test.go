@@ -0,0 +1,55 @@
+package expect_test
+
+import (
+	"testing"
+
+	"github.com/jefflinse/melatonin/expect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThat(t *testing.T) {
+	type address struct {
+		City string `json:"city"`
+	}
+
+	type user struct {
+		ID      int      `json:"id"`
+		Name    string   `json:"name"`
+		Tags    []string `json:"tags"`
+		Address *address `json:"address,omitempty"`
+	}
+
+	alice := user{ID: 1, Name: "alice", Tags: []string{"admin", "ops"}, Address: &address{City: "Paris"}}
+
+	for _, test := range []struct {
+		name     string
+		actual   any
+		expected any
+		want     []string
+	}{
+		{"literal", "alice", "alice", nil},
+		{"mismatched literal", "alice", "bob",
+			[]string{": expected bob, got alice"}},
+		{"go integers", 42, 42, nil},
+		{"mismatched go integers", 42, uint8(7),
+			[]string{": expected 7, got 42"}},
+		{"predicate", 3.5, expect.Float(), nil},
+		{"struct against object subset", alice, map[string]any{"name": "alice", "tags": expect.Contains("ops")}, nil},
+		{"struct against struct", alice, alice, nil},
+		{"mismatched nested field", alice, map[string]any{"address": map[string]any{"city": "Rome"}},
+			[]string{"address.city: expected Rome, got Paris"}},
+		{"typed slice", []int{1, 2, 3}, []int{1, 2, 3}, nil},
+		{"unordered typed slice", []string{"b", "a"}, expect.Unordered([]any{"a", "b"}), nil},
+		{"missing field", alice, map[string]any{"email": expect.Missing()}, nil},
+		{"bytes", []byte("hello"), []byte("hello"), nil},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, err := range expect.That(test.actual, test.expected) {
+				got = append(got, err.Error())
+			}
+
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package expect
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Transform is a function that converts a test result value into another
+// value, such as parsing a string field into a number, before it is matched or
+// bound.
+type Transform func(any) (any, error)
+
+// ApplyTransforms applies a sequence of transforms to a value.
+func ApplyTransforms(value any, transforms ...Transform) (any, error) {
+	for _, transform := range transforms {
+		var err error
+		if value, err = transform(value); err != nil {
+			return nil, err
+		}
+	}
+
+	return value, nil
+}
+
+// Lower creates a transform converting a string to lower case.
+func Lower() Transform {
+	return stringTransform(func(s string) (any, error) {
+		return strings.ToLower(s), nil
+	})
+}
+
+// ParseBool creates a transform parsing a string as a bool.
+func ParseBool() Transform {
+	return stringTransform(func(s string) (any, error) {
+		return strconv.ParseBool(s)
+	})
+}
+
+// ParseFloat creates a transform parsing a string as a float64.
+func ParseFloat() Transform {
+	return stringTransform(func(s string) (any, error) {
+		return strconv.ParseFloat(s, 64)
+	})
+}
+
+// ParseInt creates a transform parsing a string as a base 10 int64.
+func ParseInt() Transform {
+	return stringTransform(func(s string) (any, error) {
+		return strconv.ParseInt(s, 10, 64)
+	})
+}
+
+// ParseTime creates a transform parsing a string as a time.Time using a layout,
+// such as time.RFC1123.
+func ParseTime(layout string) Transform {
+	return stringTransform(func(s string) (any, error) {
+		return time.Parse(layout, s)
+	})
+}
+
+// Trim creates a transform removing leading and trailing whitespace from a
+// string.
+func Trim() Transform {
+	return stringTransform(func(s string) (any, error) {
+		return strings.TrimSpace(s), nil
+	})
+}
+
+// stringTransform creates a transform that requires its input to be a string.
+func stringTransform(fn func(string) (any, error)) Transform {
+	return func(value any) (any, error) {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string, got %T: %+v", value, value)
+		}
+
+		return fn(s)
+	}
+}
@@ -0,0 +1,55 @@
+package expect
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// As creates a predicate that decodes a value into a Go type, honoring its json
+// struct tags, and validates the decoded value, for example:
+//
+//	tc.ExpectBody(expect.As(func(u User) error {
+//		if u.Age < 18 {
+//			return fmt.Errorf("expected adult, got age %d", u.Age)
+//		}
+//		return nil
+//	}))
+//
+// A nil validation function only requires that the value can be decoded.
+// Fields present in the value but not in the Go type are ignored; to reject
+// them, use AsStrict().
+func As[T any](validate func(T) error) Predicate {
+	return decodeAs(validate, false)
+}
+
+// AsStrict is like As, but additionally fails if the value contains any fields
+// not present in the Go type.
+func AsStrict[T any](validate func(T) error) Predicate {
+	return decodeAs(validate, true)
+}
+
+func decodeAs[T any](validate func(T) error, strict bool) Predicate {
+	return func(actual any) error {
+		var v T
+		b, err := json.Marshal(actual)
+		if err != nil {
+			return fmt.Errorf("unable to decode %T into %T: %w", actual, v, err)
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(b))
+		if strict {
+			decoder.DisallowUnknownFields()
+		}
+
+		if err := decoder.Decode(&v); err != nil {
+			return fmt.Errorf("unable to decode into %T: %w", v, err)
+		}
+
+		if validate != nil {
+			return validate(v)
+		}
+
+		return nil
+	}
+}
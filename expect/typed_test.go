@@ -0,0 +1,46 @@
+package expect_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jefflinse/melatonin/expect"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func TestAs(t *testing.T) {
+	adult := func(u user) error {
+		if u.Age < 18 {
+			return fmt.Errorf("expected adult, got age %d", u.Age)
+		}
+		return nil
+	}
+
+	for _, test := range []struct {
+		name      string
+		predicate expect.Predicate
+		actual    any
+		wantErr   string
+	}{
+		{"As decodes and validates", expect.As(adult), map[string]any{"name": "a", "age": float64(30)}, ""},
+		{"As ignores unknown fields", expect.As(adult), map[string]any{"age": float64(30), "extra": true}, ""},
+		{"As fails validation", expect.As(adult), map[string]any{"age": float64(10)}, "expected adult, got age 10"},
+		{"As fails on wrong type", expect.As(adult), map[string]any{"age": "old"}, "unable to decode into expect_test.user: json: cannot unmarshal string into Go struct field user.age of type int"},
+		{"As without validation", expect.As[user](nil), map[string]any{"name": "a"}, ""},
+		{"AsStrict rejects unknown fields", expect.AsStrict(adult), map[string]any{"age": float64(30), "extra": true}, `unable to decode into expect_test.user: json: unknown field "extra"`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.predicate(test.actual)
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package expect
+
+import (
+	"fmt"
+)
+
+// An UnorderedArray is an expected JSON array whose elements may appear in any
+// order in the actual array.
+type UnorderedArray []any
+
+// Unordered creates an expected JSON array that is matched as a set rather than
+// element by element, for APIs that return lists in an unspecified order. Each
+// expected element must match a different element of the actual array. As with
+// other arrays, the actual array may contain additional elements unless the
+// body is matched exactly.
+func Unordered(elements []any) UnorderedArray {
+	return UnorderedArray(elements)
+}
+
+// compareUnorderedValues compares an expected unordered array to an actual
+// array, pairing each expected element with a distinct matching actual element.
+func (c *comparer) compareUnorderedValues(expected UnorderedArray, actual any) []*FailedPredicateError {
+	errs := []*FailedPredicateError{}
+
+	a, ok := actual.([]any)
+	if !ok {
+		errs = append(errs, wrongTypeError([]any(expected), actual))
+		return errs
+	}
+
+	if lengthErrs := c.compareSliceLengths(len(expected), a); len(lengthErrs) > 0 {
+		return lengthErrs
+	}
+
+	return c.compareElementSet(expected, a)
+}
+
+// compareElementSet compares a set of expected elements to the elements of an
+// actual array, requiring each expected element to match a distinct actual
+// element, regardless of position.
+func (c *comparer) compareElementSet(expected, actual []any) []*FailedPredicateError {
+	errs := []*FailedPredicateError{}
+	matchedBy, matched := c.pairElements(expected, actual)
+	for i, v := range expected {
+		if !matched[i] {
+			errs = append(errs, failedPredicate(fmt.Errorf("expected an element matching %+v, got none", v)))
+		}
+	}
+
+	// compare the paired elements again to collect their warnings
+	for j, i := range matchedBy {
+		if i >= 0 {
+			c.path = append(c.path, fmt.Sprintf("[%d]", j))
+			c.compare(expected[i], actual[j])
+			c.path = c.path[:len(c.path)-1]
+		}
+	}
+
+	return errs
+}
+
+// pairElements pairs as many expected elements as possible with distinct actual
+// elements that they match, returning the index of the expected element paired
+// with each actual element, or -1, and whether each expected element is paired.
+func (c *comparer) pairElements(expected, actual []any) ([]int, []bool) {
+	// candidates[i] are the indices of the actual elements that expected
+	// element i matches on its own
+	candidates := make([][]int, len(expected))
+	for i, v := range expected {
+		for j := range actual {
+			trial := &comparer{opts: c.opts}
+			if len(trial.compare(v, actual[j])) == 0 {
+				candidates[i] = append(candidates[i], j)
+			}
+		}
+	}
+
+	// find the largest pairing of expected elements to distinct actual
+	// elements by repeatedly searching for augmenting paths
+	matchedBy := make([]int, len(actual))
+	for j := range matchedBy {
+		matchedBy[j] = -1
+	}
+
+	var assign func(i int, visited []bool) bool
+	assign = func(i int, visited []bool) bool {
+		for _, j := range candidates[i] {
+			if visited[j] {
+				continue
+			}
+
+			visited[j] = true
+			if matchedBy[j] < 0 || assign(matchedBy[j], visited) {
+				matchedBy[j] = i
+				return true
+			}
+		}
+		return false
+	}
+
+	matched := make([]bool, len(expected))
+	for i := range expected {
+		matched[i] = assign(i, make([]bool, len(actual)))
+	}
+
+	return matchedBy, matched
+}
@@ -0,0 +1,57 @@
+package expect_test
+
+import (
+	"testing"
+
+	"github.com/jefflinse/melatonin/expect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnordered(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		expected any
+		actual   any
+		exact    bool
+		want     []string
+	}{
+		{"same order", expect.Unordered([]any{"a", "b"}), []any{"a", "b"}, true, nil},
+		{"different order", expect.Unordered([]any{"b", "a"}), []any{"a", "b"}, true, nil},
+		{"subset", expect.Unordered([]any{"c"}), []any{"a", "b", "c"}, false, nil},
+		{"extra elements when exact", expect.Unordered([]any{"c"}), []any{"a", "c"}, true,
+			[]string{": expected 1 elements, got 2: [\n  \"a\",\n  \"c\"\n]"}},
+		{"too few elements", expect.Unordered([]any{"a", "b"}), []any{"a"}, false,
+			[]string{": expected at least 2 elements, got 1: [\n  \"a\"\n]"}},
+		{"missing element", expect.Unordered([]any{"a", "x"}), []any{"b", "a"}, false,
+			[]string{": expected an element matching x, got none"}},
+		{"duplicates need distinct elements", expect.Unordered([]any{"a", "a"}), []any{"a", "b"}, false,
+			[]string{": expected an element matching a, got none"}},
+		{"pairing is not greedy", expect.Unordered([]any{expect.String(), "a"}), []any{"a", "b"}, true, nil},
+		{"objects", expect.Unordered([]any{map[string]any{"id": "2"}, map[string]any{"id": "1"}}),
+			[]any{map[string]any{"id": "1", "n": float64(1)}, map[string]any{"id": "2"}}, false, nil},
+		{"nested in an object", map[string]any{"tags": expect.Unordered([]any{"y", "x"})},
+			map[string]any{"tags": []any{"x", "z"}}, false,
+			[]string{"tags: expected an element matching y, got none"}},
+		{"not an array", expect.Unordered([]any{"a"}), "a", false,
+			[]string{": expected type []interface {}, got string: a"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, err := range expect.CompareValues(test.expected, test.actual, test.exact) {
+				got = append(got, err.Error())
+			}
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestUnorderedWarnings(t *testing.T) {
+	expected := expect.Unordered([]any{map[string]any{"id": "b"}})
+	actual := []any{map[string]any{"id": "a"}, map[string]any{"id": "b", "extra": true}}
+
+	c := expect.Compare(expected, actual, expect.CompareOptions{UnknownFields: expect.WarnUnknownFields})
+	assert.Empty(t, c.Failures)
+	if assert.Len(t, c.Warnings, 1) {
+		assert.Equal(t, "[1].extra: unexpected field: true", c.Warnings[0].Error())
+	}
+}
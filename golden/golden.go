@@ -2,16 +2,20 @@ package golden
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/spf13/afero"
+	"golang.org/x/text/encoding/unicode"
 	"golang.org/x/text/language"
 	"golang.org/x/text/search"
+	"golang.org/x/text/transform"
 )
 
 // Golden represents the contents of a golden file.
@@ -40,6 +44,42 @@ type Golden struct {
 	MatchBodyJSONExactly bool
 }
 
+// A Normalization is a set of differences between the body of a golden file and
+// an actual response body that are ignored when the two are compared, so that
+// golden files edited on other platforms don't cause spurious failures.
+type Normalization int
+
+const (
+	// NormalizeBOM removes a leading byte order mark from the body.
+	NormalizeBOM Normalization = 1 << iota
+
+	// NormalizeLineEndings converts "\r\n" and "\r" line endings to "\n".
+	NormalizeLineEndings
+
+	// NoNormalization compares bodies as they are.
+	NoNormalization Normalization = 0
+
+	// DefaultNormalization is the normalization used unless another is
+	// configured.
+	DefaultNormalization = NormalizeBOM | NormalizeLineEndings
+)
+
+// Apply returns a normalized copy of a body.
+func (n Normalization) Apply(body []byte) []byte {
+	if n&NormalizeBOM != 0 {
+		body = bytes.TrimPrefix(body, []byte(utf8BOM))
+	}
+
+	if n&NormalizeLineEndings != 0 && bytes.IndexByte(body, '\r') >= 0 {
+		body = bytes.ReplaceAll(body, []byte("\r\n"), []byte("\n"))
+		body = bytes.ReplaceAll(body, []byte("\r"), []byte("\n"))
+	}
+
+	return body
+}
+
+const utf8BOM = "\ufeff"
+
 const (
 	headersLinePrefix = "--- headers"
 	bodyLinePrefix    = "--- body"
@@ -49,6 +89,10 @@ const (
 var AppFS = afero.NewOsFs()
 
 // LoadFile loads a golden file from the given path.
+//
+// The file may be encoded as UTF-8, or as UTF-16 if it begins with a byte order
+// mark, and any byte order mark is removed. Lines may end with "\r\n" or "\n",
+// and the lines of the body are always joined with "\n".
 func LoadFile(path string) (*Golden, error) {
 	if exists, err := afero.Exists(AppFS, path); err != nil {
 		return nil, newGoldenFileError(path, err)
@@ -67,7 +111,7 @@ func LoadFile(path string) (*Golden, error) {
 	var target *[]string
 	var foundHeaders, foundBody, bodyIsJSON bool
 
-	scanner := bufio.NewScanner(f)
+	scanner := bufio.NewScanner(transform.NewReader(f, unicode.BOMOverride(transform.Nop)))
 	matcher := search.New(language.English, search.IgnoreCase)
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -153,8 +197,14 @@ func (g *Golden) SaveFile(path string) error {
 			headersDirectives = append(headersDirectives, "exact")
 		}
 		lines = append(lines, strings.Join(headersDirectives, " "))
-		for key, values := range g.WantHeaders {
-			for _, value := range values {
+		keys := make([]string, 0, len(g.WantHeaders))
+		for key := range g.WantHeaders {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+		for _, key := range keys {
+			for _, value := range g.WantHeaders[key] {
 				lines = append(lines, fmt.Sprintf("%s: %s", key, value))
 			}
 		}
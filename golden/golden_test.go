@@ -166,6 +166,33 @@ func TestLoadFile(t *testing.T) {
 			content:   "200\nfoo",
 			wantError: `unexpected line "foo"`,
 		},
+		{
+			name:    "success, UTF-8 byte order mark removed",
+			content: "\ufeff200\n--- body\nbody content",
+			wantGolden: &golden.Golden{
+				WantStatus: 200,
+				WantBody:   "body content",
+			},
+		},
+		{
+			name:    "success, UTF-16 content decoded",
+			content: "\xff\xfe2\x000\x000\x00\n\x00-\x00-\x00-\x00 \x00b\x00o\x00d\x00y\x00\n\x00\xe9\x00",
+			wantGolden: &golden.Golden{
+				WantStatus: 200,
+				WantBody:   "é",
+			},
+		},
+		{
+			name:    "success, CRLF line endings",
+			content: "200\r\n--- headers\r\nSome-Header: foo\r\n--- body json\r\n{\r\n  \"foo\": \"bar\"\r\n}\r\n",
+			wantGolden: &golden.Golden{
+				WantStatus: 200,
+				WantHeaders: http.Header{
+					"Some-Header": []string{"foo"},
+				},
+				WantBody: map[string]any{"foo": "bar"},
+			},
+		},
 		{
 			name:      "failure, invalid header linie",
 			content:   "200\n--- headers\nfoo",
@@ -214,6 +241,27 @@ func TestLoadFile(t *testing.T) {
 	}
 }
 
+func TestNormalizationApply(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		normalization golden.Normalization
+		body          string
+		want          string
+	}{
+		{"default removes BOM", golden.DefaultNormalization, "\ufeffabc", "abc"},
+		{"default converts CRLF", golden.DefaultNormalization, "a\r\nb\r\n", "a\nb\n"},
+		{"default converts CR", golden.DefaultNormalization, "a\rb", "a\nb"},
+		{"BOM only keeps line endings", golden.NormalizeBOM, "\ufeffa\r\nb", "a\r\nb"},
+		{"line endings only keeps BOM", golden.NormalizeLineEndings, "\ufeffa\r\nb", "\ufeffa\nb"},
+		{"no normalization", golden.NoNormalization, "\ufeffa\r\nb", "\ufeffa\r\nb"},
+		{"BOM only removed at start", golden.DefaultNormalization, "a\ufeff", "a\ufeff"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, string(test.normalization.Apply([]byte(test.body))))
+		})
+	}
+}
+
 func TestSaveFile(t *testing.T) {
 	for _, test := range []struct {
 		name      string
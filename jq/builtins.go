@@ -0,0 +1,614 @@
+package jq
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A builtin is a function of a program, called with its input and the
+// unevaluated expressions of its arguments.
+type builtin func(input any, args []node) ([]any, error)
+
+type builtinName struct {
+	name  string
+	arity int
+}
+
+var builtins = map[builtinName]builtin{
+	{"add", 0}:            unary(add),
+	{"all", 0}:            unary(func(v any) (any, error) { return all(v, identityNode{}) }),
+	{"all", 1}:            withFilter(all),
+	{"any", 0}:            unary(func(v any) (any, error) { return anyOf(v, identityNode{}) }),
+	{"any", 1}:            withFilter(anyOf),
+	{"ascii_downcase", 0}: unary(stringFunc(strings.ToLower)),
+	{"ascii_upcase", 0}:   unary(stringFunc(strings.ToUpper)),
+	{"ceil", 0}:           unary(numberFunc(math.Ceil)),
+	{"contains", 1}:       withValue(func(v, x any) (any, error) { return contains(v, x) }),
+	{"empty", 0}:          func(any, []node) ([]any, error) { return nil, nil },
+	{"endswith", 1}:       withValue(stringPredicate(strings.HasSuffix)),
+	{"first", 0}:          unary(func(v any) (any, error) { return index(v, float64(0)) }),
+	{"first", 1}:          first,
+	{"flatten", 0}:        unary(func(v any) (any, error) { return flatten(v) }),
+	{"floor", 0}:          unary(numberFunc(math.Floor)),
+	{"group_by", 1}:       withFilter(groupBy),
+	{"has", 1}:            withValue(has),
+	{"join", 1}:           withValue(join),
+	{"keys", 0}:           unary(keys),
+	{"last", 0}:           unary(func(v any) (any, error) { return index(v, float64(-1)) }),
+	{"last", 1}:           last,
+	{"length", 0}:         unary(length),
+	{"map", 1}:            withFilter(mapValues),
+	{"max", 0}:            unary(func(v any) (any, error) { return extreme(v, identityNode{}, 1) }),
+	{"max_by", 1}:         withFilter(func(v any, f node) (any, error) { return extreme(v, f, 1) }),
+	{"min", 0}:            unary(func(v any) (any, error) { return extreme(v, identityNode{}, -1) }),
+	{"min_by", 1}:         withFilter(func(v any, f node) (any, error) { return extreme(v, f, -1) }),
+	{"not", 0}:            unary(func(v any) (any, error) { return !truthy(v), nil }),
+	{"reverse", 0}:        unary(reverse),
+	{"select", 1}:         selectValues,
+	{"sort", 0}:           unary(func(v any) (any, error) { return sortBy(v, identityNode{}) }),
+	{"sort_by", 1}:        withFilter(sortBy),
+	{"split", 1}:          withValue(splitString),
+	{"startswith", 1}:     withValue(stringPredicate(strings.HasPrefix)),
+	{"test", 1}:           withValue(test),
+	{"to_entries", 0}:     unary(toEntries),
+	{"tonumber", 0}:       unary(toNumber),
+	{"tostring", 0}:       unary(toString),
+	{"type", 0}:           unary(func(v any) (any, error) { return typeName(v), nil }),
+	{"unique", 0}:         unary(func(v any) (any, error) { return uniqueBy(v, identityNode{}) }),
+	{"unique_by", 1}:      withFilter(uniqueBy),
+}
+
+// unary creates a builtin without arguments that outputs a single value.
+func unary(f func(v any) (any, error)) builtin {
+	return func(input any, _ []node) ([]any, error) {
+		output, err := f(input)
+		if err != nil {
+			return nil, err
+		}
+
+		return []any{output}, nil
+	}
+}
+
+// withFilter creates a builtin whose argument is a filter applied by the
+// builtin, such as to each element of its input.
+func withFilter(f func(v any, filter node) (any, error)) builtin {
+	return func(input any, args []node) ([]any, error) {
+		output, err := f(input, args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return []any{output}, nil
+	}
+}
+
+// withValue creates a builtin whose argument is evaluated against its input,
+// outputting a value for each output of the argument.
+func withValue(f func(v, arg any) (any, error)) builtin {
+	return func(input any, args []node) ([]any, error) {
+		values, err := args[0].eval(input)
+		if err != nil {
+			return nil, err
+		}
+
+		outputs := make([]any, len(values))
+		for i, value := range values {
+			if outputs[i], err = f(input, value); err != nil {
+				return nil, err
+			}
+		}
+
+		return outputs, nil
+	}
+}
+
+func stringFunc(f func(string) string) func(any) (any, error) {
+	return func(v any) (any, error) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s cannot be converted, as it is not a string", describe(v))
+		}
+
+		return f(s), nil
+	}
+}
+
+func stringPredicate(f func(s, arg string) bool) func(v, arg any) (any, error) {
+	return func(v, arg any) (any, error) {
+		s, ok := v.(string)
+		a, aok := arg.(string)
+		if !ok || !aok {
+			return nil, errors.New("requires string inputs")
+		}
+
+		return f(s, a), nil
+	}
+}
+
+func numberFunc(f func(float64) float64) func(any) (any, error) {
+	return func(v any) (any, error) {
+		n, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a number", describe(v))
+		}
+
+		return f(n), nil
+	}
+}
+
+// elements returns the elements of an array or the values of an object.
+func elements(v any) ([]any, error) {
+	switch value := v.(type) {
+	case []any:
+		return value, nil
+	case map[string]any:
+		return iterateNode{}.eval(value)
+	}
+
+	return nil, fmt.Errorf("cannot iterate over %s", describe(v))
+}
+
+func add(v any) (any, error) {
+	values, err := elements(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var sum any
+	for _, value := range values {
+		if sum, err = binary("+", sum, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return sum, nil
+}
+
+func all(v any, filter node) (any, error) {
+	values, err := elements(v)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, value := range values {
+		outputs, err := filter.eval(value)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, output := range outputs {
+			if !truthy(output) {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
+
+func anyOf(v any, filter node) (any, error) {
+	values, err := elements(v)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, value := range values {
+		outputs, err := filter.eval(value)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, output := range outputs {
+			if truthy(output) {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// contains returns whether b is contained within a: strings by substring,
+// arrays by every element of b being contained in an element of a, and objects
+// by every value of b being contained in the value of the same key in a.
+func contains(a, b any) (bool, error) {
+	if typeName(a) != typeName(b) {
+		return false, fmt.Errorf("%s and %s cannot have their containment checked", describe(a), describe(b))
+	}
+
+	switch av := a.(type) {
+	case string:
+		return strings.Contains(av, b.(string)), nil
+
+	case []any:
+		for _, be := range b.([]any) {
+			found := false
+			for _, ae := range av {
+				if typeName(ae) != typeName(be) {
+					continue
+				}
+				if ok, err := contains(ae, be); err == nil && ok {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false, nil
+			}
+		}
+		return true, nil
+
+	case map[string]any:
+		for k, bv := range b.(map[string]any) {
+			value, ok := av[k]
+			if !ok {
+				return false, nil
+			}
+			if ok, err := contains(value, bv); err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	return compare(a, b) == 0, nil
+}
+
+func first(input any, args []node) ([]any, error) {
+	outputs, err := args[0].eval(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(outputs) == 0 {
+		return nil, nil
+	}
+
+	return outputs[:1], nil
+}
+
+func last(input any, args []node) ([]any, error) {
+	outputs, err := args[0].eval(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(outputs) == 0 {
+		return nil, nil
+	}
+
+	return outputs[len(outputs)-1:], nil
+}
+
+func flatten(v any) ([]any, error) {
+	values, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot flatten %s", describe(v))
+	}
+
+	flattened := []any{}
+	for _, value := range values {
+		if nested, ok := value.([]any); ok {
+			inner, _ := flatten(nested)
+			flattened = append(flattened, inner...)
+		} else {
+			flattened = append(flattened, value)
+		}
+	}
+
+	return flattened, nil
+}
+
+// keyed pairs the elements of an array with the output of a filter for each,
+// sorted by the filter's output.
+func keyed(v any, filter node) ([]any, []any, error) {
+	values, ok := v.([]any)
+	if !ok {
+		return nil, nil, fmt.Errorf("cannot sort %s, as it is not an array", describe(v))
+	}
+
+	keys := make([]any, len(values))
+	for i, value := range values {
+		outputs, err := filter.eval(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys[i] = outputs
+	}
+
+	indices := make([]int, len(values))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return compare(keys[indices[i]], keys[indices[j]]) < 0
+	})
+
+	sortedValues := make([]any, len(values))
+	sortedKeys := make([]any, len(values))
+	for i, index := range indices {
+		sortedValues[i] = values[index]
+		sortedKeys[i] = keys[index]
+	}
+
+	return sortedValues, sortedKeys, nil
+}
+
+func sortBy(v any, filter node) (any, error) {
+	values, _, err := keyed(v, filter)
+	return values, err
+}
+
+func groupBy(v any, filter node) (any, error) {
+	values, keys, err := keyed(v, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []any{}
+	for i, value := range values {
+		if i > 0 && compare(keys[i], keys[i-1]) == 0 {
+			group := groups[len(groups)-1].([]any)
+			groups[len(groups)-1] = append(group, value)
+		} else {
+			groups = append(groups, []any{value})
+		}
+	}
+
+	return groups, nil
+}
+
+func uniqueBy(v any, filter node) (any, error) {
+	values, keys, err := keyed(v, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	unique := []any{}
+	for i, value := range values {
+		if i == 0 || compare(keys[i], keys[i-1]) != 0 {
+			unique = append(unique, value)
+		}
+	}
+
+	return unique, nil
+}
+
+// extreme returns the element of an array with the greatest (sign 1) or least
+// (sign -1) output of a filter, or null if the array is empty.
+func extreme(v any, filter node, sign int) (any, error) {
+	values, _, err := keyed(v, filter)
+	if err != nil || len(values) == 0 {
+		return nil, err
+	}
+
+	if sign > 0 {
+		// the last of equal maximums is chosen, as in jq
+		return values[len(values)-1], nil
+	}
+
+	return values[0], nil
+}
+
+func has(v, key any) (any, error) {
+	switch value := v.(type) {
+	case map[string]any:
+		if k, ok := key.(string); ok {
+			_, present := value[k]
+			return present, nil
+		}
+
+	case []any:
+		if k, ok := key.(float64); ok {
+			return k >= 0 && int(k) < len(value), nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot check whether %s has a key %s", typeName(v), describe(key))
+}
+
+func join(v, separator any) (any, error) {
+	values, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot join %s", describe(v))
+	}
+
+	sep, ok := separator.(string)
+	if !ok {
+		return nil, fmt.Errorf("separator must be a string, got %s", describe(separator))
+	}
+
+	parts := make([]string, len(values))
+	for i, value := range values {
+		switch element := value.(type) {
+		case nil:
+		case string:
+			parts[i] = element
+		case bool, float64:
+			s, _ := toString(element)
+			parts[i] = s.(string)
+		default:
+			return nil, fmt.Errorf("cannot join %s", describe(value))
+		}
+	}
+
+	return strings.Join(parts, sep), nil
+}
+
+func keys(v any) (any, error) {
+	switch value := v.(type) {
+	case map[string]any:
+		return stringsToValues(sortedKeys(value)), nil
+
+	case []any:
+		indices := make([]any, len(value))
+		for i := range value {
+			indices[i] = float64(i)
+		}
+		return indices, nil
+	}
+
+	return nil, fmt.Errorf("%s has no keys", describe(v))
+}
+
+func length(v any) (any, error) {
+	switch value := v.(type) {
+	case nil:
+		return float64(0), nil
+	case float64:
+		return math.Abs(value), nil
+	case string:
+		return float64(utf8.RuneCountInString(value)), nil
+	case []any:
+		return float64(len(value)), nil
+	case map[string]any:
+		return float64(len(value)), nil
+	}
+
+	return nil, fmt.Errorf("%s has no length", describe(v))
+}
+
+func mapValues(v any, filter node) (any, error) {
+	values, err := elements(v)
+	if err != nil {
+		return nil, err
+	}
+
+	mapped := []any{}
+	for _, value := range values {
+		outputs, err := filter.eval(value)
+		if err != nil {
+			return nil, err
+		}
+		mapped = append(mapped, outputs...)
+	}
+
+	return mapped, nil
+}
+
+func reverse(v any) (any, error) {
+	switch value := v.(type) {
+	case nil:
+		return []any{}, nil
+
+	case string:
+		runes := []rune(value)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes), nil
+
+	case []any:
+		reversed := make([]any, len(value))
+		for i, element := range value {
+			reversed[len(value)-1-i] = element
+		}
+		return reversed, nil
+	}
+
+	return nil, fmt.Errorf("cannot reverse %s", describe(v))
+}
+
+func selectValues(input any, args []node) ([]any, error) {
+	outputs, err := args[0].eval(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []any
+	for _, output := range outputs {
+		if truthy(output) {
+			selected = append(selected, input)
+		}
+	}
+
+	return selected, nil
+}
+
+func split(s, separator string) []any {
+	if s == "" {
+		return []any{}
+	}
+
+	return stringsToValues(strings.Split(s, separator))
+}
+
+func splitString(v, separator any) (any, error) {
+	s, ok := v.(string)
+	sep, sepOK := separator.(string)
+	if !ok || !sepOK {
+		return nil, errors.New("requires string inputs")
+	}
+
+	return split(s, sep), nil
+}
+
+func test(v, pattern any) (any, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s cannot be matched, as it is not a string", describe(v))
+	}
+
+	p, ok := pattern.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a string", describe(pattern))
+	}
+
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return re.MatchString(s), nil
+}
+
+func toEntries(v any) (any, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s has no keys", describe(v))
+	}
+
+	entries := make([]any, 0, len(m))
+	for _, key := range sortedKeys(m) {
+		entries = append(entries, map[string]any{"key": key, "value": m[key]})
+	}
+
+	return entries, nil
+}
+
+func toNumber(v any) (any, error) {
+	switch value := v.(type) {
+	case float64:
+		return value, nil
+
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %q as a number", value)
+		}
+		return n, nil
+	}
+
+	return nil, fmt.Errorf("%s cannot be parsed as a number", describe(v))
+}
+
+func toString(v any) (any, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,743 @@
+package jq
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// A node is an expression of a program, which outputs a stream of values for
+// an input value.
+type node interface {
+	eval(input any) ([]any, error)
+}
+
+type identityNode struct{}
+
+func (identityNode) eval(input any) ([]any, error) {
+	return []any{input}, nil
+}
+
+type literalNode struct {
+	value any
+}
+
+func (n literalNode) eval(any) ([]any, error) {
+	return []any{n.value}, nil
+}
+
+type pipeNode struct {
+	left, right node
+}
+
+func (n pipeNode) eval(input any) ([]any, error) {
+	lefts, err := n.left.eval(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var outputs []any
+	for _, left := range lefts {
+		rights, err := n.right.eval(left)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, rights...)
+	}
+
+	return outputs, nil
+}
+
+type commaNode struct {
+	left, right node
+}
+
+func (n commaNode) eval(input any) ([]any, error) {
+	lefts, err := n.left.eval(input)
+	if err != nil {
+		return nil, err
+	}
+
+	rights, err := n.right.eval(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(lefts, rights...), nil
+}
+
+// An alternativeNode outputs the truthy outputs of its left expression or, if
+// there are none, the outputs of its right expression.
+type alternativeNode struct {
+	left, right node
+}
+
+func (n alternativeNode) eval(input any) ([]any, error) {
+	lefts, _ := n.left.eval(input)
+
+	var outputs []any
+	for _, left := range lefts {
+		if truthy(left) {
+			outputs = append(outputs, left)
+		}
+	}
+
+	if len(outputs) > 0 {
+		return outputs, nil
+	}
+
+	return n.right.eval(input)
+}
+
+// A tryNode outputs the outputs of its expression, or nothing if it fails.
+type tryNode struct {
+	body node
+}
+
+func (n tryNode) eval(input any) ([]any, error) {
+	outputs, err := n.body.eval(input)
+	if err != nil {
+		return nil, nil
+	}
+
+	return outputs, nil
+}
+
+type indexNode struct {
+	key node
+}
+
+func (n indexNode) eval(input any) ([]any, error) {
+	keys, err := n.key.eval(input)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make([]any, len(keys))
+	for i, key := range keys {
+		if outputs[i], err = index(input, key); err != nil {
+			return nil, err
+		}
+	}
+
+	return outputs, nil
+}
+
+func index(value, key any) (any, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+
+	case map[string]any:
+		if k, ok := key.(string); ok {
+			return v[k], nil
+		}
+
+	case []any:
+		if k, ok := key.(float64); ok {
+			i := int(math.Floor(k))
+			if i < 0 {
+				i += len(v)
+			}
+			if i < 0 || i >= len(v) {
+				return nil, nil
+			}
+			return v[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot index %s with %s", typeName(value), describe(key))
+}
+
+type sliceNode struct {
+	from, to node
+}
+
+func (n sliceNode) eval(input any) ([]any, error) {
+	var length int
+	switch v := input.(type) {
+	case nil:
+		return []any{nil}, nil
+	case []any:
+		length = len(v)
+	case string:
+		length = len([]rune(v))
+	default:
+		return nil, fmt.Errorf("cannot slice %s", typeName(input))
+	}
+
+	from, err := sliceBound(n.from, input, 0, length)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := sliceBound(n.to, input, length, length)
+	if err != nil {
+		return nil, err
+	}
+
+	if to < from {
+		to = from
+	}
+
+	if s, ok := input.(string); ok {
+		return []any{string([]rune(s)[from:to])}, nil
+	}
+
+	return []any{append([]any{}, input.([]any)[from:to]...)}, nil
+}
+
+// sliceBound evaluates a bound of a slice, clamped to the length of the sliced
+// value.
+func sliceBound(bound node, input any, omitted, length int) (int, error) {
+	if bound == nil {
+		return omitted, nil
+	}
+
+	outputs, err := bound.eval(input)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(outputs) != 1 {
+		return 0, errors.New("slice bound must have exactly one value")
+	}
+
+	n, ok := outputs[0].(float64)
+	if !ok {
+		return 0, fmt.Errorf("slice bound must be a number, got %s", describe(outputs[0]))
+	}
+
+	i := int(math.Floor(n))
+	if i < 0 {
+		i += length
+	}
+
+	if i < 0 {
+		return 0, nil
+	} else if i > length {
+		return length, nil
+	}
+
+	return i, nil
+}
+
+type iterateNode struct{}
+
+func (iterateNode) eval(input any) ([]any, error) {
+	switch v := input.(type) {
+	case []any:
+		return v, nil
+
+	case map[string]any:
+		outputs := make([]any, 0, len(v))
+		for _, key := range sortedKeys(v) {
+			outputs = append(outputs, v[key])
+		}
+		return outputs, nil
+	}
+
+	return nil, fmt.Errorf("cannot iterate over %s", describe(input))
+}
+
+// A recurseNode outputs its input and, recursively, every value within it.
+type recurseNode struct{}
+
+func (recurseNode) eval(input any) ([]any, error) {
+	outputs := []any{input}
+	switch v := input.(type) {
+	case []any:
+		for _, element := range v {
+			children, _ := recurseNode{}.eval(element)
+			outputs = append(outputs, children...)
+		}
+
+	case map[string]any:
+		for _, key := range sortedKeys(v) {
+			children, _ := recurseNode{}.eval(v[key])
+			outputs = append(outputs, children...)
+		}
+	}
+
+	return outputs, nil
+}
+
+type arrayNode struct {
+	elements node
+}
+
+func (n arrayNode) eval(input any) ([]any, error) {
+	if n.elements == nil {
+		return []any{[]any{}}, nil
+	}
+
+	elements, err := n.elements.eval(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if elements == nil {
+		elements = []any{}
+	}
+
+	return []any{elements}, nil
+}
+
+type objectEntry struct {
+	key, value node
+}
+
+type objectNode struct {
+	entries []objectEntry
+}
+
+// eval outputs an object for every combination of the outputs of the object's
+// keys and values.
+func (n objectNode) eval(input any) ([]any, error) {
+	objects := []map[string]any{{}}
+	for _, entry := range n.entries {
+		keys, err := entry.key.eval(input)
+		if err != nil {
+			return nil, err
+		}
+
+		values, err := entry.value.eval(input)
+		if err != nil {
+			return nil, err
+		}
+
+		var next []map[string]any
+		for _, object := range objects {
+			for _, key := range keys {
+				k, ok := key.(string)
+				if !ok {
+					return nil, fmt.Errorf("object key must be a string, got %s", describe(key))
+				}
+
+				for _, value := range values {
+					o := make(map[string]any, len(object)+1)
+					for ek, ev := range object {
+						o[ek] = ev
+					}
+					o[k] = value
+					next = append(next, o)
+				}
+			}
+		}
+		objects = next
+	}
+
+	outputs := make([]any, len(objects))
+	for i, object := range objects {
+		outputs[i] = object
+	}
+
+	return outputs, nil
+}
+
+type logicalNode struct {
+	op          string
+	left, right node
+}
+
+func (n logicalNode) eval(input any) ([]any, error) {
+	lefts, err := n.left.eval(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var outputs []any
+	for _, left := range lefts {
+		// the right expression is only evaluated if the left doesn't decide
+		if n.op == "and" && !truthy(left) || n.op == "or" && truthy(left) {
+			outputs = append(outputs, n.op == "or")
+			continue
+		}
+
+		rights, err := n.right.eval(input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, right := range rights {
+			outputs = append(outputs, truthy(right))
+		}
+	}
+
+	return outputs, nil
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n binaryNode) eval(input any) ([]any, error) {
+	lefts, err := n.left.eval(input)
+	if err != nil {
+		return nil, err
+	}
+
+	rights, err := n.right.eval(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var outputs []any
+	for _, right := range rights {
+		for _, left := range lefts {
+			output, err := binary(n.op, left, right)
+			if err != nil {
+				return nil, err
+			}
+			outputs = append(outputs, output)
+		}
+	}
+
+	return outputs, nil
+}
+
+func binary(op string, left, right any) (any, error) {
+	switch op {
+	case "==":
+		return compare(left, right) == 0, nil
+	case "!=":
+		return compare(left, right) != 0, nil
+	case "<":
+		return compare(left, right) < 0, nil
+	case "<=":
+		return compare(left, right) <= 0, nil
+	case ">":
+		return compare(left, right) > 0, nil
+	case ">=":
+		return compare(left, right) >= 0, nil
+	}
+
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if lok && rok {
+		switch op {
+		case "+":
+			return l + r, nil
+		case "-":
+			return l - r, nil
+		case "*":
+			return l * r, nil
+		case "/":
+			if r == 0 {
+				return nil, fmt.Errorf("%v and %v cannot be divided because the divisor is zero", l, r)
+			}
+			return l / r, nil
+		case "%":
+			if int64(r) == 0 {
+				return nil, fmt.Errorf("%v and %v cannot be divided because the divisor is zero", l, r)
+			}
+			return float64(int64(l) % int64(r)), nil
+		}
+	}
+
+	switch op {
+	case "+":
+		if left == nil {
+			return right, nil
+		} else if right == nil {
+			return left, nil
+		}
+
+		switch l := left.(type) {
+		case string:
+			if r, ok := right.(string); ok {
+				return l + r, nil
+			}
+
+		case []any:
+			if r, ok := right.([]any); ok {
+				return append(append([]any{}, l...), r...), nil
+			}
+
+		case map[string]any:
+			if r, ok := right.(map[string]any); ok {
+				merged := make(map[string]any, len(l)+len(r))
+				for k, v := range l {
+					merged[k] = v
+				}
+				for k, v := range r {
+					merged[k] = v
+				}
+				return merged, nil
+			}
+		}
+
+	case "-":
+		l, lok := left.([]any)
+		r, rok := right.([]any)
+		if lok && rok {
+			remaining := []any{}
+			for _, element := range l {
+				if !containsElement(r, element) {
+					remaining = append(remaining, element)
+				}
+			}
+			return remaining, nil
+		}
+
+	case "/":
+		l, lok := left.(string)
+		r, rok := right.(string)
+		if lok && rok {
+			return split(l, r), nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s and %s cannot be %s", describe(left), describe(right), operationNames[op])
+}
+
+var operationNames = map[string]string{
+	"+": "added",
+	"-": "subtracted",
+	"*": "multiplied",
+	"/": "divided",
+	"%": "divided",
+}
+
+type callNode struct {
+	name string
+	f    builtin
+	args []node
+}
+
+func (n callNode) eval(input any) ([]any, error) {
+	outputs, err := n.f(input, n.args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+
+	return outputs, nil
+}
+
+// truthy returns whether a value is neither false nor null.
+func truthy(v any) bool {
+	return v != nil && v != false
+}
+
+// typeName returns the jq name of the type of a value.
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+
+	return fmt.Sprintf("%T", v)
+}
+
+// describe returns the type and JSON representation of a value, for errors.
+func describe(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return typeName(v)
+	}
+
+	s := string(b)
+	if len(s) > 40 {
+		s = s[:37] + "..."
+	}
+
+	return fmt.Sprintf("%s (%s)", typeName(v), s)
+}
+
+// typeOrder is the order of types when values of different types are compared.
+var typeOrder = map[string]int{
+	"null":    0,
+	"boolean": 1,
+	"number":  2,
+	"string":  3,
+	"array":   4,
+	"object":  5,
+}
+
+// compare compares two values in jq's ordering, in which values of different
+// types are ordered by type: null, false, true, numbers, strings, arrays, and
+// objects.
+func compare(a, b any) int {
+	ta, tb := typeName(a), typeName(b)
+	if ta != tb {
+		return typeOrder[ta] - typeOrder[tb]
+	}
+
+	switch av := a.(type) {
+	case bool:
+		bv := b.(bool)
+		switch {
+		case av == bv:
+			return 0
+		case !av:
+			return -1
+		}
+		return 1
+
+	case float64:
+		bv := b.(float64)
+		switch {
+		case av < bv:
+			return -1
+		case av > bv:
+			return 1
+		}
+		return 0
+
+	case string:
+		return strings.Compare(av, b.(string))
+
+	case []any:
+		bv := b.([]any)
+		for i := 0; i < len(av) && i < len(bv); i++ {
+			if c := compare(av[i], bv[i]); c != 0 {
+				return c
+			}
+		}
+		return len(av) - len(bv)
+
+	case map[string]any:
+		bv := b.(map[string]any)
+		ak, bk := sortedKeys(av), sortedKeys(bv)
+		if c := compare(stringsToValues(ak), stringsToValues(bk)); c != 0 {
+			return c
+		}
+		for _, k := range ak {
+			if c := compare(av[k], bv[k]); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+
+	return 0
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func stringsToValues(s []string) []any {
+	values := make([]any, len(s))
+	for i, v := range s {
+		values[i] = v
+	}
+
+	return values
+}
+
+func containsElement(elements []any, v any) bool {
+	for _, element := range elements {
+		if compare(element, v) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalize converts the numbers of a value into float64, and the typed maps
+// and slices of a value, such as those of a JSON object, into their generic
+// forms.
+func normalize(v any) any {
+	switch value := v.(type) {
+	case nil, bool, float64, string:
+		return v
+
+	case json.Number:
+		f, _ := value.Float64()
+		return f
+
+	case []byte:
+		// bytes are represented in JSON as a base64 string
+		return base64.StdEncoding.EncodeToString(value)
+
+	case map[string]any:
+		m := make(map[string]any, len(value))
+		for k, element := range value {
+			m[k] = normalize(element)
+		}
+		return m
+
+	case []any:
+		s := make([]any, len(value))
+		for i, element := range value {
+			s[i] = normalize(element)
+		}
+		return s
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+
+	case reflect.String:
+		return rv.String()
+
+	case reflect.Bool:
+		return rv.Bool()
+
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			m := make(map[string]any, rv.Len())
+			iter := rv.MapRange()
+			for iter.Next() {
+				m[iter.Key().String()] = normalize(iter.Value().Interface())
+			}
+			return m
+		}
+
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil
+		}
+
+		s := make([]any, rv.Len())
+		for i := range s {
+			s[i] = normalize(rv.Index(i).Interface())
+		}
+		return s
+	}
+
+	// other values, such as structs, are evaluated as their JSON representation
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+
+	var decoded any
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return v
+	}
+
+	return decoded
+}
@@ -0,0 +1,603 @@
+// Package jq evaluates a subset of the jq language against decoded JSON values,
+// for compact assertions about the shape and contents of response bodies.
+//
+// Supported are paths (".a.b", ".[0]", ".[-1]", ".[2:4]", ".[]", "..", and the
+// optional operator "?"), the pipe and comma operators, literals, array and
+// object construction, arithmetic, comparison, the "and", "or", and "//"
+// operators, and the builtin functions listed in the documentation of
+// Compile(). Variables, reductions, and user-defined functions are not
+// supported.
+package jq
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A Program is a compiled jq program.
+type Program struct {
+	source string
+	root   node
+}
+
+// Compile parses a jq program.
+//
+// The supported builtin functions are add, all, any, ascii_downcase,
+// ascii_upcase, ceil, contains(x), empty, endswith(s), first, first(f),
+// flatten, floor, group_by(f), has(k), join(s), keys, last, last(f), length,
+// map(f), max, max_by(f), min, min_by(f), not, reverse, select(f), sort,
+// sort_by(f), split(s), startswith(s), test(re), to_entries, tonumber,
+// tostring, type, unique, and unique_by(f), along with the all(f) and any(f)
+// forms of all and any.
+func Compile(program string) (*Program, error) {
+	tokens, err := lex(program)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("jq: unexpected %s at offset %d", tok, tok.offset)
+	}
+
+	return &Program{source: program, root: root}, nil
+}
+
+// Run evaluates the program against an input value, such as a decoded JSON
+// document, returning each of the values the program outputs. Numbers are
+// output as float64.
+func (p *Program) Run(input any) ([]any, error) {
+	outputs, err := p.root.eval(normalize(input))
+	if err != nil {
+		return nil, fmt.Errorf("jq: %w", err)
+	}
+
+	return outputs, nil
+}
+
+// String returns the source of the program.
+func (p *Program) String() string {
+	return p.source
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenField
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenPunct
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	number float64
+	offset int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of program"
+	case tokenField:
+		return fmt.Sprintf("%q", "."+t.text)
+	case tokenString:
+		return strconv.Quote(t.text)
+	}
+
+	return fmt.Sprintf("%q", t.text)
+}
+
+// punctuation are the operators and delimiters of the language, longest first
+var punctuation = []string{
+	"==", "!=", "<=", ">=", "//", "..",
+	".", "[", "]", "(", ")", "{", "}", "|", ",", ":", ";", "?",
+	"<", ">", "+", "-", "*", "/", "%",
+}
+
+func lex(program string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(program); {
+		c := program[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '#':
+			for i < len(program) && program[i] != '\n' {
+				i++
+			}
+
+		case c == '.' && i+1 < len(program) && isIdentStart(program[i+1]):
+			end := identEnd(program, i+1)
+			tokens = append(tokens, token{kind: tokenField, text: program[i+1 : end], offset: i})
+			i = end
+
+		case isIdentStart(c):
+			end := identEnd(program, i)
+			tokens = append(tokens, token{kind: tokenIdent, text: program[i:end], offset: i})
+			i = end
+
+		case c >= '0' && c <= '9':
+			end := i
+			for end < len(program) && (program[end] >= '0' && program[end] <= '9' || program[end] == '.' ||
+				program[end] == 'e' || program[end] == 'E' ||
+				(program[end] == '-' || program[end] == '+') && (program[end-1] == 'e' || program[end-1] == 'E')) {
+				end++
+			}
+
+			n, err := strconv.ParseFloat(program[i:end], 64)
+			if err != nil {
+				return nil, fmt.Errorf("jq: invalid number %q at offset %d", program[i:end], i)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: program[i:end], number: n, offset: i})
+			i = end
+
+		case c == '"':
+			s, end, err := lexString(program, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: s, offset: i})
+			i = end
+
+		default:
+			matched := false
+			for _, p := range punctuation {
+				if strings.HasPrefix(program[i:], p) {
+					tokens = append(tokens, token{kind: tokenPunct, text: p, offset: i})
+					i += len(p)
+					matched = true
+					break
+				}
+			}
+
+			if !matched {
+				return nil, fmt.Errorf("jq: unexpected character %q at offset %d", c, i)
+			}
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, offset: len(program)}), nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func identEnd(program string, start int) int {
+	end := start
+	for end < len(program) && (isIdentStart(program[end]) || program[end] >= '0' && program[end] <= '9') {
+		end++
+	}
+
+	return end
+}
+
+// lexString reads the string literal starting at an offset, returning its value
+// and the offset following it.
+func lexString(program string, start int) (string, int, error) {
+	for end := start + 1; end < len(program); end++ {
+		switch program[end] {
+		case '\\':
+			end++
+		case '"':
+			s, err := strconv.Unquote(program[start : end+1])
+			if err != nil {
+				return "", 0, fmt.Errorf("jq: invalid string %s at offset %d", program[start:end+1], start)
+			}
+			return s, end + 1, nil
+		}
+	}
+
+	return "", 0, fmt.Errorf("jq: unterminated string at offset %d", start)
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+
+	return tok
+}
+
+// accept consumes the next token if it is the given punctuation or keyword.
+func (p *parser) accept(text string) bool {
+	if tok := p.peek(); (tok.kind == tokenPunct || tok.kind == tokenIdent) && tok.text == text {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		tok := p.peek()
+		return fmt.Errorf("jq: expected %q, got %s at offset %d", text, tok, tok.offset)
+	}
+
+	return nil
+}
+
+func (p *parser) parsePipe() (node, error) {
+	left, err := p.parseComma()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.accept("|") {
+		right, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		return pipeNode{left, right}, nil
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseComma() (node, error) {
+	left, err := p.parseAlternative()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept(",") {
+		right, err := p.parseAlternative()
+		if err != nil {
+			return nil, err
+		}
+		left = commaNode{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAlternative() (node, error) {
+	left, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.accept("//") {
+		right, err := p.parseAlternative()
+		if err != nil {
+			return nil, err
+		}
+		return alternativeNode{left, right}, nil
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{op: "or", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("and") {
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{op: "and", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.accept(op) {
+			right, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			return binaryNode{op: op, left: left, right: right}, nil
+		}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	return p.parseBinary([]string{"+", "-"}, p.parseMultiplicative)
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+	return p.parseBinary([]string{"*", "/", "%"}, p.parsePostfix)
+}
+
+// parseBinary parses a left-associative sequence of binary operations.
+func (p *parser) parseBinary(ops []string, operand func() (node, error)) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		matched := false
+		for _, op := range ops {
+			if p.accept(op) {
+				right, err := operand()
+				if err != nil {
+					return nil, err
+				}
+				left = binaryNode{op: op, left: left, right: right}
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return left, nil
+		}
+	}
+}
+
+func (p *parser) parsePostfix() (node, error) {
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		switch {
+		case tok.kind == tokenField:
+			p.next()
+			n = pipeNode{n, indexNode{key: literalNode{tok.text}}}
+
+		case tok.kind == tokenPunct && tok.text == "." && p.tokens[p.pos+1].kind == tokenString:
+			p.next()
+			n = pipeNode{n, indexNode{key: literalNode{p.next().text}}}
+
+		case tok.kind == tokenPunct && tok.text == "." && p.tokens[p.pos+1].kind == tokenPunct && p.tokens[p.pos+1].text == "[":
+			p.next()
+
+		case tok.kind == tokenPunct && tok.text == "[":
+			p.next()
+			suffix, err := p.parseBracketSuffix()
+			if err != nil {
+				return nil, err
+			}
+			n = pipeNode{n, suffix}
+
+		case tok.kind == tokenPunct && tok.text == "?":
+			p.next()
+			n = tryNode{n}
+
+		default:
+			return n, nil
+		}
+	}
+}
+
+// parseBracketSuffix parses the remainder of an iteration, index, or slice
+// following its opening bracket.
+func (p *parser) parseBracketSuffix() (node, error) {
+	if p.accept("]") {
+		return iterateNode{}, nil
+	}
+
+	var from, to node
+	var err error
+	if !p.accept(":") {
+		if from, err = p.parsePipe(); err != nil {
+			return nil, err
+		}
+
+		if p.accept("]") {
+			return indexNode{key: from}, nil
+		}
+
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+	}
+
+	if !p.accept("]") {
+		if to, err = p.parsePipe(); err != nil {
+			return nil, err
+		}
+
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+	}
+
+	return sliceNode{from: from, to: to}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenField:
+		return indexNode{key: literalNode{tok.text}}, nil
+
+	case tokenNumber:
+		return literalNode{tok.number}, nil
+
+	case tokenString:
+		return literalNode{tok.text}, nil
+
+	case tokenIdent:
+		switch tok.text {
+		case "true":
+			return literalNode{true}, nil
+		case "false":
+			return literalNode{false}, nil
+		case "null":
+			return literalNode{nil}, nil
+		}
+
+		return p.parseCall(tok)
+
+	case tokenPunct:
+		switch tok.text {
+		case ".":
+			if p.peek().kind == tokenString {
+				return indexNode{key: literalNode{p.next().text}}, nil
+			}
+			return identityNode{}, nil
+
+		case "..":
+			return recurseNode{}, nil
+
+		case "-":
+			operand, err := p.parsePostfix()
+			if err != nil {
+				return nil, err
+			}
+			return binaryNode{op: "-", left: literalNode{float64(0)}, right: operand}, nil
+
+		case "(":
+			n, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+
+		case "[":
+			if p.accept("]") {
+				return arrayNode{}, nil
+			}
+
+			n, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			return arrayNode{elements: n}, p.expect("]")
+
+		case "{":
+			return p.parseObject()
+		}
+	}
+
+	if tok.kind == tokenEOF {
+		return nil, errors.New("jq: unexpected end of program")
+	}
+
+	return nil, fmt.Errorf("jq: unexpected %s at offset %d", tok, tok.offset)
+}
+
+// parseCall parses a call of a builtin function and its arguments.
+func (p *parser) parseCall(name token) (node, error) {
+	var args []node
+	if p.accept("(") {
+		for {
+			arg, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+
+			if !p.accept(";") {
+				break
+			}
+		}
+
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+
+	f, ok := builtins[builtinName{name.text, len(args)}]
+	if !ok {
+		return nil, fmt.Errorf("jq: unknown function %s/%d at offset %d", name.text, len(args), name.offset)
+	}
+
+	return callNode{name: name.text, f: f, args: args}, nil
+}
+
+// parseObject parses the entries of an object construction following its
+// opening brace.
+func (p *parser) parseObject() (node, error) {
+	var entries []objectEntry
+	for !p.accept("}") {
+		if len(entries) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+
+		var entry objectEntry
+		tok := p.next()
+		switch {
+		case tok.kind == tokenIdent || tok.kind == tokenString:
+			entry.key = literalNode{tok.text}
+		case tok.kind == tokenPunct && tok.text == "(":
+			key, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			entry.key = key
+		default:
+			return nil, fmt.Errorf("jq: unexpected %s at offset %d", tok, tok.offset)
+		}
+
+		if p.accept(":") {
+			value, err := p.parseAlternative()
+			if err != nil {
+				return nil, err
+			}
+			entry.value = value
+		} else if key, ok := entry.key.(literalNode); ok {
+			// {name} is shorthand for {name: .name}
+			entry.value = indexNode{key: key}
+		} else {
+			return nil, fmt.Errorf("jq: expected \":\", got %s at offset %d", p.peek(), p.peek().offset)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return objectNode{entries: entries}, nil
+}
@@ -0,0 +1,155 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/jefflinse/melatonin/jq"
+	"github.com/stretchr/testify/assert"
+)
+
+var doc = map[string]any{
+	"items": []any{
+		map[string]any{"id": "a", "price": float64(3), "tags": []any{"new"}},
+		map[string]any{"id": "b", "price": float64(1), "tags": []any{}},
+		map[string]any{"id": "c", "price": float64(2), "tags": []any{"new", "sale"}},
+	},
+	"owner": map[string]any{"name": "Ada", "email": nil},
+	"total": float64(3),
+}
+
+func TestRun(t *testing.T) {
+	for _, test := range []struct {
+		program string
+		want    []any
+	}{
+		{".", []any{doc}},
+		{".total", []any{float64(3)}},
+		{".owner.name", []any{"Ada"}},
+		{`."owner"."name"`, []any{"Ada"}},
+		{".missing", []any{nil}},
+		{".missing.deeper", []any{nil}},
+		{".items | length", []any{float64(3)}},
+		{".items[0].id", []any{"a"}},
+		{".items[-1].id", []any{"c"}},
+		{".items[5]", []any{nil}},
+		{".items[].id", []any{"a", "b", "c"}},
+		{".items[1:].[0].id", []any{"b"}},
+		{".items[:2] | map(.id)", []any{[]any{"a", "b"}}},
+		{`"hello"[1:3]`, []any{"el"}},
+		{".items | map(.price) | add", []any{float64(6)}},
+		{".items | map(.price * 2)", []any{[]any{float64(6), float64(2), float64(4)}}},
+		{"[.items[] | select(.price > 1) | .id]", []any{[]any{"a", "c"}}},
+		{".items | map(select(.tags | length > 0)) | length", []any{float64(2)}},
+		{".items | sort_by(.price) | map(.id)", []any{[]any{"b", "c", "a"}}},
+		{".items | max_by(.price) | .id", []any{"a"}},
+		{".items | min_by(.price) | .id", []any{"b"}},
+		{"[.items[].tags[]] | unique", []any{[]any{"new", "sale"}}},
+		{".items | group_by(.tags | length) | map(length)", []any{[]any{float64(1), float64(1), float64(1)}}},
+		{".items | all(.price > 0)", []any{true}},
+		{".items | any(.price > 2)", []any{true}},
+		{".items | map(.id) | join(\",\")", []any{"a,b,c"}},
+		{".owner | keys", []any{[]any{"email", "name"}}},
+		{".owner | has(\"email\")", []any{true}},
+		{".owner | to_entries | length", []any{float64(2)}},
+		{".owner.email // \"none\"", []any{"none"}},
+		{".owner.name // \"none\"", []any{"Ada"}},
+		{".total == 3 and .owner.name == \"Ada\"", []any{true}},
+		{".total < 3 or false", []any{false}},
+		{".total | not", []any{false}},
+		{".total, .owner.name", []any{float64(3), "Ada"}},
+		{"{id: .items[0].id, n: .total}", []any{map[string]any{"id": "a", "n": float64(3)}}},
+		{"{total}", []any{map[string]any{"total": float64(3)}}},
+		{"[.items[].price] | sort | reverse | first", []any{float64(3)}},
+		{"first(.items[].id)", []any{"a"}},
+		{"last(.items[].id)", []any{"c"}},
+		{".owner.name | ascii_upcase | test(\"^AD\")", []any{true}},
+		{".owner.name | startswith(\"A\"), endswith(\"x\")", []any{true, false}},
+		{"\"a-b\" | split(\"-\")", []any{[]any{"a", "b"}}},
+		{"[..] | length", []any{float64(21)}},
+		{".owner.name | type", []any{"string"}},
+		{".total | tostring", []any{"3"}},
+		{"\"42\" | tonumber", []any{float64(42)}},
+		{"[[1, [2]], 3] | flatten", []any{[]any{float64(1), float64(2), float64(3)}}},
+		{".items[0] | contains({tags: [\"new\"]})", []any{true}},
+		{"-.total + 1", []any{float64(-2)}},
+		{"7 % 4, 1 / 4", []any{float64(3), 0.25}},
+		{"[1, 2, 3] - [2]", []any{[]any{float64(1), float64(3)}}},
+		{".total.x?", nil},
+		{"[empty]", []any{[]any{}}},
+		{"null | length", []any{float64(0)}},
+		{". # a comment", []any{doc}},
+	} {
+		t.Run(test.program, func(t *testing.T) {
+			program, err := jq.Compile(test.program)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			got, err := program.Run(doc)
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestRunNormalizesInput(t *testing.T) {
+	type item struct {
+		ID int `json:"id"`
+	}
+
+	program, err := jq.Compile(".items | map(.id) | add")
+	assert.NoError(t, err)
+
+	got, err := program.Run(map[string]any{"items": []item{{1}, {2}}})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{float64(3)}, got)
+
+	got, err = program.Run(map[string]any{"items": []any{map[string]any{"id": int64(4)}}})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{float64(4)}, got)
+}
+
+func TestRunErrors(t *testing.T) {
+	for _, test := range []struct {
+		program string
+		wantErr string
+	}{
+		{".total.x", `jq: cannot index number with string ("x")`},
+		{".total[]", "jq: cannot iterate over number (3)"},
+		{".owner.name - 1", `jq: string ("Ada") and number (1) cannot be subtracted`},
+		{".total / 0", "jq: 3 and 0 cannot be divided because the divisor is zero"},
+		{".total | keys", "jq: keys: number (3) has no keys"},
+	} {
+		t.Run(test.program, func(t *testing.T) {
+			program, err := jq.Compile(test.program)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			_, err = program.Run(doc)
+			assert.EqualError(t, err, test.wantErr)
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, test := range []struct {
+		program string
+		wantErr string
+	}{
+		{"", "jq: unexpected end of program"},
+		{".items |", "jq: unexpected end of program"},
+		{".items[", "jq: unexpected end of program"},
+		{"(.a", `jq: expected ")", got end of program at offset 3`},
+		{".a .b)", `jq: unexpected ")" at offset 5`},
+		{"nope", "jq: unknown function nope/0 at offset 0"},
+		{"map", "jq: unknown function map/0 at offset 0"},
+		{`"abc`, "jq: unterminated string at offset 0"},
+		{".a & .b", `jq: unexpected character '&' at offset 3`},
+	} {
+		t.Run(test.program, func(t *testing.T) {
+			_, err := jq.Compile(test.program)
+			assert.EqualError(t, err, test.wantErr)
+		})
+	}
+}
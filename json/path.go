@@ -0,0 +1,154 @@
+package json
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Wildcard is a path segment that matches every key of an object or every
+// element of an array.
+const Wildcard = "*"
+
+// A Path identifies a set of values within a JSON document as a sequence of
+// object keys and array indices, any of which may be a wildcard.
+type Path []string
+
+// ParsePath parses a dot-separated path such as "data.*.updated_at" or
+// "items[0].id". Array indices may be written either as "[n]" or as a segment
+// of their own, and "[*]" is equivalent to "*". A leading "$", denoting the root
+// of the document as in JSONPath expressions such as "$.items[0].id", is
+// ignored.
+func ParsePath(path string) Path {
+	if path == "$" || strings.HasPrefix(path, "$.") || strings.HasPrefix(path, "$[") {
+		path = path[1:]
+	}
+
+	path = strings.ReplaceAll(path, "[", ".")
+	path = strings.ReplaceAll(path, "]", "")
+
+	p := Path{}
+	for _, segment := range strings.Split(path, ".") {
+		if segment != "" {
+			p = append(p, segment)
+		}
+	}
+
+	return p
+}
+
+// String returns the path in dot-separated form.
+func (p Path) String() string {
+	return strings.Join(p, ".")
+}
+
+// Lookup returns all values in a document matched by a path. Values matched by
+// a wildcard over an object are returned in the order of their keys.
+func Lookup(doc any, path string) []any {
+	return ParsePath(path).lookup(doc)
+}
+
+func (p Path) lookup(doc any) []any {
+	if len(p) == 0 {
+		return []any{doc}
+	}
+
+	switch v := doc.(type) {
+	case Object:
+		doc = map[string]any(v)
+	case Array:
+		doc = []any(v)
+	}
+
+	segment, rest := p[0], p[1:]
+	values := []any{}
+	switch v := doc.(type) {
+	case map[string]any:
+		if segment == Wildcard {
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+
+			sort.Strings(keys)
+			for _, key := range keys {
+				values = append(values, rest.lookup(v[key])...)
+			}
+		} else if value, ok := v[segment]; ok {
+			values = append(values, rest.lookup(value)...)
+		}
+
+	case []any:
+		if segment == Wildcard {
+			for _, value := range v {
+				values = append(values, rest.lookup(value)...)
+			}
+		} else if i, err := strconv.Atoi(segment); err == nil && i >= 0 && i < len(v) {
+			values = append(values, rest.lookup(v[i])...)
+		}
+	}
+
+	return values
+}
+
+// Without returns a copy of a document with all values matched by any of a set
+// of paths removed. Removed array elements are omitted from the copy. The
+// original document is not modified.
+func Without(doc any, paths ...string) any {
+	parsed := make([]Path, len(paths))
+	for i, path := range paths {
+		parsed[i] = ParsePath(path)
+	}
+
+	return without(doc, parsed)
+}
+
+func without(doc any, paths []Path) any {
+	switch v := doc.(type) {
+	case Object:
+		return Object(without(map[string]any(v), paths).(map[string]any))
+
+	case Array:
+		return Array(without([]any(v), paths).([]any))
+
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for key, value := range v {
+			if remaining, removed := descend(paths, key); !removed {
+				m[key] = without(value, remaining)
+			}
+		}
+		return m
+
+	case []any:
+		s := make([]any, 0, len(v))
+		for i, value := range v {
+			if remaining, removed := descend(paths, strconv.Itoa(i)); !removed {
+				s = append(s, without(value, remaining))
+			}
+		}
+		return s
+
+	default:
+		return doc
+	}
+}
+
+// descend returns the remainders of all paths whose first segment matches a
+// key, and whether any of the paths ends at that key.
+func descend(paths []Path, key string) ([]Path, bool) {
+	remaining := []Path{}
+	for _, path := range paths {
+		if len(path) == 0 || (path[0] != Wildcard && path[0] != key) {
+			continue
+		}
+
+		if len(path) == 1 {
+			return nil, true
+		}
+
+		remaining = append(remaining, path[1:])
+	}
+
+	return remaining, false
+}
@@ -0,0 +1,82 @@
+package json_test
+
+import (
+	"testing"
+
+	"github.com/jefflinse/melatonin/json"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePath(t *testing.T) {
+	for _, test := range []struct {
+		have string
+		want json.Path
+	}{
+		{"", json.Path{}},
+		{"foo", json.Path{"foo"}},
+		{"foo.bar", json.Path{"foo", "bar"}},
+		{"foo.*.bar", json.Path{"foo", "*", "bar"}},
+		{"foo[0].bar", json.Path{"foo", "0", "bar"}},
+		{"foo[*].bar", json.Path{"foo", "*", "bar"}},
+		{"[1][2]", json.Path{"1", "2"}},
+		{"$", json.Path{}},
+		{"$.items[0].id", json.Path{"items", "0", "id"}},
+		{"$[0]", json.Path{"0"}},
+		{"$foo", json.Path{"$foo"}},
+	} {
+		t.Run(test.have, func(t *testing.T) {
+			assert.Equal(t, test.want, json.ParsePath(test.have))
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	doc := map[string]any{
+		"data": []any{
+			map[string]any{"id": "a", "n": float64(1)},
+			map[string]any{"id": "b", "n": float64(2)},
+		},
+		"meta": map[string]any{"count": float64(2)},
+	}
+
+	for _, test := range []struct {
+		path string
+		want []any
+	}{
+		{"meta.count", []any{float64(2)}},
+		{"data[1].id", []any{"b"}},
+		{"data.*.n", []any{float64(1), float64(2)}},
+		{"data[5].id", []any{}},
+		{"missing", []any{}},
+		{"", []any{doc}},
+	} {
+		t.Run(test.path, func(t *testing.T) {
+			assert.Equal(t, test.want, json.Lookup(doc, test.path))
+		})
+	}
+}
+
+func TestWithout(t *testing.T) {
+	doc := map[string]any{
+		"data": []any{
+			map[string]any{"id": "a", "updated_at": "t1"},
+			map[string]any{"id": "b", "updated_at": "t2"},
+		},
+		"meta": map[string]any{"request_id": "xyz", "count": float64(2)},
+	}
+
+	got := json.Without(doc, "data.*.updated_at", "meta.request_id")
+	assert.Equal(t, map[string]any{
+		"data": []any{
+			map[string]any{"id": "a"},
+			map[string]any{"id": "b"},
+		},
+		"meta": map[string]any{"count": float64(2)},
+	}, got)
+
+	// the original document is unchanged
+	assert.Equal(t, "xyz", doc["meta"].(map[string]any)["request_id"])
+
+	assert.Equal(t, []any{"a", "c"}, json.Without([]any{"a", "b", "c"}, "[1]"))
+	assert.Equal(t, "foo", json.Without("foo", "bar"))
+}
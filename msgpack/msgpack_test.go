@@ -0,0 +1,103 @@
+package msgpack_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jefflinse/melatonin/msgpack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshal(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		value any
+		want  []byte
+	}{
+		{"nil", nil, []byte{0xc0}},
+		{"true", true, []byte{0xc3}},
+		{"false", false, []byte{0xc2}},
+		{"positive fixint", 7, []byte{0x07}},
+		{"negative fixint", -3, []byte{0xfd}},
+		{"uint8", 200, []byte{0xcc, 0xc8}},
+		{"uint16", 1000, []byte{0xcd, 0x03, 0xe8}},
+		{"int8", -100, []byte{0xd0, 0x9c}},
+		{"int32", -100000, []byte{0xd2, 0xff, 0xfe, 0x79, 0x60}},
+		{"float64", 1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"fixstr", "hi", []byte{0xa2, 'h', 'i'}},
+		{"binary", []byte{1, 2}, []byte{0xc4, 0x02, 0x01, 0x02}},
+		{"fixarray", []any{1, "a"}, []byte{0x92, 0x01, 0xa1, 'a'}},
+		{"fixmap with sorted keys", map[string]any{"b": 2, "a": 1}, []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x02}},
+		{"struct", struct {
+			ID int `json:"id"`
+		}{5}, []byte{0x81, 0xa2, 'i', 'd', 0x05}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := msgpack.Marshal(test.value)
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, b)
+		})
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		data []byte
+		want any
+		err  string
+	}{
+		{"nil", []byte{0xc0}, nil, ""},
+		{"positive fixint", []byte{0x07}, int64(7), ""},
+		{"negative fixint", []byte{0xfd}, int64(-3), ""},
+		{"uint32", []byte{0xce, 0, 0x01, 0, 0}, int64(65536), ""},
+		{"int16", []byte{0xd1, 0xff, 0x38}, int64(-200), ""},
+		{"float32", []byte{0xca, 0x3f, 0xc0, 0, 0}, float64(1.5), ""},
+		{"str8", []byte{0xd9, 0x02, 'h', 'i'}, "hi", ""},
+		{"binary", []byte{0xc4, 0x01, 0xff}, []byte{0xff}, ""},
+		{"nested", []byte{0x81, 0xa4, 'u', 's', 'e', 'r', 0x92, 0xc3, 0xa1, 'x'},
+			map[string]any{"user": []any{true, "x"}}, ""},
+		{"integer map keys", []byte{0x81, 0x01, 0xa1, 'a'}, map[string]any{"1": "a"}, ""},
+		{"timestamp", []byte{0xd6, 0xff, 0, 0, 0, 0}, "1970-01-01T00:00:00Z", ""},
+		{"truncated", []byte{0x92, 0x01}, nil, "msgpack: unexpected end of document"},
+		{"trailing data", []byte{0x01, 0x02}, nil, "msgpack: 1 unexpected bytes after document"},
+		{"invalid format", []byte{0xc1}, nil, "msgpack: invalid format byte 0xc1"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var got any
+			err := msgpack.Unmarshal(test.data, &got)
+			if test.err != "" {
+				if assert.Error(t, err) {
+					assert.Equal(t, test.err, err.Error())
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	type user struct {
+		ID    int      `json:"id"`
+		Name  string   `json:"name"`
+		Tags  []string `json:"tags"`
+		Score float64  `json:"score"`
+	}
+
+	want := user{ID: 70000, Name: "alice", Tags: []string{"admin"}, Score: -2.25}
+	b, err := msgpack.Marshal(want)
+	assert.NoError(t, err)
+
+	var got user
+	assert.NoError(t, msgpack.Unmarshal(b, &got))
+	assert.Equal(t, want, got)
+
+	raw, err := msgpack.Marshal(json.RawMessage(`{"a":[1,2.5]}`))
+	assert.NoError(t, err)
+	var generic any
+	assert.NoError(t, msgpack.Unmarshal(raw, &generic))
+	assert.Equal(t, map[string]any{"a": []any{int64(1), 2.5}}, generic)
+}
@@ -0,0 +1,259 @@
+package mt
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Allure result statuses.
+const (
+	allureStatusPassed = "passed"
+	allureStatusFailed = "failed"
+)
+
+type allureResult struct {
+	UUID          string              `json:"uuid"`
+	HistoryID     string              `json:"historyId"`
+	Name          string              `json:"name"`
+	FullName      string              `json:"fullName"`
+	Status        string              `json:"status"`
+	StatusDetails *allureStatusDetail `json:"statusDetails,omitempty"`
+	Stage         string              `json:"stage"`
+	Start         int64               `json:"start"`
+	Stop          int64               `json:"stop"`
+	Labels        []allureLabel       `json:"labels"`
+	Steps         []allureStep        `json:"steps"`
+	Attachments   []allureAttachment  `json:"attachments"`
+}
+
+type allureStatusDetail struct {
+	Message string `json:"message"`
+	Trace   string `json:"trace,omitempty"`
+}
+
+type allureLabel struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type allureStep struct {
+	Name        string             `json:"name"`
+	Status      string             `json:"status"`
+	Stage       string             `json:"stage"`
+	Attachments []allureAttachment `json:"attachments"`
+}
+
+type allureAttachment struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Type   string `json:"type"`
+}
+
+// WriteAllureResults writes the results of a group run to the given directory
+// in the Allure results format, creating the directory if necessary.
+//
+// One result file is written for each test, with the HTTP request and response
+// of each test attached, along with any attachments added to its result. Any
+// run metadata is written to the Allure environment file.
+func WriteAllureResults(dir string, results *GroupRunResult) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("allure results: %w", err)
+	}
+
+	if err := writeAllureEnvironment(dir, results.Metadata); err != nil {
+		return fmt.Errorf("allure results: %w", err)
+	}
+
+	return writeAllureGroupResults(dir, results, nil)
+}
+
+func writeAllureGroupResults(dir string, results *GroupRunResult, suites []string) error {
+	if results.Group != nil && results.Group.Name != "" {
+		suites = append(suites, results.Group.Name)
+	}
+
+	for _, testResult := range results.TestResults {
+		if err := writeAllureTestResult(dir, testResult, suites); err != nil {
+			return fmt.Errorf("allure results: %w", err)
+		}
+	}
+
+	for _, subgroupResult := range results.SubgroupResults {
+		if err := writeAllureGroupResults(dir, subgroupResult, suites); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeAllureTestResult(dir string, result TestRunResult, suites []string) error {
+	id, err := newUUID()
+	if err != nil {
+		return err
+	}
+
+	name := result.TestCase.Description()
+	ar := allureResult{
+		UUID:        id,
+		HistoryID:   result.Fingerprint,
+		Name:        name,
+		FullName:    strings.Join(append(suites, name), " › "),
+		Status:      allureStatusPassed,
+		Stage:       "finished",
+		Start:       result.StartedAt.UnixMilli(),
+		Stop:        result.EndedAt.UnixMilli(),
+		Labels:      allureSuiteLabels(suites),
+		Steps:       []allureStep{},
+		Attachments: []allureAttachment{},
+	}
+
+	if failures := result.TestResult.Failures(); len(failures) > 0 {
+		ar.Status = allureStatusFailed
+		messages := make([]string, len(failures))
+		for i, failure := range failures {
+			messages[i] = failure.Error()
+		}
+		ar.StatusDetails = &allureStatusDetail{Message: strings.Join(messages, "\n")}
+		if location := testCaseLocation(result.TestCase); location != "" {
+			ar.StatusDetails.Trace = "at " + location
+		}
+	}
+
+	switch testResult := result.TestResult.(type) {
+	case *HTTPTestCaseResult:
+		attachments, err := writeAllureHTTPAttachments(dir, testResult)
+		if err != nil {
+			return err
+		}
+		ar.Attachments = append(ar.Attachments, attachments...)
+
+	case *ScenarioResult:
+		for _, stepResult := range testResult.StepResults {
+			step := allureStep{
+				Name:        stepResult.TestCase().Description(),
+				Status:      allureStatusPassed,
+				Stage:       "finished",
+				Attachments: []allureAttachment{},
+			}
+
+			if len(stepResult.Failures()) > 0 {
+				step.Status = allureStatusFailed
+			}
+
+			if httpResult, ok := stepResult.(*HTTPTestCaseResult); ok {
+				attachments, err := writeAllureHTTPAttachments(dir, httpResult)
+				if err != nil {
+					return err
+				}
+				step.Attachments = append(step.Attachments, attachments...)
+			}
+
+			ar.Steps = append(ar.Steps, step)
+		}
+	}
+
+	b, err := json.MarshalIndent(ar, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, id+"-result.json"), b, 0644)
+}
+
+func writeAllureHTTPAttachments(dir string, result *HTTPTestCaseResult) ([]allureAttachment, error) {
+	tc := result.testCase
+	request, err := httputil.DumpRequestOut(tc.request, false)
+	if err != nil {
+		request, err = httputil.DumpRequest(tc.request, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	response := &strings.Builder{}
+	fmt.Fprintf(response, "%d\n", result.Status)
+	result.Headers.Write(response)
+	fmt.Fprintf(response, "\n%s", result.Body)
+
+	attachments := []allureAttachment{}
+	for _, a := range append([]Attachment{
+		{Name: "Request", ContentType: "text/plain", Data: request},
+		{Name: "Response", ContentType: "text/plain", Data: []byte(response.String())},
+	}, result.Attachments...) {
+		attachment, err := writeAllureAttachment(dir, a.Name, a.ContentType, a.Data)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, nil
+}
+
+func writeAllureAttachment(dir, name, contentType string, content []byte) (allureAttachment, error) {
+	id, err := newUUID()
+	if err != nil {
+		return allureAttachment{}, err
+	}
+
+	source := id + "-attachment" + attachmentExtension(contentType)
+	if err := os.WriteFile(filepath.Join(dir, source), content, 0644); err != nil {
+		return allureAttachment{}, err
+	}
+
+	return allureAttachment{
+		Name:   name,
+		Source: source,
+		Type:   contentType,
+	}, nil
+}
+
+// attachmentExtension returns the file extension for an attachment with a
+// content type, which is named after its subtype if that is a known extension
+// for the type, such as ".png" or ".json", and ".txt" otherwise.
+func attachmentExtension(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ".txt"
+	}
+
+	extensions, _ := mime.ExtensionsByType(mediaType)
+	subtype := "." + mediaType[strings.Index(mediaType, "/")+1:]
+	for _, extension := range extensions {
+		if extension == subtype {
+			return extension
+		}
+	}
+
+	return ".txt"
+}
+
+func allureSuiteLabels(suites []string) []allureLabel {
+	labels := []allureLabel{{Name: "framework", Value: "melatonin"}}
+	for i, label := range []string{"parentSuite", "suite", "subSuite"} {
+		if i < len(suites) {
+			labels = append(labels, allureLabel{Name: label, Value: suites[i]})
+		}
+	}
+
+	return labels
+}
+
+// newUUID generates a random (version 4) UUID.
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -0,0 +1,39 @@
+package mt
+
+// An Attachment is a named piece of content attached to a test result, such as
+// a screenshot from a downstream check, a raw payload dump, or a log excerpt.
+// Attachments are included in JSON and Allure results.
+type Attachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
+
+// Attach adds an attachment to the result and returns the result. It can be
+// called from a phase hook, for example:
+//
+//	tc.OnPhase(func(phase mt.Phase, result *mt.HTTPTestCaseResult) {
+//		if phase == mt.AfterReceive {
+//			result.Attach("payload", "application/json", result.Body)
+//		}
+//	})
+func (r *HTTPTestCaseResult) Attach(name, contentType string, data []byte) *HTTPTestCaseResult {
+	r.Attachments = append(r.Attachments, Attachment{
+		Name:        name,
+		ContentType: contentType,
+		Data:        data,
+	})
+	return r
+}
+
+// Attach adds an attachment to the result of the test case's current execution,
+// for attaching content from predicates and functions that don't have access to
+// the result, and returns the test case. Attachments added while the test case
+// is not executing are added to the result of its most recent execution, or are
+// discarded if it hasn't been executed.
+func (tc *HTTPTestCase) Attach(name, contentType string, data []byte) *HTTPTestCase {
+	if tc.lastResult != nil {
+		tc.lastResult.Attach(name, contentType, data)
+	}
+	return tc
+}
@@ -0,0 +1,153 @@
+package mt
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// Credentials authenticate a request, typically by setting its Authorization
+// header.
+type Credentials func(req *http.Request)
+
+// BasicAuth returns Credentials that authenticate a request using HTTP basic
+// authentication.
+func BasicAuth(username, password string) Credentials {
+	return func(req *http.Request) {
+		token := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		req.Header.Set("Authorization", "Basic "+token)
+	}
+}
+
+// BearerToken returns Credentials that authenticate a request using a bearer
+// token.
+func BearerToken(token string) Credentials {
+	return func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// HeaderCredentials returns Credentials that authenticate a request by setting
+// a header, such as an API key.
+func HeaderCredentials(key, value string) Credentials {
+	return func(req *http.Request) {
+		req.Header.Set(key, value)
+	}
+}
+
+// NoCredentials returns Credentials that remove any Authorization and Cookie
+// headers from a request, making it anonymous.
+func NoCredentials() Credentials {
+	return func(req *http.Request) {
+		req.Header.Del("Authorization")
+		req.Header.Del("Cookie")
+	}
+}
+
+// An AuthMatrix re-runs HTTP test cases with the credentials of each of a set
+// of roles, checking that each role is allowed or denied access as expected.
+// This automates checks for broken access control across an API.
+//
+//	matrix := mt.NewAuthMatrix(map[string]mt.Credentials{
+//		"admin":     mt.BearerToken(adminToken),
+//		"viewer":    mt.BearerToken(viewerToken),
+//		"anonymous": mt.NoCredentials(),
+//	})
+//
+//	matrix.Expect(api.GET("/users"), map[string]int{"admin": 200, "viewer": 200, "anonymous": 401})
+//	matrix.Expect(api.DELETE("/users/1"), map[string]int{"admin": 204})
+//
+//	mt.RunTestGroupT(t, matrix.TestGroup("Access Control"))
+type AuthMatrix struct {
+	// Roles are the credentials of each role, by role name.
+	Roles map[string]Credentials
+
+	rows []authMatrixRow
+}
+
+// An authMatrixRow is a test case of an auth matrix with the expected status
+// for each role.
+type authMatrixRow struct {
+	tc       *HTTPTestCase
+	statuses map[string]int
+}
+
+// NewAuthMatrix creates an AuthMatrix for the given roles.
+func NewAuthMatrix(roles map[string]Credentials) *AuthMatrix {
+	return &AuthMatrix{
+		Roles: roles,
+	}
+}
+
+// Expect adds a test case to the matrix, with the status expected for each role.
+// Roles without an expected status are expected to be denied access with a 401
+// or 403 status.
+//
+// Only the request of the test case is used; its expectations are not.
+func (m *AuthMatrix) Expect(tc *HTTPTestCase, statuses map[string]int) *AuthMatrix {
+	m.rows = append(m.rows, authMatrixRow{tc, statuses})
+	return m
+}
+
+// Tests returns a test case for each test case of the matrix and each role, in
+// the order the test cases were added and in order of role names.
+func (m *AuthMatrix) Tests() []TestCase {
+	roles := make([]string, 0, len(m.Roles))
+	for role := range m.Roles {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	tests := []TestCase{}
+	for _, row := range m.rows {
+		for _, role := range roles {
+			tc := row.tc.withCredentials(m.Roles[role])
+			tc.Desc = fmt.Sprintf("%s as %s", row.tc.Description(), role)
+			if status, ok := row.statuses[role]; ok {
+				tc.ExpectStatus(status)
+			} else {
+				tc.ExpectAnyOf(
+					func(tc *HTTPTestCase) { tc.ExpectStatus(http.StatusUnauthorized) },
+					func(tc *HTTPTestCase) { tc.ExpectStatus(http.StatusForbidden) },
+				)
+			}
+
+			tests = append(tests, tc)
+		}
+	}
+
+	return tests
+}
+
+// TestGroup returns a test group containing the tests of the matrix.
+func (m *AuthMatrix) TestGroup(name string) *TestGroup {
+	return NewTestGroup(name).AddTests(m.Tests()...)
+}
+
+// withCredentials returns a test case making the same request as the test case,
+// authenticated with the given credentials, and without any expectations.
+func (tc *HTTPTestCase) withCredentials(credentials Credentials) *HTTPTestCase {
+	req := tc.request.Clone(tc.ctx)
+	req.URL.Path = tc.path
+	req.URL.RawQuery = tc.rawQuery
+	credentials(req)
+
+	clone := &HTTPTestCase{
+		Desc:           tc.Desc,
+		RequirementIDs: tc.RequirementIDs,
+		SeverityLevel:  tc.SeverityLevel,
+		pathParams:     tc.pathParams,
+		queryParams:    tc.queryParams,
+		requestBody:    tc.requestBody,
+		tctx:           tc.tctx,
+		clientCert:     tc.clientCert,
+		source:         tc.source,
+		userAgent:      tc.userAgent,
+		cacheBusters:   tc.cacheBusters,
+		timeout:        tc.timeout,
+	}
+	clone.useRequest(req)
+
+	return clone
+}
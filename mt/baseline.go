@@ -0,0 +1,58 @@
+package mt
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// WithBaselineFile sets a baseline file listing the fingerprints of tests that
+// are known to fail and returns the TestRunner.
+//
+// Failures of tests listed in the baseline are reported but suppressed, so that
+// only new failures cause the test run to fail. This allows adopting melatonin
+// against an API with pre-existing known issues.
+//
+// The baseline is regenerated from the failures of the test run when
+// UpdateBaseline is true. A missing file is treated as an empty baseline.
+func (r *TestRunner) WithBaselineFile(path string) *TestRunner {
+	ids, err := readIDFile(path)
+	if err != nil {
+		log.Fatalf("failed to read baseline file %q: %v", path, err)
+	}
+
+	r.BaselineFile = path
+	r.baseline = map[string]bool{}
+	for _, id := range ids {
+		r.baseline[id] = true
+	}
+
+	return r
+}
+
+// WithBaselineUpdate sets the UpdateBaseline field of the TestRunner and returns
+// the TestRunner.
+func (r *TestRunner) WithBaselineUpdate(update bool) *TestRunner {
+	r.UpdateBaseline = update
+	return r
+}
+
+// isBaselined returns true if a test run result refers to a test listed in the
+// baseline.
+func (r *TestRunner) isBaselined(result TestRunResult) bool {
+	return r.baseline[result.Fingerprint]
+}
+
+// writeBaselineFile writes the fingerprints of all failed tests in a group run
+// to a baseline file.
+func writeBaselineFile(path string, result *GroupRunResult) error {
+	lines := []string{"# melatonin baseline: known failing tests"}
+	forEachTestRunResult(result, func(result TestRunResult) {
+		if len(result.TestResult.Failures()) > 0 {
+			lines = append(lines, fmt.Sprintf("%s # %s", result.Fingerprint, result.TestCase.Description()))
+		}
+	})
+
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
@@ -0,0 +1,156 @@
+package mt
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// A BatchEncoder encodes a set of requests into the body of a single batch
+// request.
+type BatchEncoder func(requests []*http.Request) ([]byte, error)
+
+// A BatchDecoder decodes the body of a batch response into the responses to
+// each request in the batch, in the same order as the requests.
+type BatchDecoder func(body []byte) ([]BatchResponse, error)
+
+// A BatchResponse is the response to a single request within a batch.
+type BatchResponse struct {
+	Status  int
+	Headers http.Header
+	Body    []byte
+}
+
+// A Batch is a set of HTTP test cases whose requests are sent together in a
+// single request to a batch endpoint.
+//
+// Each test case is still reported separately and validated against its own
+// response. All requests are sent when the first test case of the batch is
+// executed, so any BeforeFunc of a later test case runs after its request has
+// already been sent.
+type Batch struct {
+	// Request is the batch request. It can be used to set headers or
+	// expectations for the batch request itself.
+	Request *HTTPTestCase
+
+	// Encode encodes the requests of the batch's test cases into the body of
+	// the batch request.
+	Encode BatchEncoder
+
+	// Decode decodes the body of the batch response.
+	Decode BatchDecoder
+
+	cases     []*HTTPTestCase
+	sent      bool
+	consumed  []bool
+	responses []BatchResponse
+	response  *HTTPTestCaseResult
+	err       error
+}
+
+// NewBatch creates a Batch whose requests are sent in a single POST request to
+// the given path.
+//
+//	batch := api.NewBatch("/batch", encodeBatch, decodeBatch).Add(
+//		api.GET("/users/1").ExpectStatus(200),
+//		api.GET("/users/2").ExpectStatus(404),
+//	)
+//
+//	mt.RunTestsT(t, batch.Cases()...)
+func (c *HTTPTestContext) NewBatch(path string, encode BatchEncoder, decode BatchDecoder) *Batch {
+	return &Batch{
+		Request: c.POST(path, "batch request"),
+		Encode:  encode,
+		Decode:  decode,
+	}
+}
+
+// Add adds one or more test cases to the batch.
+func (b *Batch) Add(cases ...*HTTPTestCase) *Batch {
+	for _, tc := range cases {
+		tc.batch = b
+		tc.batchIndex = len(b.cases)
+		b.cases = append(b.cases, tc)
+		b.consumed = append(b.consumed, false)
+	}
+
+	return b
+}
+
+// Cases returns the test cases of the batch, to be run by a test runner.
+func (b *Batch) Cases() []TestCase {
+	cases := make([]TestCase, len(b.cases))
+	for i, tc := range b.cases {
+		cases[i] = tc
+	}
+
+	return cases
+}
+
+// receive copies the response to the request of the i-th test case of the batch
+// into result, sending the batch request first if the response was not yet
+// received.
+func (b *Batch) receive(i int, result *HTTPTestCaseResult) error {
+	if !b.sent || b.consumed[i] {
+		b.send()
+	}
+
+	b.consumed[i] = true
+	if b.err != nil {
+		return b.err
+	}
+
+	response := b.responses[i]
+	result.Status = response.Status
+	result.StatusText = http.StatusText(response.Status)
+	result.Headers = response.Headers
+	result.Body = response.Body
+	result.Proto = b.response.Proto
+	result.TLS = b.response.TLS
+	return nil
+}
+
+// send sends the batch request and decodes the batch response.
+func (b *Batch) send() {
+	b.sent = true
+	b.responses = nil
+	for i := range b.consumed {
+		b.consumed[i] = false
+	}
+
+	b.err = b.roundTrip()
+	if b.err != nil {
+		b.err = fmt.Errorf("batch request: %w", b.err)
+	}
+}
+
+func (b *Batch) roundTrip() error {
+	requests := make([]*http.Request, len(b.cases))
+	for i, tc := range b.cases {
+		if _, err := tc.prepareRequest(); err != nil {
+			return fmt.Errorf("request %d (%s): %w", i+1, tc.Description(), err)
+		}
+
+		requests[i] = tc.request
+	}
+
+	b.Request.requestBody = func() ([]byte, error) {
+		return b.Encode(requests)
+	}
+
+	b.response = b.Request.Execute().(*HTTPTestCaseResult)
+	if failures := b.response.Failures(); len(failures) > 0 {
+		return failures[0]
+	}
+
+	responses, err := b.Decode(b.response.Body)
+	if err != nil {
+		return fmt.Errorf("failed to decode batch response: %w", err)
+	}
+
+	if len(responses) != len(requests) {
+		return fmt.Errorf("expected %d responses, got %d", len(requests), len(responses))
+	}
+
+	b.responses = responses
+	return nil
+}
@@ -0,0 +1,73 @@
+package mt_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jefflinse/melatonin/mt"
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeBatch(requests []*http.Request) ([]byte, error) {
+	paths := make([]string, len(requests))
+	for i, req := range requests {
+		paths[i] = req.URL.Path
+	}
+
+	return json.Marshal(paths)
+}
+
+func decodeBatch(body []byte) ([]mt.BatchResponse, error) {
+	var statuses []int
+	if err := json.Unmarshal(body, &statuses); err != nil {
+		return nil, err
+	}
+
+	responses := make([]mt.BatchResponse, len(statuses))
+	for i, status := range statuses {
+		responses[i] = mt.BatchResponse{Status: status, Body: []byte(fmt.Sprint(status))}
+	}
+
+	return responses, nil
+}
+
+func TestBatch(t *testing.T) {
+	batches := 0
+	ctx := mt.NewHandlerContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		batches++
+		var paths []string
+		if err := json.NewDecoder(r.Body).Decode(&paths); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		statuses := make([]int, len(paths))
+		for i, path := range paths {
+			statuses[i] = http.StatusOK
+			if path == "/users/2" {
+				statuses[i] = http.StatusNotFound
+			}
+		}
+		json.NewEncoder(w).Encode(statuses)
+	}))
+
+	batch := ctx.NewBatch("/batch", encodeBatch, decodeBatch).Add(
+		ctx.GET("/users/1").ExpectStatus(http.StatusOK).ExpectBody("200"),
+		ctx.GET("/users/2").ExpectStatus(http.StatusNotFound),
+		ctx.GET("/users/3").ExpectStatus(http.StatusNotFound),
+	)
+
+	result := mt.NewTestRunner().WithContinueOnFailure(true).RunTests(batch.Cases()...)
+	assert.Equal(t, 1, batches)
+	assert.Equal(t, 2, result.Passed)
+	assert.Equal(t, 1, result.Failed)
+	failures := result.TestResults[2].TestResult.Failures()
+	if assert.Len(t, failures, 1) {
+		assert.EqualError(t, failures[0], "expected status 404, got 200")
+	}
+
+	mt.NewTestRunner().WithContinueOnFailure(true).RunTests(batch.Cases()...)
+	assert.Equal(t, 2, batches)
+}
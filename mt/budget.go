@@ -0,0 +1,109 @@
+package mt
+
+import (
+	"fmt"
+	"time"
+)
+
+// A Budget sets limits that every test in a test run is evaluated against,
+// independent of its expectations. A zero limit is not enforced.
+type Budget struct {
+	// MaxResponseSize is the maximum size of any response body, in bytes.
+	MaxResponseSize int
+
+	// MaxHeaderCount is the maximum number of header fields in any response.
+	MaxHeaderCount int
+
+	// MaxHeaderSize is the maximum total size of the header fields of any
+	// response, in bytes, counting each "Key: value" line.
+	MaxHeaderSize int
+
+	// MaxLatency is the maximum duration of any test.
+	MaxLatency time.Duration
+
+	// Enforce indicates whether budget violations fail the test run. Otherwise
+	// they are only reported.
+	Enforce bool
+}
+
+// A BudgetViolation records a test exceeding a limit of the runner's budget.
+type BudgetViolation struct {
+	// Test is the description of the test that exceeded the limit.
+	Test string `json:"test"`
+
+	// Limit is the name of the limit that was exceeded, such as "response size".
+	Limit string `json:"limit"`
+
+	// Max is the value of the limit.
+	Max string `json:"max"`
+
+	// Actual is the value that exceeded the limit.
+	Actual string `json:"actual"`
+}
+
+func (v BudgetViolation) String() string {
+	return fmt.Sprintf("%s: %s %s exceeds budget of %s", v.Test, v.Limit, v.Actual, v.Max)
+}
+
+// WithBudget sets limits that every test in the test run is evaluated against
+// and returns the TestRunner.
+func (r *TestRunner) WithBudget(budget Budget) *TestRunner {
+	r.Budget = &budget
+	return r
+}
+
+// evaluateBudget returns every violation of the runner's budget by the tests in
+// a group run.
+func (r *TestRunner) evaluateBudget(result *GroupRunResult) []BudgetViolation {
+	violations := []BudgetViolation{}
+	forEachTestRunResult(result, func(runResult TestRunResult) {
+		test := runResult.TestCase.Description()
+		if r.Budget.MaxLatency > 0 && runResult.Duration > r.Budget.MaxLatency {
+			violations = append(violations, BudgetViolation{
+				Test:   test,
+				Limit:  "latency",
+				Max:    r.Budget.MaxLatency.String(),
+				Actual: runResult.Duration.String(),
+			})
+		}
+
+		for _, httpResult := range httpResults(runResult.TestResult) {
+			if r.Budget.MaxResponseSize > 0 && len(httpResult.Body) > r.Budget.MaxResponseSize {
+				violations = append(violations, BudgetViolation{
+					Test:   test,
+					Limit:  "response size",
+					Max:    fmt.Sprintf("%d bytes", r.Budget.MaxResponseSize),
+					Actual: fmt.Sprintf("%d bytes", len(httpResult.Body)),
+				})
+			}
+
+			count, size := 0, 0
+			for key, values := range httpResult.Headers {
+				for _, value := range values {
+					count++
+					size += len(key) + len(": ") + len(value)
+				}
+			}
+
+			if r.Budget.MaxHeaderCount > 0 && count > r.Budget.MaxHeaderCount {
+				violations = append(violations, BudgetViolation{
+					Test:   test,
+					Limit:  "header count",
+					Max:    fmt.Sprintf("%d", r.Budget.MaxHeaderCount),
+					Actual: fmt.Sprintf("%d", count),
+				})
+			}
+
+			if r.Budget.MaxHeaderSize > 0 && size > r.Budget.MaxHeaderSize {
+				violations = append(violations, BudgetViolation{
+					Test:   test,
+					Limit:  "header size",
+					Max:    fmt.Sprintf("%d bytes", r.Budget.MaxHeaderSize),
+					Actual: fmt.Sprintf("%d bytes", size),
+				})
+			}
+		}
+	})
+
+	return violations
+}
@@ -0,0 +1,76 @@
+package mt
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+// A CacheBuster modifies a request so that it is served by the origin rather
+// than by any caching layer in front of it, such as a CDN.
+type CacheBuster func(req *http.Request)
+
+var cacheBusterSeq uint64
+
+// CacheBustQuery returns a CacheBuster that adds a query parameter with a value
+// unique to each request.
+func CacheBustQuery(param string) CacheBuster {
+	return func(req *http.Request) {
+		value := fmt.Sprintf("%d-%d", time.Now().UnixNano(), atomic.AddUint64(&cacheBusterSeq, 1))
+		param := url.QueryEscape(param) + "=" + value
+		if req.URL.RawQuery != "" {
+			req.URL.RawQuery += "&" + param
+		} else {
+			req.URL.RawQuery = param
+		}
+	}
+}
+
+// CacheBustHeaders returns a CacheBuster that sets "Cache-Control: no-cache" and
+// "Pragma: no-cache" request headers.
+func CacheBustHeaders() CacheBuster {
+	return func(req *http.Request) {
+		req.Header.Set("Cache-Control", "no-cache")
+		req.Header.Set("Pragma", "no-cache")
+	}
+}
+
+// WithCacheBuster sets the strategies used to bypass caching layers for the
+// requests of all test cases created from the context, and returns the context.
+// If no strategies are given, a "_cb" query parameter unique to each request is
+// added.
+func (c *HTTPTestContext) WithCacheBuster(strategies ...CacheBuster) *HTTPTestContext {
+	c.CacheBusters = defaultCacheBusters(strategies)
+	return c
+}
+
+// WithCacheBuster sets the strategies used to bypass caching layers for the
+// test case's request, overriding any set by the context, and returns the
+// test case. If no strategies are given, a "_cb" query parameter unique to each
+// request is added.
+func (tc *HTTPTestCase) WithCacheBuster(strategies ...CacheBuster) *HTTPTestCase {
+	tc.cacheBusters = defaultCacheBusters(strategies)
+	return tc
+}
+
+// bustCache applies the test case's cache busting strategies to its request.
+func (tc *HTTPTestCase) bustCache() {
+	busters := tc.cacheBusters
+	if busters == nil {
+		busters = tc.tctx.CacheBusters
+	}
+
+	for _, bust := range busters {
+		bust(tc.request)
+	}
+}
+
+func defaultCacheBusters(strategies []CacheBuster) []CacheBuster {
+	if len(strategies) == 0 {
+		return []CacheBuster{CacheBustQuery("_cb")}
+	}
+
+	return strategies
+}
@@ -0,0 +1,110 @@
+package mt
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// unknownVersion is the version reported for results whose response did not
+// identify the responding backend.
+const unknownVersion = "unknown"
+
+// A CanaryResult contains the results of a canary run, in which a test group is
+// run repeatedly while a deployment rolls out.
+type CanaryResult struct {
+	// Runs are the results of each run of the test group.
+	Runs []*GroupRunResult `json:"runs"`
+
+	// Versions are the test statistics of each backend version that responded
+	// during the canary run.
+	Versions map[string]*VersionStats `json:"versions"`
+}
+
+// VersionStats are the test statistics for a single backend version.
+type VersionStats struct {
+	Passed int `json:"passed"`
+	Failed int `json:"failed"`
+}
+
+// PassRate returns the fraction of tests answered by the version that passed.
+func (s VersionStats) PassRate() float64 {
+	if s.Passed+s.Failed == 0 {
+		return 0
+	}
+
+	return float64(s.Passed) / float64(s.Passed+s.Failed)
+}
+
+// SortedVersions returns the backend versions that responded during the canary
+// run, sorted.
+func (r *CanaryResult) SortedVersions() []string {
+	versions := make([]string, 0, len(r.Versions))
+	for version := range r.Versions {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+
+	return versions
+}
+
+// WithVersionHeader sets the VersionHeader field of the TestRunner and returns
+// the TestRunner.
+func (r *TestRunner) WithVersionHeader(header string) *TestRunner {
+	r.VersionHeader = header
+	return r
+}
+
+// RunCanary runs a test group continuously, pausing for the given interval
+// between runs, until the context is done. This is useful for testing a
+// deployment while it rolls out.
+//
+// Each test result is tagged with the version of the backend that responded,
+// as identified by the runner's VersionHeader, and pass rates are reported for
+// each version.
+func (r *TestRunner) RunCanary(ctx context.Context, group *TestGroup, interval time.Duration) *CanaryResult {
+	result := &CanaryResult{
+		Versions: map[string]*VersionStats{},
+	}
+
+	for ctx.Err() == nil {
+		run := r.RunTestGroup(group)
+		result.Runs = append(result.Runs, run)
+
+		forEachTestRunResult(run, func(testResult TestRunResult) {
+			version := testResult.BackendVersion
+			if version == "" {
+				version = unknownVersion
+			}
+
+			stats, ok := result.Versions[version]
+			if !ok {
+				stats = &VersionStats{}
+				result.Versions[version] = stats
+			}
+
+			if len(testResult.TestResult.Failures()) > 0 {
+				stats.Failed++
+			} else {
+				stats.Passed++
+			}
+		})
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(interval):
+		}
+	}
+
+	return result
+}
+
+// backendVersion returns the version of the backend that responded to a test,
+// as identified by the given response header.
+func backendVersion(result TestResult, header string) string {
+	if httpResult, ok := result.(*HTTPTestCaseResult); ok {
+		return httpResult.Headers.Get(header)
+	}
+
+	return ""
+}
@@ -0,0 +1,85 @@
+package mt
+
+import (
+	"crypto/x509"
+	"fmt"
+	"time"
+)
+
+// A CertificateMatcher is a function that checks the leaf certificate presented
+// by a server and possibly returns an error.
+type CertificateMatcher func(cert *x509.Certificate) error
+
+// ExpectCertificate adds a matcher for the leaf certificate presented by the
+// server, for example:
+//
+//	tc.ExpectCertificate(mt.CertificateValidFor(30 * 24 * time.Hour))
+//
+// A test case with certificate expectations fails if the response was not
+// received over TLS.
+func (tc *HTTPTestCase) ExpectCertificate(matchers ...CertificateMatcher) *HTTPTestCase {
+	tc.Expectations.Certificate = append(tc.Expectations.Certificate, matchers...)
+	return tc
+}
+
+// CertificateCommonName creates a matcher requiring a certificate's subject
+// common name to equal an expected name.
+func CertificateCommonName(name string) CertificateMatcher {
+	return func(cert *x509.Certificate) error {
+		if cert.Subject.CommonName != name {
+			return fmt.Errorf("expected certificate common name %q, got %q", name, cert.Subject.CommonName)
+		}
+
+		return nil
+	}
+}
+
+// CertificateDNSName creates a matcher requiring a certificate to be valid for a
+// host name, according to its subject alternative names.
+func CertificateDNSName(host string) CertificateMatcher {
+	return func(cert *x509.Certificate) error {
+		if err := cert.VerifyHostname(host); err != nil {
+			return fmt.Errorf("expected certificate valid for %q, got SANs %v", host, cert.DNSNames)
+		}
+
+		return nil
+	}
+}
+
+// CertificateValidFor creates a matcher requiring a certificate to be currently
+// valid and to remain valid for at least a given duration.
+func CertificateValidFor(d time.Duration) CertificateMatcher {
+	return func(cert *x509.Certificate) error {
+		now := time.Now()
+		if now.Before(cert.NotBefore) {
+			return fmt.Errorf("expected certificate to be valid, but it is not valid until %s", cert.NotBefore.Format(time.RFC3339))
+		}
+
+		if now.Add(d).After(cert.NotAfter) {
+			return fmt.Errorf("expected certificate to be valid for %s, but it expires %s", d, cert.NotAfter.Format(time.RFC3339))
+		}
+
+		return nil
+	}
+}
+
+// validateCertificate checks the leaf certificate presented by the server
+// against a set of matchers and returns any failures.
+func (r *HTTPTestCaseResult) validateCertificate(matchers []CertificateMatcher) []error {
+	if len(matchers) == 0 {
+		return nil
+	}
+
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return []error{fmt.Errorf("expected server certificate, got none")}
+	}
+
+	var errs []error
+	for _, matcher := range matchers {
+		if err := matcher(r.TLS.PeerCertificates[0]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,64 @@
+package mt
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+)
+
+// WithCheckpoint sets a checkpoint file recording the fingerprints of tests that
+// have passed, and returns the TestRunner.
+//
+// Each test that passes is recorded to the checkpoint file as soon as it
+// completes. When a test run is interrupted and re-run with the same
+// checkpoint file, tests that already passed are skipped, allowing long test
+// runs to resume where they left off. Once a test run completes without any
+// failures, the checkpoint file is removed.
+//
+// The checkpoint file can also be set using the MELATONIN_CHECKPOINT
+// environment variable. A missing file is treated as an empty checkpoint.
+func (r *TestRunner) WithCheckpoint(path string) *TestRunner {
+	ids, err := readIDFile(path)
+	if err != nil {
+		log.Fatalf("failed to read checkpoint file %q: %v", path, err)
+	}
+
+	r.CheckpointFile = path
+	r.checkpoint = map[string]bool{}
+	for _, id := range ids {
+		r.checkpoint[id] = true
+	}
+
+	return r
+}
+
+// isCheckpointed returns true if a test passed during a previous, interrupted
+// test run.
+func (r *TestRunner) isCheckpointed(fingerprint string) bool {
+	return r.checkpoint[fingerprint]
+}
+
+// recordCheckpoint appends the fingerprint of a passed test to the checkpoint
+// file.
+func (r *TestRunner) recordCheckpoint(result TestRunResult) error {
+	f, err := os.OpenFile(r.CheckpointFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "%s # %s\n", result.Fingerprint, result.TestCase.Description())
+	return err
+}
+
+// clearCheckpoint removes the checkpoint file after a test run that completed
+// without failures.
+func (r *TestRunner) clearCheckpoint() error {
+	if err := os.Remove(r.CheckpointFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	r.checkpoint = map[string]bool{}
+	return nil
+}
@@ -0,0 +1,32 @@
+package mt_test
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jefflinse/melatonin/mt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpointResumesPassedTests(t *testing.T) {
+	ctx := mt.NewHandlerContext(statusHandler(http.StatusOK))
+	tests := []mt.TestCase{
+		ctx.GET("/1").ExpectStatus(http.StatusOK),
+		ctx.GET("/2").ExpectStatus(http.StatusOK),
+		ctx.GET("/3").ExpectStatus(http.StatusTeapot),
+		ctx.GET("/4").ExpectStatus(http.StatusOK),
+	}
+
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	checkpoint := fmt.Sprintf("%s\n%s\n", mt.Fingerprint(tests[0]), mt.Fingerprint(tests[1]))
+	assert.NoError(t, os.WriteFile(path, []byte(checkpoint), 0644))
+
+	result := mt.NewTestRunner().WithCheckpoint(path).RunTests(tests...)
+	assert.Equal(t, 2, result.Resumed)
+	assert.Equal(t, 0, result.Passed)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, 1, result.Skipped)
+}
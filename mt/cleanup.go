@@ -0,0 +1,99 @@
+package mt
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// AfterAll adds a hook called once the test run completes, before any
+// cleanups. Like cleanups, AfterAll hooks are called even if the test run is
+// aborted, and any error they return is a failure of the test run.
+func (r *TestRunner) AfterAll(hook func() error) *TestRunner {
+	r.AfterAllHooks = append(r.AfterAllHooks, hook)
+	return r
+}
+
+// Cleanup registers a function called once the test run completes, such as to
+// tear down a shared environment. Cleanups are called in the reverse order they
+// were registered, even if the test run is aborted by a canceled context, a
+// panic, or a call to t.FailNow(), and any error they return is a failure of
+// the test run.
+func (r *TestRunner) Cleanup(cleanup func() error) *TestRunner {
+	r.Cleanups = append(r.Cleanups, cleanup)
+	return r
+}
+
+// WithContext sets a context that aborts the test run when it is done. Tests
+// that have not started when the context is done are skipped, but the AfterFunc
+// of every group that has started, the AfterAll hooks, and the cleanups are
+// still called.
+func (r *TestRunner) WithContext(ctx context.Context) *TestRunner {
+	r.Context = ctx
+	return r
+}
+
+// aborted returns whether the test run has been aborted by its context.
+func (r *TestRunner) aborted() bool {
+	return r.Context != nil && r.Context.Err() != nil
+}
+
+// run runs a test group from start to finish. If the test run is aborted before
+// it finishes, the AfterAll hooks and cleanups are still called, and their
+// failures are reported to t or, without a testing context, to stderr.
+func (r *TestRunner) run(t *testing.T, group *TestGroup) *GroupRunResult {
+	var result *GroupRunResult
+	defer closeIdleConnections(group)
+	defer func() {
+		if result != nil {
+			return
+		}
+
+		for _, err := range r.cleanUp() {
+			if t != nil {
+				t.Error(err)
+			} else {
+				fmt.Fprintf(os.Stderr, "✘ %s\n", err)
+			}
+		}
+	}()
+
+	r.start(group)
+	result = r.runCheckedGroup(t, group)
+	if r.aborted() {
+		result.RunFailures = append(result.RunFailures, fmt.Errorf("test run aborted: %w", r.Context.Err()))
+	}
+
+	result.RunFailures = append(result.RunFailures, r.cleanUp()...)
+	r.finish(t, result)
+	return result
+}
+
+// closeIdleConnections closes the idle connections held by the tests of a
+// group once the test run finishes.
+func closeIdleConnections(group *TestGroup) {
+	for _, test := range groupTests(group) {
+		if closer, ok := test.(idleConnectionCloser); ok {
+			closer.closeIdleConnections()
+		}
+	}
+}
+
+// cleanUp calls the AfterAll hooks and cleanups, returning their failures.
+func (r *TestRunner) cleanUp() []error {
+	var errs []error
+	for _, hook := range r.AfterAllHooks {
+		if err := callSafely(hook); err != nil {
+			errs = append(errs, fmt.Errorf("after-all hook: %w", err))
+		}
+	}
+
+	for i := len(r.Cleanups) - 1; i >= 0; i-- {
+		if err := callSafely(r.Cleanups[i]); err != nil {
+			errs = append(errs, fmt.Errorf("cleanup: %w", err))
+		}
+	}
+
+	return errs
+}
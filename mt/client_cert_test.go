@@ -0,0 +1,88 @@
+package mt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jefflinse/melatonin/mt"
+	"github.com/stretchr/testify/assert"
+)
+
+func clientCertificate(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// clientNameHandler responds with the common name of the client certificate,
+// or a 401 if there is none.
+func clientNameHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	})
+}
+
+func TestWithClientCert(t *testing.T) {
+	cert := clientCertificate(t)
+
+	t.Run("handler", func(t *testing.T) {
+		ctx := mt.NewHandlerContext(clientNameHandler())
+		result := mt.NewTestRunner().RunTests(
+			ctx.GET("/").WithClientCert(cert).ExpectStatus(http.StatusOK).ExpectBody("client"),
+			ctx.GET("/").ExpectStatus(http.StatusUnauthorized),
+		)
+		assert.Equal(t, 2, result.Passed)
+	})
+
+	t.Run("url", func(t *testing.T) {
+		var connections int32
+		server := httptest.NewUnstartedServer(clientNameHandler())
+		server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+		server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				atomic.AddInt32(&connections, 1)
+			}
+		}
+		server.StartTLS()
+		defer server.Close()
+
+		ctx := mt.NewURLContext(server.URL).WithHTTPClient(server.Client())
+		tc := ctx.GET("/").WithClientCert(cert).ExpectStatus(http.StatusOK).ExpectBody("client")
+		for i := 0; i < 3; i++ {
+			assert.Empty(t, tc.Execute().Failures())
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&connections))
+	})
+}
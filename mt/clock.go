@@ -0,0 +1,101 @@
+package mt
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// A Clock tells the current time.
+//
+// Handlers tested using a handler context with a clock can obtain it from the
+// request context using ClockFromContext(), making time-dependent logic such as
+// token expiry or rate limiting windows deterministic under test.
+type Clock interface {
+	Now() time.Time
+}
+
+type clockContextKey struct{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// ClockFromContext returns the clock stored in a request context by a handler
+// context, or a clock telling the real time if there is none.
+func ClockFromContext(ctx context.Context) Clock {
+	if clock, ok := ctx.Value(clockContextKey{}).(Clock); ok {
+		return clock
+	}
+
+	return realClock{}
+}
+
+// A FakeClock is a Clock whose time only changes when it is explicitly set or
+// advanced. It is safe for concurrent use.
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock creates a new FakeClock set to the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Advance moves the clock forward by the given duration.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Now returns the clock's current time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set sets the clock's current time.
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// WithClock sets the clock made available to handlers through the request
+// context of test cases created by a handler context, and returns the context.
+func (c *HTTPTestContext) WithClock(clock Clock) *HTTPTestContext {
+	c.Clock = clock
+	return c
+}
+
+// AdvanceClock advances the context's FakeClock by the given duration
+// immediately before the test case's request is handled.
+func (tc *HTTPTestCase) AdvanceClock(d time.Duration) *HTTPTestCase {
+	tc.clockAdvance = d
+	return tc
+}
+
+// applyClock advances the context's clock as requested by the test case and
+// stores it in the request context.
+func (tc *HTTPTestCase) applyClock() error {
+	if tc.clockAdvance != 0 {
+		fake, ok := tc.tctx.Clock.(*FakeClock)
+		if !ok {
+			return fmt.Errorf("cannot advance clock of type %T, use a *FakeClock", tc.tctx.Clock)
+		}
+
+		fake.Advance(tc.clockAdvance)
+	}
+
+	if tc.tctx.Clock != nil {
+		tc.request = tc.request.WithContext(context.WithValue(tc.request.Context(), clockContextKey{}, tc.tctx.Clock))
+	}
+
+	return nil
+}
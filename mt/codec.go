@@ -0,0 +1,196 @@
+package mt
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"sync"
+
+	"github.com/jefflinse/melatonin/cbor"
+	"github.com/jefflinse/melatonin/msgpack"
+)
+
+const (
+	msgpackContentType = "application/msgpack"
+	cborContentType    = "application/cbor"
+)
+
+// A Codec encodes request bodies and decodes response bodies of a media type.
+// See RegisterCodec().
+type Codec struct {
+	// Marshal encodes a request body.
+	Marshal func(v any) ([]byte, error)
+
+	// Unmarshal decodes a response body into v, which is a pointer to an
+	// empty interface value.
+	Unmarshal func(data []byte, v any) error
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{}
+)
+
+func init() {
+	RegisterCodec(msgpackContentType, msgpack.Marshal, msgpack.Unmarshal)
+	RegisterCodec("application/x-msgpack", msgpack.Marshal, msgpack.Unmarshal)
+	RegisterCodec("application/vnd.msgpack", msgpack.Marshal, msgpack.Unmarshal)
+	RegisterCodec(cborContentType, cbor.Marshal, cbor.Unmarshal)
+}
+
+// RegisterCodec registers the functions used to encode request bodies and
+// decode response bodies of a media type, such as "application/msgpack", for
+// all test cases.
+//
+// A request body that is not already a string or bytes is encoded by the codec
+// of the request's Content-Type header, and a response body is decoded by the
+// codec of the response's Content-Type header before it is matched, for
+// example:
+//
+//	mt.RegisterCodec("application/msgpack", msgpack.Marshal, msgpack.Unmarshal)
+//
+// Bodies of media types without a registered codec are encoded and decoded as
+// JSON. Registering a media type again replaces its codec. Codecs for
+// MessagePack and CBOR are registered by default.
+func RegisterCodec(contentType string, marshal func(any) ([]byte, error), unmarshal func([]byte, any) error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		log.Fatalf("invalid codec content type %q: %v", contentType, err)
+	}
+
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[mediaType] = Codec{Marshal: marshal, Unmarshal: unmarshal}
+}
+
+// codecFor returns the codec registered for the media type of a Content-Type
+// header value, if any.
+func codecFor(contentType string) (Codec, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return Codec{}, false
+	}
+
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, ok := codecs[mediaType]
+	return codec, ok
+}
+
+// encodeBody encodes a request body using the codec of a Content-Type header
+// value, or as JSON if it has none.
+func encodeBody(contentType string, body any) ([]byte, error) {
+	codec, ok := codecFor(contentType)
+	if !ok || codec.Marshal == nil {
+		return toBytes(body)
+	}
+
+	switch body.(type) {
+	case nil, []byte, string, func() []byte, func() ([]byte, error):
+		return toBytes(body)
+	}
+
+	b, err := codec.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("request body: %w", err)
+	}
+
+	return b, nil
+}
+
+// decodeBody decodes a response body using the codec of a Content-Type header
+// value, or as JSON if it has none. Bodies that the codec cannot decode are
+// returned as strings.
+func decodeBody(contentType string, body []byte) any {
+	codec, ok := codecFor(contentType)
+	if !ok || codec.Unmarshal == nil || len(body) == 0 {
+		return toInterface(body)
+	}
+
+	var decoded any
+	if err := codec.Unmarshal(body, &decoded); err != nil {
+		return string(body)
+	}
+
+	return normalizeDecoded(decoded)
+}
+
+// normalizeDecoded converts the values decoded by a codec into the types of
+// decoded JSON values, such as maps with non-string keys, which are common in
+// binary formats, into JSON objects.
+func normalizeDecoded(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, element := range v {
+			v[key] = normalizeDecoded(element)
+		}
+		return v
+
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, element := range v {
+			m[fmt.Sprint(key)] = normalizeDecoded(element)
+		}
+		return m
+
+	case []any:
+		for i, element := range v {
+			v[i] = normalizeDecoded(element)
+		}
+		return v
+
+	case uint:
+		return int64(v)
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case uint64:
+		return int64(v)
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case float32:
+		return float64(v)
+	}
+
+	return value
+}
+
+// body returns the decoded response body.
+func (r *HTTPTestCaseResult) body() any {
+	return decodeBody(r.Headers.Get("Content-Type"), r.Body)
+}
+
+// WithMsgpackBody sets the request body of the test case, encoded as
+// MessagePack, and its Content-Type header.
+func (tc *HTTPTestCase) WithMsgpackBody(body any) *HTTPTestCase {
+	return tc.WithHeader("Content-Type", msgpackContentType).WithBody(body)
+}
+
+// WithCBORBody sets the request body of the test case, encoded as CBOR, and its
+// Content-Type header.
+func (tc *HTTPTestCase) WithCBORBody(body any) *HTTPTestCase {
+	return tc.WithHeader("Content-Type", cborContentType).WithBody(body)
+}
+
+// ExpectMsgpackBody sets the expected HTTP response body for the test case,
+// decoding the response body as MessagePack regardless of its Content-Type.
+func (tc *HTTPTestCase) ExpectMsgpackBody(body any) *HTTPTestCase {
+	tc.Expectations.BodyCodec = msgpackContentType
+	return tc.ExpectBody(body)
+}
+
+// ExpectCBORBody sets the expected HTTP response body for the test case,
+// decoding the response body as CBOR regardless of its Content-Type.
+func (tc *HTTPTestCase) ExpectCBORBody(body any) *HTTPTestCase {
+	tc.Expectations.BodyCodec = cborContentType
+	return tc.ExpectBody(body)
+}
@@ -0,0 +1,126 @@
+package mt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A conditional is a set of expectations that only apply to a response when a
+// condition holds.
+type conditional struct {
+	condition    func(*HTTPTestCaseResult) bool
+	expectations []ExpectFunc
+}
+
+// ExpectIf adds expectations that only apply when a condition holds for the
+// response, for endpoints with legitimately different behaviors, for example:
+//
+//	tc.ExpectIf(mt.StatusIs(200), func(tc *mt.HTTPTestCase) {
+//		tc.ExpectBody(json.Object{"state": "done"})
+//	}).ExpectIf(mt.StatusIs(202), func(tc *mt.HTTPTestCase) {
+//		tc.ExpectInvariant(expect.FieldsEqual("location", "job.url"))
+//	})
+//
+// Conditional expectations are evaluated in addition to any unconditional
+// expectations of the test case. Golden file expectations are not supported.
+func (tc *HTTPTestCase) ExpectIf(condition func(*HTTPTestCaseResult) bool, expectations ...ExpectFunc) *HTTPTestCase {
+	tc.conditionals = append(tc.conditionals, conditional{condition, expectations})
+	return tc
+}
+
+// ExpectWarn adds expectations whose mismatches are reported as warnings
+// without failing the test case, for example to warn while a deprecated field
+// is still being returned:
+//
+//	tc.ExpectWarn(func(tc *mt.HTTPTestCase) {
+//		tc.ExpectBody(json.Object{"legacy_id": expect.Missing()})
+//	})
+//
+// Golden file expectations are not supported.
+func (tc *HTTPTestCase) ExpectWarn(expectations ...ExpectFunc) *HTTPTestCase {
+	tc.warnExpectations = append(tc.warnExpectations, expectations...)
+	return tc
+}
+
+// StatusIs creates a condition for ExpectIf() that holds when the response has
+// one of a set of status codes.
+func StatusIs(statuses ...int) func(*HTTPTestCaseResult) bool {
+	return func(r *HTTPTestCaseResult) bool {
+		for _, status := range statuses {
+			if r.Status == status {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// collectExpectations collects the expectations configured by a set of
+// ExpectFuncs on top of a base set of expectations, without applying them to
+// the test case. The functions are run against a copy of the test case with its
+// own request, so that they can use any of the test case's methods. The first
+// definition error recorded by the functions, if any, is returned.
+func (tc *HTTPTestCase) collectExpectations(base expectatons, fns ...ExpectFunc) (expectatons, error) {
+	scratch := *tc
+	scratch.Expectations = base.clone()
+	scratch.definitionErrors = nil
+	if tc.request != nil {
+		scratch.request = tc.request.Clone(tc.request.Context())
+	}
+
+	for _, fn := range fns {
+		fn(&scratch)
+	}
+
+	if len(scratch.definitionErrors) > 0 {
+		return expectatons{}, scratch.definitionErrors[0]
+	}
+
+	return scratch.Expectations, nil
+}
+
+// ExpectAnyOf adds a set of acceptable outcomes, each configuring a complete
+// set of expectations, and passes if the response fully matches any one of
+// them, for example:
+//
+//	tc.ExpectAnyOf(
+//		func(tc *mt.HTTPTestCase) { tc.ExpectStatus(404) },
+//		func(tc *mt.HTTPTestCase) { tc.ExpectStatus(200).ExpectBody(want) },
+//	)
+//
+// This is useful for eventually consistent endpoints that may respond
+// differently while converging. Outcomes are evaluated in addition to any other
+// expectations of the test case. Golden file expectations are not supported.
+func (tc *HTTPTestCase) ExpectAnyOf(outcomes ...ExpectFunc) *HTTPTestCase {
+	tc.outcomes = append(tc.outcomes, outcomes)
+	return tc
+}
+
+// validateOutcomes compares the result against a set of acceptable outcomes
+// and returns an error describing each outcome's failures if none match.
+func (r *HTTPTestCaseResult) validateOutcomes(outcomes []ExpectFunc) error {
+	if len(outcomes) == 0 {
+		return nil
+	}
+
+	messages := []string{}
+	for i, outcome := range outcomes {
+		expectations, err := r.testCase.collectExpectations(expectatons{}, outcome)
+		if err != nil {
+			return err
+		}
+
+		errs, warnings := r.validate(expectations)
+		if len(errs) == 0 {
+			r.addWarnings(warnings...)
+			return nil
+		}
+
+		for _, err := range errs {
+			messages = append(messages, fmt.Sprintf("  outcome %d: %s", i+1, err))
+		}
+	}
+
+	return fmt.Errorf("expected any of %d outcomes, matched none:\n%s", len(outcomes), strings.Join(messages, "\n"))
+}
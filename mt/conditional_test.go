@@ -0,0 +1,66 @@
+package mt_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jefflinse/melatonin/mt"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectCreated is a helper that uses the test case it configures, as shared
+// helpers commonly do.
+func expectCreated(tc *mt.HTTPTestCase) {
+	tc.WithHeader("X-Helper", tc.Target()).ExpectStatus(http.StatusCreated)
+}
+
+func statusHandler(status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	})
+}
+
+func TestExpectIf(t *testing.T) {
+	ctx := mt.NewHandlerContext(statusHandler(http.StatusAccepted))
+
+	tc := ctx.POST("/jobs").ExpectIf(mt.StatusIs(http.StatusAccepted), expectCreated)
+	failures := tc.Execute().Failures()
+	if assert.Len(t, failures, 1) {
+		assert.EqualError(t, failures[0], "expected status 201, got 202")
+	}
+
+	tc = ctx.POST("/jobs").ExpectIf(mt.StatusIs(http.StatusOK), expectCreated)
+	assert.Empty(t, tc.Execute().Failures())
+}
+
+func TestExpectAnyOf(t *testing.T) {
+	ctx := mt.NewHandlerContext(statusHandler(http.StatusCreated))
+
+	tc := ctx.POST("/users").ExpectAnyOf(
+		func(tc *mt.HTTPTestCase) { tc.ExpectStatus(http.StatusConflict) },
+		expectCreated,
+	)
+	assert.Empty(t, tc.Execute().Failures())
+
+	tc = ctx.POST("/users").ExpectAnyOf(
+		func(tc *mt.HTTPTestCase) { tc.ExpectStatus(http.StatusConflict) },
+		func(tc *mt.HTTPTestCase) { tc.WithHeader("X-Helper", "1").ExpectStatus(http.StatusOK) },
+	)
+	failures := tc.Execute().Failures()
+	if assert.Len(t, failures, 1) {
+		assert.EqualError(t, failures[0], "expected any of 2 outcomes, matched none:\n"+
+			"  outcome 1: expected status 409, got 201\n"+
+			"  outcome 2: expected status 200, got 201")
+	}
+}
+
+func TestExpectWarn(t *testing.T) {
+	ctx := mt.NewHandlerContext(statusHandler(http.StatusOK))
+
+	result := ctx.GET("/legacy").ExpectStatus(http.StatusOK).ExpectWarn(expectCreated).Execute()
+	assert.Empty(t, result.Failures())
+	warnings := result.(*mt.HTTPTestCaseResult).Warnings()
+	if assert.Len(t, warnings, 1) {
+		assert.EqualError(t, warnings[0], "expected status 201, got 200")
+	}
+}
@@ -3,23 +3,44 @@ package mt
 import (
 	"io"
 	"os"
+	"strconv"
 )
 
 const (
 	outputTypeNone = iota
 	outputTypeFormattedTable
 	outputTypeJSON
+	outputTypeTeamCity
+	outputTypeGitHub
 )
 
 var cfg = struct {
+	BodyDiff          bool
+	CheckpointFile    string
 	ContinueOnFailure bool
+	GoldenDiffContext int
 	OutputType        int
+	RecordRegression  bool
+	StableJSON        bool
 	Stdout            io.Writer
+	SuiteName         string
+	UpdateBaseline    bool
+	UpdateGolden      bool
+	Verbose           bool
 	WorkingDir        string
 }{
+	BodyDiff:          false,
+	CheckpointFile:    "",
 	ContinueOnFailure: false,
+	GoldenDiffContext: 3,
 	OutputType:        outputTypeFormattedTable,
+	RecordRegression:  false,
+	StableJSON:        false,
 	Stdout:            os.Stdout,
+	SuiteName:         "",
+	UpdateBaseline:    false,
+	UpdateGolden:      false,
+	Verbose:           false,
 	WorkingDir:        "",
 }
 
@@ -28,6 +49,40 @@ func init() {
 		cfg.ContinueOnFailure = true
 	}
 
+	if checkpoint := os.Getenv("MELATONIN_CHECKPOINT"); checkpoint != "" {
+		cfg.CheckpointFile = checkpoint
+	}
+
+	if os.Getenv("MELATONIN_UPDATE_BASELINE") != "" {
+		cfg.UpdateBaseline = true
+	}
+
+	if os.Getenv("MELATONIN_BODY_DIFF") != "" {
+		cfg.BodyDiff = true
+	}
+
+	if os.Getenv("MELATONIN_UPDATE_GOLDEN") != "" {
+		cfg.UpdateGolden = true
+	}
+
+	if os.Getenv("MELATONIN_VERBOSE") != "" {
+		cfg.Verbose = true
+	}
+
+	if lines, err := strconv.Atoi(os.Getenv("MELATONIN_GOLDEN_DIFF_CONTEXT")); err == nil && lines >= 0 {
+		cfg.GoldenDiffContext = lines
+	}
+
+	if os.Getenv("MELATONIN_RECORD_REGRESSION") != "" {
+		cfg.RecordRegression = true
+	}
+
+	if os.Getenv("MELATONIN_JSON_STABLE") != "" {
+		cfg.StableJSON = true
+	}
+
+	cfg.SuiteName = os.Getenv("MELATONIN_SUITE_NAME")
+
 	cfg.Stdout = os.Stdout
 	switch os.Getenv("MELATONIN_OUTPUT") {
 	case "none":
@@ -35,6 +90,10 @@ func init() {
 		cfg.Stdout = io.Discard
 	case "json":
 		cfg.OutputType = outputTypeJSON
+	case "teamcity":
+		cfg.OutputType = outputTypeTeamCity
+	case "github":
+		cfg.OutputType = outputTypeGitHub
 	default:
 		cfg.OutputType = outputTypeFormattedTable
 	}
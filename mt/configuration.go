@@ -0,0 +1,159 @@
+package mt
+
+import (
+	"fmt"
+
+	"github.com/jefflinse/melatonin/expect"
+	"github.com/jefflinse/melatonin/golden"
+)
+
+// Sources of the configuration settings of a test case.
+const (
+	SourceDefault  = "default"
+	SourceContext  = "context"
+	SourceRunner   = "runner"
+	SourceTestCase = "test case"
+)
+
+// A ConfigurationSetting is a default, interceptor, or context option that
+// affected the execution of a test case, and where it was set. The settings of
+// each test case are printed when the MELATONIN_VERBOSE environment variable is
+// set. See HTTPTestCaseResult.Configuration.
+type ConfigurationSetting struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// String returns a string representation of the setting.
+func (s ConfigurationSetting) String() string {
+	return fmt.Sprintf("%s: %s (%s)", s.Name, s.Value, s.Source)
+}
+
+// effectiveConfiguration returns the settings that affect the execution of the
+// test case, such as its timeout, the User-Agent it sends, and the options of
+// its context, so that a case's behavior can be explained without reading all
+// of the setup code that produced it.
+func (tc *HTTPTestCase) effectiveConfiguration() []ConfigurationSetting {
+	var settings []ConfigurationSetting
+	add := func(name, source string, format string, args ...any) {
+		settings = append(settings, ConfigurationSetting{Name: name, Value: fmt.Sprintf(format, args...), Source: source})
+	}
+
+	c := tc.tctx
+	if c.Handler != nil {
+		add("target", SourceContext, "handler %T", c.Handler)
+	} else {
+		add("target", SourceContext, "%s", c.BaseURL)
+		if c.Client != nil {
+			add("client", SourceContext, "custom (timeout %s)", c.Client.Timeout)
+		} else {
+			add("client", SourceDefault, "http.DefaultClient")
+		}
+	}
+
+	timeoutSource := SourceTestCase
+	if tc.timeout == defaultRequestTimeout {
+		timeoutSource = SourceDefault
+	}
+	add("timeout", timeoutSource, "%s", tc.timeout)
+
+	switch {
+	case tc.userAgent != "":
+		add("user agent", SourceTestCase, "%s", tc.userAgent)
+	case c.UserAgent != "":
+		add("user agent", SourceContext, "%s", c.UserAgent)
+	default:
+		add("user agent", SourceDefault, "%s", defaultUserAgent())
+	}
+
+	if c.Credentials != nil {
+		add("credentials", SourceContext, "set")
+	}
+
+	if tc.cacheBusters != nil {
+		add("cache busters", SourceTestCase, "%d", len(tc.cacheBusters))
+	} else if len(c.CacheBusters) > 0 {
+		add("cache busters", SourceContext, "%d", len(c.CacheBusters))
+	}
+
+	if c.Clock != nil {
+		add("clock", SourceContext, "%T", c.Clock)
+	}
+
+	if tc.failFastOverride != nil {
+		add("fail fast", SourceTestCase, "%t", *tc.failFastOverride)
+	} else if c.FailFast {
+		add("fail fast", SourceContext, "true")
+	}
+
+	switch {
+	case tc.bodyDiffOverride != nil:
+		add("body diff", SourceTestCase, "%t", *tc.bodyDiffOverride)
+	case c.BodyDiff:
+		add("body diff", SourceContext, "true")
+	case cfg.BodyDiff:
+		add("body diff", SourceDefault, "true")
+	}
+
+	if c.FlagProvider != nil {
+		add("flag provider", SourceContext, "set")
+	}
+
+	if c.Memoize {
+		add("memoization", SourceContext, "true")
+	}
+
+	if c.Shadow != nil {
+		add("shadow", SourceContext, "%s", c.Shadow.BaseURL)
+	}
+
+	if c.Strictness != nil {
+		add("strictness", SourceContext, "%s", strictnessString(c.Strictness))
+	} else if tc.defaultStrictness != nil {
+		add("strictness", SourceRunner, "%s", strictnessString(tc.defaultStrictness))
+	}
+
+	if tc.GoldenFilePath != "" {
+		if c.GoldenNormalization != nil {
+			add("golden normalization", SourceContext, "%s", normalizationString(*c.GoldenNormalization))
+		} else {
+			add("golden normalization", SourceDefault, "%s", normalizationString(golden.DefaultNormalization))
+		}
+	}
+
+	if tc.retryAfter != nil {
+		add("retry after budget", SourceRunner, "%s", tc.retryAfter.total)
+	}
+
+	if tc.responseFile != "" {
+		add("response file", SourceTestCase, "%s", tc.responseFile)
+	}
+
+	return settings
+}
+
+func strictnessString(s *Strictness) string {
+	policy := "allow"
+	switch s.UnknownFields {
+	case expect.WarnUnknownFields:
+		policy = "warn"
+	case expect.FailUnknownFields:
+		policy = "fail"
+	}
+
+	return fmt.Sprintf("%s unknown fields, null is not missing: %t", policy, s.NullIsNotMissing)
+}
+
+func normalizationString(n golden.Normalization) string {
+	switch n {
+	case golden.NoNormalization:
+		return "none"
+	case golden.NormalizeBOM:
+		return "BOM"
+	case golden.NormalizeLineEndings:
+		return "line endings"
+	default:
+		return "BOM, line endings"
+	}
+}
@@ -0,0 +1,86 @@
+package mt_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"testing/iotest"
+
+	"github.com/jefflinse/melatonin/mt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvalidDefinitions(t *testing.T) {
+	ctx := mt.NewHandlerContext(statusHandler(http.StatusOK))
+
+	for _, test := range []struct {
+		name    string
+		tc      *mt.HTTPTestCase
+		wantErr string
+	}{
+		{"body pattern", ctx.GET("/").ExpectBodyMatches("a("),
+			"invalid body pattern \"a(\": error parsing regexp: missing closing ): `a(`"},
+		{"body pattern type", ctx.GET("/").ExpectBodyMatches(42),
+			"invalid body pattern of type int, expected a string or *regexp.Regexp"},
+		{"SHA-256 checksum", ctx.GET("/").ExpectBodySHA256("abc"),
+			`invalid SHA-256 checksum "abc"`},
+		{"schema", ctx.GET("/").ExpectSchema(iotest.ErrReader(errors.New("closed"))),
+			"failed to read schema: closed"},
+		{"jq program", ctx.GET("/").ExpectBodyJQ(".items |", 1),
+			`invalid jq program ".items |": jq: unexpected end of program`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			test.tc.ExpectStatus(http.StatusOK)
+
+			failures := test.tc.Execute().Failures()
+			if assert.Len(t, failures, 1) {
+				assert.EqualError(t, failures[0], test.wantErr)
+			}
+
+			diagnostics := mt.Lint(test.tc)
+			if assert.Len(t, diagnostics, 1) {
+				assert.Equal(t, "invalid-definition", diagnostics[0].Rule)
+				assert.Equal(t, test.wantErr, diagnostics[0].Message)
+			}
+		})
+	}
+}
+
+func TestNestedInvalidDefinitions(t *testing.T) {
+	ctx := mt.NewHandlerContext(statusHandler(http.StatusOK)).
+		WithFlagProvider(func(flag string) (bool, error) { return true, nil })
+
+	for _, test := range []struct {
+		name    string
+		invalid mt.ExpectFunc
+		wantErr string
+	}{
+		{"body pattern", func(tc *mt.HTTPTestCase) { tc.ExpectBodyMatches("a(") },
+			"invalid body pattern \"a(\": error parsing regexp: missing closing ): `a(`"},
+		{"jq program", func(tc *mt.HTTPTestCase) { tc.ExpectBodyJQ(".items |", 1) },
+			`invalid jq program ".items |": jq: unexpected end of program`},
+		{"SHA-256 checksum", func(tc *mt.HTTPTestCase) { tc.ExpectBodySHA256("abc") },
+			`invalid SHA-256 checksum "abc"`},
+		{"schema", func(tc *mt.HTTPTestCase) { tc.ExpectSchema(iotest.ErrReader(errors.New("closed"))) },
+			"failed to read schema: closed"},
+	} {
+		for _, nested := range []struct {
+			name string
+			tc   *mt.HTTPTestCase
+		}{
+			{"conditional", ctx.GET("/").ExpectIf(mt.StatusIs(http.StatusOK), test.invalid)},
+			{"warning", ctx.GET("/").ExpectWarn(test.invalid)},
+			{"outcome", ctx.GET("/").ExpectAnyOf(test.invalid)},
+			{"flag variant", ctx.GET("/").WhenFlag("beta", test.invalid, nil)},
+		} {
+			t.Run(test.name+" in "+nested.name, func(t *testing.T) {
+				nested.tc.ExpectStatus(http.StatusOK)
+
+				failures := nested.tc.Execute().Failures()
+				if assert.Len(t, failures, 1) {
+					assert.EqualError(t, failures[0], test.wantErr)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package mt
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	mtjson "github.com/jefflinse/melatonin/json"
+)
+
+// A DeprecationNotice records a test's use of a deprecated API.
+type DeprecationNotice struct {
+	// Endpoint identifies the request that used the deprecated API, such as
+	// "GET /v1/users".
+	Endpoint string `json:"endpoint"`
+
+	// Reason describes what is deprecated, such as a Deprecation header or a
+	// deprecated response field.
+	Reason string `json:"reason"`
+
+	// Tests are the descriptions of the tests that used the deprecated API.
+	Tests []string `json:"tests"`
+}
+
+// WithDeprecationCheck enables deprecation sniffing and returns the TestRunner.
+//
+// Responses carrying a Deprecation or Sunset header, or containing a value at
+// any of the given JSON paths of deprecated fields, are reported as warnings on
+// the test that received them and aggregated in the summary of the test run.
+func (r *TestRunner) WithDeprecationCheck(deprecatedFields ...string) *TestRunner {
+	r.CheckDeprecations = true
+	r.DeprecatedFields = append(r.DeprecatedFields, deprecatedFields...)
+	return r
+}
+
+// WithDeprecationManifest enables deprecation sniffing using a manifest file
+// listing the JSON paths of deprecated response fields, one per line, and
+// returns the TestRunner. See WithDeprecationCheck().
+//
+// Blank lines and comments beginning with '#' are ignored.
+func (r *TestRunner) WithDeprecationManifest(path string) *TestRunner {
+	fields, err := readIDFile(path)
+	if err != nil {
+		log.Fatalf("failed to read deprecation manifest %q: %v", path, err)
+	}
+
+	return r.WithDeprecationCheck(fields...)
+}
+
+// sniffDeprecations checks every response received by a test for use of a
+// deprecated API, adding a warning to the response's result for each.
+func (r *TestRunner) sniffDeprecations(runResult *TestRunResult) {
+	for _, result := range httpResults(runResult.TestResult) {
+		reasons := []string{}
+		for _, header := range []string{"Deprecation", "Sunset"} {
+			if value := result.Headers.Get(header); value != "" {
+				reasons = append(reasons, fmt.Sprintf("%s header: %s", header, value))
+			}
+		}
+
+		body := result.body()
+		for _, field := range r.DeprecatedFields {
+			if len(mtjson.Lookup(body, field)) > 0 {
+				reasons = append(reasons, fmt.Sprintf("field %s", field))
+			}
+		}
+
+		endpoint := fmt.Sprintf("%s %s", result.testCase.request.Method, result.testCase.request.URL.Path)
+		for _, reason := range reasons {
+			result.addWarnings(fmt.Errorf("deprecated: %s", reason))
+			runResult.Deprecations = append(runResult.Deprecations, DeprecationNotice{
+				Endpoint: endpoint,
+				Reason:   reason,
+				Tests:    []string{runResult.TestCase.Description()},
+			})
+		}
+	}
+}
+
+// collectDeprecations aggregates the deprecation notices of all tests in a group
+// run by endpoint and reason.
+func collectDeprecations(result *GroupRunResult) []DeprecationNotice {
+	byKey := map[string]*DeprecationNotice{}
+	forEachTestRunResult(result, func(runResult TestRunResult) {
+		for _, notice := range runResult.Deprecations {
+			key := notice.Endpoint + "\n" + notice.Reason
+			if existing, ok := byKey[key]; ok {
+				existing.Tests = append(existing.Tests, notice.Tests...)
+			} else {
+				notice := notice
+				notice.Tests = append([]string{}, notice.Tests...)
+				byKey[key] = &notice
+			}
+		}
+	})
+
+	notices := make([]DeprecationNotice, 0, len(byKey))
+	for _, notice := range byKey {
+		notices = append(notices, *notice)
+	}
+
+	sort.Slice(notices, func(i, j int) bool {
+		if notices[i].Endpoint != notices[j].Endpoint {
+			return notices[i].Endpoint < notices[j].Endpoint
+		}
+		return notices[i].Reason < notices[j].Reason
+	})
+
+	return notices
+}
+
+// summary returns a one-line description of a deprecation notice.
+func (n DeprecationNotice) summary() string {
+	tests := "1 test"
+	if len(n.Tests) != 1 {
+		tests = fmt.Sprintf("%d tests", len(n.Tests))
+	}
+
+	return fmt.Sprintf("%s: %s (%s)", n.Endpoint, n.Reason, tests)
+}
@@ -0,0 +1,379 @@
+package mt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jefflinse/melatonin/expect"
+)
+
+// A BodyDiffError is a failure of a JSON response body to match the expected
+// body, described by a unified diff of the expected and actual bodies rather
+// than by a failure for each mismatched value. See WithBodyDiff().
+type BodyDiffError struct {
+	// Diff is the lines of the unified diff, each beginning with "@", " ", "-",
+	// or "+".
+	Diff []string
+}
+
+func (e *BodyDiffError) Error() string {
+	return strings.Join(append([]string{e.summary()}, e.Diff...), "\n")
+}
+
+func (e *BodyDiffError) summary() string {
+	return "body does not match:"
+}
+
+func (e *BodyDiffError) diffLines() []string {
+	return e.Diff
+}
+
+func (e *BodyDiffError) hint() string {
+	return ""
+}
+
+// A diffError is a failure described by a unified diff, which is printed line
+// by line.
+type diffError interface {
+	error
+	summary() string
+	diffLines() []string
+	hint() string
+}
+
+// WithBodyDiff sets whether test cases created from the context describe a
+// JSON response body that does not match the expected body with a unified diff
+// of the two, and returns the context.
+//
+// By default, a failure is reported for each mismatched value, which is hard
+// to read for large bodies. In the diff, values matched by predicates are shown
+// as their actual values, and fields of the response that are not part of a
+// subset match are omitted. As for golden file diffs, the number of lines of
+// context is set by MELATONIN_GOLDEN_DIFF_CONTEXT. Setting MELATONIN_BODY_DIFF
+// enables body diffs for all test cases.
+func (c *HTTPTestContext) WithBodyDiff(bodyDiff bool) *HTTPTestContext {
+	c.BodyDiff = bodyDiff
+	return c
+}
+
+// WithBodyDiff sets whether the test case describes a JSON response body that
+// does not match the expected body with a unified diff, overriding its context,
+// and returns the test case. See HTTPTestContext.WithBodyDiff().
+func (tc *HTTPTestCase) WithBodyDiff(bodyDiff bool) *HTTPTestCase {
+	tc.bodyDiffOverride = &bodyDiff
+	return tc
+}
+
+// bodyDiff returns true if the test case describes a mismatched JSON response
+// body with a unified diff.
+func (tc *HTTPTestCase) bodyDiff() bool {
+	if tc.bodyDiffOverride != nil {
+		return *tc.bodyDiffOverride
+	}
+
+	return cfg.BodyDiff || (tc.tctx != nil && tc.tctx.BodyDiff)
+}
+
+// bodyDiff returns a failure describing the differences between an expected
+// JSON body and a response body, or nil if they can't be usefully diffed.
+func bodyDiff(expected, actual any, opts expect.CompareOptions) *BodyDiffError {
+	switch expected.(type) {
+	case map[string]any, []any:
+	default:
+		return nil
+	}
+
+	switch actual.(type) {
+	case map[string]any, []any:
+	default:
+		return nil
+	}
+
+	want, got := diffViews(expected, actual, opts)
+	diff := unifiedDiff(strings.Split(diffJSON(want), "\n"), strings.Split(diffJSON(got), "\n"), cfg.GoldenDiffContext)
+	if len(diff) == 0 {
+		return nil
+	}
+
+	return &BodyDiffError{Diff: append([]string{"--- expected", "+++ response"}, diff...)}
+}
+
+// diffJSON returns the indented JSON of a value shown in a body diff, without
+// escaping the angle brackets that mark values shown by type.
+func diffJSON(v any) string {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Sprintf("%+v", v)
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// diffViews returns the expected and actual values as they are shown in a body
+// diff. Expected values that match, such as predicates, are shown as the actual
+// value, and actual object fields that aren't expected are omitted unless the
+// objects are matched exactly.
+func diffViews(expected, actual any, opts expect.CompareOptions) (any, any) {
+	switch e := expected.(type) {
+	case map[string]any:
+		a, ok := actual.(map[string]any)
+		if !ok {
+			break
+		}
+
+		want, got := map[string]any{}, map[string]any{}
+		for key, value := range e {
+			field := map[string]any{}
+			actualValue, present := a[key]
+			if present {
+				field[key] = actualValue
+			}
+
+			if len(expect.Compare(map[string]any{key: value}, field, opts).Failures) == 0 {
+				if present {
+					want[key], got[key] = actualValue, actualValue
+				}
+				continue
+			}
+
+			switch {
+			case !present:
+				want[key] = diffValue(value)
+			case value == expect.Missing():
+				got[key] = actualValue
+			default:
+				want[key], got[key] = diffViews(value, actualValue, opts)
+			}
+		}
+
+		if opts.ExactJSON {
+			for key, value := range a {
+				if _, expected := e[key]; !expected {
+					got[key] = value
+				}
+			}
+		}
+
+		return want, got
+
+	case []any:
+		a, ok := actual.([]any)
+		if !ok {
+			break
+		}
+
+		want, got := make([]any, len(e)), make([]any, len(a))
+		for i := range want {
+			if i < len(a) {
+				want[i], got[i] = diffViews(e[i], a[i], opts)
+			} else {
+				want[i] = diffValue(e[i])
+			}
+		}
+		for i := len(e); i < len(a); i++ {
+			got[i] = a[i]
+		}
+
+		return want, got
+	}
+
+	if len(expect.Compare(expected, actual, opts).Failures) == 0 {
+		return actual, actual
+	}
+
+	return diffValue(expected), actual
+}
+
+// diffValue returns an expected value as it is shown in a body diff. Values
+// that can't be represented as JSON, such as predicates, are shown by type.
+func diffValue(expected any) any {
+	switch v := expected.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for key, value := range v {
+			m[key] = diffValue(value)
+		}
+		return m
+
+	case []any:
+		s := make([]any, len(v))
+		for i, value := range v {
+			s[i] = diffValue(value)
+		}
+		return s
+
+	case expect.Presence:
+		if v == expect.Null() {
+			return nil
+		}
+		return fmt.Sprintf("<%T>", v)
+	}
+
+	if _, err := json.Marshal(expected); err != nil {
+		return fmt.Sprintf("<%T>", expected)
+	}
+
+	return expected
+}
+
+// A diffLine is a line of a diff: an unchanged line (' '), a line only in the
+// expected text ('-'), or a line only in the actual text ('+').
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// unifiedDiff returns the hunks of a unified diff between two sets of lines,
+// with the given number of unchanged lines of context around each change.
+func unifiedDiff(expected, actual []string, context int) []string {
+	lines := diffLines(expected, actual)
+
+	var out []string
+	for start := 0; start < len(lines); {
+		// find the next change, and extend the hunk until there are more than
+		// twice the context of unchanged lines before the following change
+		first := start
+		for first < len(lines) && lines[first].op == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+
+		last := first
+		for i := first; i < len(lines); i++ {
+			if lines[i].op != ' ' {
+				last = i
+			} else if i-last > 2*context {
+				break
+			}
+		}
+
+		from, to := first-context, last+context+1
+		if from < 0 {
+			from = 0
+		}
+		if to > len(lines) {
+			to = len(lines)
+		}
+
+		// the positions of the hunk within the expected and actual lines
+		expectedStart, actualStart := 0, 0
+		for _, line := range lines[:from] {
+			if line.op != '+' {
+				expectedStart++
+			}
+			if line.op != '-' {
+				actualStart++
+			}
+		}
+
+		expectedCount, actualCount := 0, 0
+		hunk := []string{}
+		for _, line := range lines[from:to] {
+			if line.op != '+' {
+				expectedCount++
+			}
+			if line.op != '-' {
+				actualCount++
+			}
+			hunk = append(hunk, string(line.op)+line.text)
+		}
+
+		out = append(out, fmt.Sprintf("@@ -%s +%s @@", hunkRange(expectedStart, expectedCount), hunkRange(actualStart, actualCount)))
+		out = append(out, hunk...)
+		start = to
+	}
+
+	return out
+}
+
+// hunkRange formats the range of lines of a hunk, whose start is 1-based unless
+// the hunk contains no lines, in which case it is the line preceding the hunk.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// diffLines returns a shortest edit script transforming the expected lines into
+// the actual lines, using the Myers difference algorithm.
+func diffLines(expected, actual []string) []diffLine {
+	n, m := len(expected), len(actual)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+
+	// find the length of the shortest edit script, recording the furthest
+	// reaching path of each diagonal before each step
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		done := false
+		for k := -d; k <= d; k += 2 {
+			x := v[offset+k-1] + 1
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			}
+
+			y := x - k
+			for x < n && y < m && expected[x] == actual[y] {
+				x++
+				y++
+			}
+
+			v[offset+k] = x
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+
+		if done {
+			break
+		}
+	}
+
+	// walk back through the recorded paths to recover the edits
+	var lines []diffLine
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		prevK := k - 1
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		}
+
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			lines = append(lines, diffLine{' ', expected[x-1]})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				lines = append(lines, diffLine{'+', actual[y-1]})
+				y--
+			} else {
+				lines = append(lines, diffLine{'-', expected[x-1]})
+				x--
+			}
+		}
+	}
+
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+
+	return lines
+}
@@ -0,0 +1,163 @@
+package mt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// A ResponseFile is a file that an HTTP response body was streamed to. See
+// WithResponseToFile().
+type ResponseFile struct {
+	// Path is the path of the file.
+	Path string `json:"path"`
+
+	// Size is the size of the response body in bytes.
+	Size int64 `json:"size"`
+
+	// SHA256 is the hex-encoded SHA-256 checksum of the response body.
+	SHA256 string `json:"sha256"`
+}
+
+// A BodyRange is an expected sequence of bytes at an offset within an HTTP
+// response body. See ExpectBodyRange().
+type BodyRange struct {
+	Offset int64  `json:"offset"`
+	Bytes  []byte `json:"bytes"`
+}
+
+// WithResponseToFile causes the HTTP response body to be streamed to a file
+// rather than buffered in memory, for large downloads, leaving the file as an
+// artifact of the test run. The file and any missing parent directories are
+// created, and an existing file is overwritten.
+//
+// The body of the result is empty, so only expectations of the body's size,
+// checksum, and byte ranges apply to it, which are evaluated against the
+// file. See ExpectBodySize(), ExpectBodySHA256(), and ExpectBodyRange().
+func (tc *HTTPTestCase) WithResponseToFile(path string) *HTTPTestCase {
+	tc.responseFile = path
+	return tc
+}
+
+// ExpectBodyRange adds an expectation for the bytes at an offset within the
+// HTTP response body, such as the magic number of a file format, without
+// comparing the rest of the body.
+func (tc *HTTPTestCase) ExpectBodyRange(offset int64, expected []byte) *HTTPTestCase {
+	tc.Expectations.BodyRanges = append(tc.Expectations.BodyRanges, BodyRange{
+		Offset: offset,
+		Bytes:  expected,
+	})
+	return tc
+}
+
+// ExpectBodySize sets the expected size in bytes of the HTTP response body.
+func (tc *HTTPTestCase) ExpectBodySize(size int64) *HTTPTestCase {
+	tc.Expectations.BodySize = &size
+	return tc
+}
+
+// doRequestToFile executes a request, streaming the response body to a file.
+func doRequestToFile(c *http.Client, req *http.Request, path string) (*http.Response, *ResponseFile, error) {
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// trailers are only populated once the body has been read entirely
+	defer resp.Body.Close()
+	file, err := streamToFile(path, resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp, file, nil
+}
+
+// streamToFile copies a response body to a file, computing its checksum as it
+// is written.
+func streamToFile(path string, body io.Reader) (*ResponseFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create response file %q: %w", path, err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create response file %q: %w", path, err)
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, hash), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write response file %q: %w", path, err)
+	}
+
+	return &ResponseFile{
+		Path:   path,
+		Size:   size,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// bodySize returns the size of the response body, whether it was buffered or
+// streamed to a file.
+func (r *HTTPTestCaseResult) bodySize() int64 {
+	if r.BodyFile != nil {
+		return r.BodyFile.Size
+	}
+
+	return int64(len(r.Body))
+}
+
+// bodySHA256 returns the hex-encoded SHA-256 checksum of the response body,
+// whether it was buffered or streamed to a file.
+func (r *HTTPTestCaseResult) bodySHA256() string {
+	if r.BodyFile != nil {
+		return r.BodyFile.SHA256
+	}
+
+	sum := sha256.Sum256(r.Body)
+	return hex.EncodeToString(sum[:])
+}
+
+// validateBodyRange compares the bytes at an offset within the response body,
+// whether it was buffered or streamed to a file, to the expected bytes.
+func (r *HTTPTestCaseResult) validateBodyRange(expected BodyRange) error {
+	var actual []byte
+	if r.BodyFile != nil {
+		f, err := os.Open(r.BodyFile.Path)
+		if err != nil {
+			return fmt.Errorf("failed to read response file: %w", err)
+		}
+		defer f.Close()
+
+		actual = make([]byte, len(expected.Bytes))
+		n, err := f.ReadAt(actual, expected.Offset)
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read response file: %w", err)
+		}
+		actual = actual[:n]
+	} else if expected.Offset < int64(len(r.Body)) {
+		end := expected.Offset + int64(len(expected.Bytes))
+		if end > int64(len(r.Body)) {
+			end = int64(len(r.Body))
+		}
+		actual = r.Body[expected.Offset:end]
+	}
+
+	if len(actual) < len(expected.Bytes) {
+		return fmt.Errorf("expected %d bytes at offset %d, got %d bytes of a %d-byte body",
+			len(expected.Bytes), expected.Offset, len(actual), r.bodySize())
+	}
+
+	if !bytes.Equal(actual, expected.Bytes) {
+		return fmt.Errorf("expected bytes %x at offset %d, got %x", expected.Bytes, expected.Offset, actual)
+	}
+
+	return nil
+}
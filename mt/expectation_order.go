@@ -0,0 +1,111 @@
+package mt
+
+import "errors"
+
+// An ExpectationCategory is a category of expectations of an HTTP test case.
+// Categories are evaluated in a defined order. See ExpectationOrder().
+type ExpectationCategory int
+
+const (
+	// StatusExpectations are expectations of the response status.
+	StatusExpectations ExpectationCategory = iota
+
+	// HeaderExpectations are expectations of the response headers.
+	HeaderExpectations
+
+	// TransportExpectations are expectations of the connection the response
+	// was received on, such as its protocol or TLS version, and of the
+	// response trailers.
+	TransportExpectations
+
+	// BodyExpectations are expectations of the response body.
+	BodyExpectations
+)
+
+func (c ExpectationCategory) String() string {
+	switch c {
+	case StatusExpectations:
+		return "status"
+	case HeaderExpectations:
+		return "headers"
+	case TransportExpectations:
+		return "transport"
+	case BodyExpectations:
+		return "body"
+	default:
+		return "unknown"
+	}
+}
+
+// An ExpectationError is the failure of an expectation of a particular category.
+type ExpectationError struct {
+	// Category is the category of the expectation that failed.
+	Category ExpectationCategory
+
+	// Err is the underlying failure.
+	Err error
+}
+
+func (e *ExpectationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExpectationError) Unwrap() error {
+	return e.Err
+}
+
+// CategoryOf returns the category of the expectation whose failure is described
+// by err, and false if err is not the failure of an expectation.
+func CategoryOf(err error) (ExpectationCategory, bool) {
+	var expectationErr *ExpectationError
+	if errors.As(err, &expectationErr) {
+		return expectationErr.Category, true
+	}
+
+	return 0, false
+}
+
+// ExpectationOrder returns the order in which the categories of expectations of
+// an HTTP test case are evaluated: status, headers, transport, then body.
+//
+// Failures are always reported in this order. Conditional expectations (see
+// ExpectIf()), warning-only expectations, and sets of acceptable outcomes are
+// evaluated after all categories.
+func ExpectationOrder() []ExpectationCategory {
+	return []ExpectationCategory{
+		StatusExpectations,
+		HeaderExpectations,
+		TransportExpectations,
+		BodyExpectations,
+	}
+}
+
+// WithFailFast sets whether test cases created from the context stop evaluating
+// expectations once a category of expectations fails, and returns the context.
+//
+// By default, every expectation is evaluated, even after an earlier category
+// has failed, so that failure output is as complete as possible. With fail fast
+// enabled, a wrong status, for example, is reported without also reporting the
+// mismatches in the body of what is likely an error response.
+func (c *HTTPTestContext) WithFailFast(failFast bool) *HTTPTestContext {
+	c.FailFast = failFast
+	return c
+}
+
+// WithFailFast sets whether the test case stops evaluating expectations once a
+// category of expectations fails, overriding its context, and returns the test
+// case. See HTTPTestContext.WithFailFast().
+func (tc *HTTPTestCase) WithFailFast(failFast bool) *HTTPTestCase {
+	tc.failFastOverride = &failFast
+	return tc
+}
+
+// failFast returns true if the test case stops evaluating expectations once a
+// category of expectations fails.
+func (tc *HTTPTestCase) failFast() bool {
+	if tc.failFastOverride != nil {
+		return *tc.failFastOverride
+	}
+
+	return tc.tctx.FailFast
+}
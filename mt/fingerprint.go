@@ -0,0 +1,44 @@
+package mt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// A definitionFingerprinter is a test case whose fingerprint is computed from
+// fields of its definition other than its action, target, and description,
+// which may change as the test case is executed.
+type definitionFingerprinter interface {
+	fingerprintFields() []string
+}
+
+// Fingerprint computes a stable identifier for a test case from its action,
+// target, and description.
+//
+// Fingerprints allow external systems to track the history of an individual
+// test case across runs without assigning IDs manually. A fingerprint changes
+// only if the test's method, path, or description changes. The path of an HTTP
+// test case is taken as defined, including its query and before any path
+// parameters are expanded, so its fingerprint is the same before and after it's
+// executed.
+func Fingerprint(tc TestCase) string {
+	fields := []string{tc.Action(), tc.Target(), tc.Description()}
+	if fingerprinter, ok := tc.(definitionFingerprinter); ok {
+		fields = fingerprinter.fingerprintFields()
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// fingerprintFields returns the method, path and query, and description of the
+// test case as defined.
+func (tc *HTTPTestCase) fingerprintFields() []string {
+	target := tc.path
+	if tc.rawQuery != "" {
+		target += "?" + tc.rawQuery
+	}
+
+	return []string{tc.Action(), target, tc.Desc}
+}
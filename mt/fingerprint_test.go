@@ -0,0 +1,26 @@
+package mt_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jefflinse/melatonin/mt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprint(t *testing.T) {
+	ctx := mt.NewHandlerContext(statusHandler(http.StatusOK))
+	tc := ctx.GET("/users/:id?fields=name").
+		WithPathParam("id", 1).
+		WithQueryParam("verbose", true).
+		ExpectStatus(http.StatusOK)
+
+	fingerprint := mt.Fingerprint(tc)
+	assert.Empty(t, tc.Execute().Failures())
+	assert.Equal(t, "/users/1", tc.Target())
+	assert.Equal(t, fingerprint, mt.Fingerprint(tc))
+
+	assert.NotEqual(t, fingerprint, mt.Fingerprint(ctx.GET("/users/:id")))
+	assert.NotEqual(t, fingerprint, mt.Fingerprint(ctx.POST("/users/:id?fields=name")))
+	assert.NotEqual(t, fingerprint, mt.Fingerprint(ctx.GET("/users/:id?fields=name", "get a user")))
+}
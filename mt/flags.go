@@ -0,0 +1,69 @@
+package mt
+
+import "fmt"
+
+// A FlagProvider resolves the state of a feature flag at run time.
+type FlagProvider func(flag string) (bool, error)
+
+// flagVariant holds the expectations of a test case for each state of a
+// feature flag.
+type flagVariant struct {
+	flag string
+	on   ExpectFunc
+	off  ExpectFunc
+}
+
+// WithFlagProvider sets the provider used to resolve feature flags for test
+// cases created by the context, and returns the context.
+func (c *HTTPTestContext) WithFlagProvider(provider FlagProvider) *HTTPTestContext {
+	c.FlagProvider = provider
+	return c
+}
+
+// WhenFlag declares that the test case's expectations depend on the state of a
+// feature flag. When the test case is executed, the flag is resolved using the
+// context's flag provider and either the on or the off expectations are applied
+// to the test case. Either may be nil.
+//
+//	api.GET("/price").
+//		WhenFlag("new-pricing",
+//			func(tc *mt.HTTPTestCase) { tc.ExpectBody(json.Object{"price": 9.99}) },
+//			func(tc *mt.HTTPTestCase) { tc.ExpectBody(json.Object{"price": 10}) },
+//		)
+func (tc *HTTPTestCase) WhenFlag(flag string, on, off ExpectFunc) *HTTPTestCase {
+	tc.flagVariants = append(tc.flagVariants, flagVariant{flag, on, off})
+	return tc
+}
+
+// flagExpectations resolves each feature flag the test case depends on and
+// returns the test case's expectations combined with those of the resolved
+// variants. The test case itself is left unchanged, so that the flags are
+// resolved anew each time it is executed.
+func (tc *HTTPTestCase) flagExpectations() (expectatons, error) {
+	if len(tc.flagVariants) == 0 {
+		return tc.Expectations, nil
+	}
+
+	var fns []ExpectFunc
+	for _, variant := range tc.flagVariants {
+		if tc.tctx.FlagProvider == nil {
+			return expectatons{}, fmt.Errorf("no flag provider configured to resolve feature flag %q", variant.flag)
+		}
+
+		enabled, err := tc.tctx.FlagProvider(variant.flag)
+		if err != nil {
+			return expectatons{}, fmt.Errorf("failed to resolve feature flag %q: %w", variant.flag, err)
+		}
+
+		expectations := variant.off
+		if enabled {
+			expectations = variant.on
+		}
+
+		if expectations != nil {
+			fns = append(fns, expectations)
+		}
+	}
+
+	return tc.collectExpectations(tc.Expectations, fns...)
+}
@@ -0,0 +1,43 @@
+package mt_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jefflinse/melatonin/mt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWhenFlagResolvesEachExecution(t *testing.T) {
+	enabled := false
+	ctx := mt.NewHandlerContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if enabled {
+			w.Header().Set("X-New", "1")
+		} else {
+			w.Header().Set("X-Old", "1")
+		}
+	})).WithFlagProvider(func(flag string) (bool, error) {
+		return enabled, nil
+	})
+
+	tc := ctx.GET("/").WhenFlag("new",
+		func(tc *mt.HTTPTestCase) { tc.ExpectNoHeader("X-Old").ExpectHeader("X-New", "1") },
+		func(tc *mt.HTTPTestCase) { tc.ExpectNoHeader("X-New").ExpectHeader("X-Old", "1") },
+	)
+
+	for _, state := range []bool{true, false, true, false} {
+		enabled = state
+		assert.Empty(t, tc.Execute().Failures(), "flag enabled: %t", state)
+	}
+
+	assert.Empty(t, tc.Expectations.AbsentHeaders)
+	assert.Empty(t, tc.Expectations.Headers)
+}
+
+func TestWhenFlagWithoutProvider(t *testing.T) {
+	tc := mt.NewHandlerContext(http.NotFoundHandler()).GET("/").WhenFlag("new", nil, nil)
+	failures := tc.Execute().Failures()
+	if assert.Len(t, failures, 1) {
+		assert.EqualError(t, failures[0], `no flag provider configured to resolve feature flag "new"`)
+	}
+}
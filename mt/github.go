@@ -0,0 +1,97 @@
+package mt
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	gitHubDataEscaper = strings.NewReplacer(
+		"%", "%25",
+		"\r", "%0D",
+		"\n", "%0A",
+	)
+
+	gitHubPropertyEscaper = strings.NewReplacer(
+		"%", "%25",
+		"\r", "%0D",
+		"\n", "%0A",
+		":", "%3A",
+		",", "%2C",
+	)
+)
+
+// A sourceLocator is a test case that knows where in the source it was defined.
+type sourceLocator interface {
+	SourceLocation() (file string, line int)
+}
+
+// FPrintGitHubAnnotations prints a GitHub Actions error annotation for each
+// failed test in a group run to the given io.Writer, so that failures appear
+// inline in pull request checks. Suppressed failures are annotated as warnings.
+//
+// If a test case knows where it was defined in the source, the annotation
+// references that file and line. When run in GitHub Actions, the file is
+// relative to the workspace, so that annotations of tests in packages below the
+// root of the repository are attached to their files.
+func FPrintGitHubAnnotations(w io.Writer, results *GroupRunResult) {
+	forEachTestRunResult(results, func(result TestRunResult) {
+		failures := result.TestResult.Failures()
+		if len(failures) == 0 {
+			return
+		}
+
+		properties := []string{}
+		if locator, ok := result.TestCase.(sourceLocator); ok {
+			if file, line := locator.SourceLocation(); file != "" {
+				properties = append(properties,
+					"file="+gitHubPropertyEscaper.Replace(gitHubFile(file)),
+					fmt.Sprintf("line=%d", line))
+			}
+		}
+
+		title := fmt.Sprintf("%s %s: %s",
+			result.TestCase.Action(),
+			result.TestCase.Target(),
+			result.TestCase.Description())
+		properties = append(properties, "title="+gitHubPropertyEscaper.Replace(title))
+
+		messages := make([]string, len(failures))
+		for i, failure := range failures {
+			messages[i] = failure.Error()
+		}
+
+		command := "error"
+		if result.Suppressed != "" {
+			command = "warning"
+			messages = append([]string{fmt.Sprintf("failures suppressed (%s)", result.Suppressed)}, messages...)
+		}
+
+		fmt.Fprintf(w, "::%s %s::%s\n",
+			command,
+			strings.Join(properties, ","),
+			gitHubDataEscaper.Replace(strings.Join(messages, "\n")))
+	})
+}
+
+// gitHubFile returns the path of a source file relative to the GitHub Actions
+// workspace, if it's within the workspace.
+func gitHubFile(file string) string {
+	workspace := os.Getenv("GITHUB_WORKSPACE")
+	if workspace == "" {
+		return file
+	}
+
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(cfg.WorkingDir, file)
+	}
+
+	if rel, err := filepath.Rel(workspace, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+
+	return file
+}
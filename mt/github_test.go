@@ -0,0 +1,27 @@
+package mt_test
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jefflinse/melatonin/mt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitHubAnnotationsInWorkspace(t *testing.T) {
+	dir, err := os.Getwd()
+	if !assert.NoError(t, err) {
+		return
+	}
+	t.Setenv("GITHUB_WORKSPACE", filepath.Dir(dir))
+
+	ctx := mt.NewHandlerContext(statusHandler(http.StatusOK))
+	results := mt.NewTestRunner().RunTests(ctx.GET("/", "fails").ExpectStatus(http.StatusCreated))
+
+	w := &bytes.Buffer{}
+	mt.FPrintGitHubAnnotations(w, results)
+	assert.Contains(t, w.String(), "::error file=mt/github_test.go,")
+}
@@ -0,0 +1,140 @@
+package mt
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/jefflinse/melatonin/golden"
+)
+
+// goldenPath returns the path of the test case's golden file, which is relative
+// to the working directory unless absolute.
+func (tc *HTTPTestCase) goldenPath() string {
+	if filepath.IsAbs(tc.GoldenFilePath) {
+		return tc.GoldenFilePath
+	}
+
+	return filepath.Join(cfg.WorkingDir, tc.GoldenFilePath)
+}
+
+// updateGoldenFile rewrites the test case's golden file to expect the response
+// of a result, keeping the file's directives and the set of headers it expects,
+// and reloads the test case's expectations from it.
+//
+// Golden files are updated when the MELATONIN_UPDATE_GOLDEN environment
+// variable is set.
+func (tc *HTTPTestCase) updateGoldenFile(result *HTTPTestCaseResult) error {
+	path := tc.goldenPath()
+	existing, err := golden.LoadFile(path)
+	if err != nil {
+		existing = &golden.Golden{}
+	}
+
+	updated := &golden.Golden{WantStatus: result.Status}
+	if existing.WantHeaders != nil {
+		updated.MatchHeadersExactly = existing.MatchHeadersExactly
+		if existing.MatchHeadersExactly {
+			updated.WantHeaders = result.Headers.Clone()
+		} else {
+			updated.WantHeaders = http.Header{}
+			for key := range existing.WantHeaders {
+				if values := result.Headers.Values(key); len(values) > 0 {
+					updated.WantHeaders[key] = values
+				}
+			}
+		}
+	}
+
+	if body := tc.goldenNormalization().Apply(result.Body); len(body) > 0 {
+		updated.WantBody = string(body)
+
+		// a body that was expected as text stays text, even if it's JSON
+		if _, isText := existing.WantBody.(string); !isText {
+			switch decoded := toInterface(body).(type) {
+			case map[string]any, []any:
+				updated.WantBody = decoded
+				updated.MatchBodyJSONExactly = existing.MatchBodyJSONExactly
+			}
+		}
+	}
+
+	if err := updated.SaveFile(path); err != nil {
+		return err
+	}
+
+	return tc.Validate()
+}
+
+// A GoldenDiffError is a failure of a response body to match the body of a
+// golden file, described by a unified diff of the expected and actual bodies.
+type GoldenDiffError struct {
+	// Path is the path of the golden file.
+	Path string
+
+	// Diff is the lines of the unified diff, each beginning with "@", " ", "-",
+	// or "+".
+	Diff []string
+}
+
+func (e *GoldenDiffError) Error() string {
+	return strings.Join(append(append([]string{e.summary()}, e.Diff...), e.hint()), "\n")
+}
+
+func (e *GoldenDiffError) summary() string {
+	return fmt.Sprintf("golden file %q: body does not match:", e.Path)
+}
+
+func (e *GoldenDiffError) diffLines() []string {
+	return e.Diff
+}
+
+func (e *GoldenDiffError) hint() string {
+	return "to update the golden file, rerun with MELATONIN_UPDATE_GOLDEN=1"
+}
+
+// goldenDiff returns a failure describing the differences between the body of
+// a golden file and a response body, given both decoded and as text, or nil if
+// they can't be usefully diffed. A JSON body is diffed only if it is matched
+// exactly, since the differences of a subset match aren't all failures.
+func goldenDiff(path string, expected, actual any, text []byte, exact bool) *GoldenDiffError {
+	var want, got string
+	switch expected.(type) {
+	case string:
+		want, got = expected.(string), string(text)
+
+	case map[string]any, []any:
+		if !exact {
+			return nil
+		}
+
+		want, got = indentJSON(expected), indentJSON(actual)
+		if _, isText := actual.(string); isText {
+			got = string(text)
+		}
+
+	default:
+		return nil
+	}
+
+	diff := unifiedDiff(strings.Split(want, "\n"), strings.Split(got, "\n"), cfg.GoldenDiffContext)
+	if len(diff) == 0 {
+		return nil
+	}
+
+	return &GoldenDiffError{
+		Path: path,
+		Diff: append([]string{"--- " + path, "+++ response"}, diff...),
+	}
+}
+
+func indentJSON(v any) string {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%+v", v)
+	}
+
+	return string(b)
+}
@@ -69,41 +69,68 @@ func PUT(url string, description ...string) *HTTPTestCase {
 // DO creates a test case from a custom HTTP request.
 func DO(request *http.Request, description ...string) *HTTPTestCase {
 	tc := DefaultContext().newHTTPTestCase(request.Method, request.URL.Path, description...)
-	tc.request = request
+	tc.useRequest(request)
 	return tc
 }
 
-func createRequest(method, path string) (*http.Request, context.CancelFunc, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
-	req, err := http.NewRequestWithContext(ctx, method, path, nil)
-	if err != nil {
-		return nil, cancel, err
-	}
-
-	return req, cancel, nil
+func createRequest(method, path string) (*http.Request, error) {
+	return http.NewRequestWithContext(context.Background(), method, path, nil)
 }
 
-func doRequest(c *http.Client, req *http.Request) (int, http.Header, []byte, error) {
+func doRequest(c *http.Client, req *http.Request) (*http.Response, []byte, error) {
 	resp, err := c.Do(req)
 	if err != nil {
-		return -1, nil, nil, err
+		return nil, nil, err
 	}
 
+	// trailers are only populated once the body has been read entirely
 	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return -1, nil, nil, err
+		return nil, nil, err
 	}
 
-	return resp.StatusCode, resp.Header, body, nil
+	return resp, body, nil
 }
 
-func handleRequest(h http.Handler, req *http.Request) (int, http.Header, []byte, error) {
+func handleRequest(h http.Handler, req *http.Request) (*http.Response, []byte, error) {
 	w := httptest.NewRecorder()
 	h.ServeHTTP(w, req)
 	resp := w.Result()
+	resp.TLS = req.TLS
 	b, err := ioutil.ReadAll(resp.Body)
-	return resp.StatusCode, resp.Header, b, err
+	return resp, b, err
+}
+
+// handleRequestWithJar serves a request with a handler, sending the cookies of
+// a cookie jar and storing the cookies set by the response, as an HTTP client
+// using the jar would. If the jar is nil, no cookies are sent or stored.
+func handleRequestWithJar(h http.Handler, req *http.Request, jar http.CookieJar) (*http.Response, []byte, error) {
+	if jar == nil {
+		return handleRequest(h, req)
+	}
+
+	// requests served by a handler have no scheme or host, which a cookie jar
+	// requires to scope its cookies
+	u := *req.URL
+	if u.Scheme == "" {
+		u.Scheme = "http"
+	}
+	if u.Host == "" {
+		u.Host = "localhost"
+	}
+
+	req = req.Clone(req.Context())
+	for _, cookie := range jar.Cookies(&u) {
+		req.AddCookie(cookie)
+	}
+
+	resp, body, err := handleRequest(h, req)
+	if err == nil {
+		jar.SetCookies(&u, resp.Cookies())
+	}
+
+	return resp, body, err
 }
 
 func toBytes(body any) ([]byte, error) {
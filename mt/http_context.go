@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+
+	"github.com/jefflinse/melatonin/golden"
 )
 
 // An HTTPTestContext is used to create HTTP test cases that target either
@@ -15,6 +17,59 @@ type HTTPTestContext struct {
 	BaseURL string
 	Client  *http.Client
 	Handler http.Handler
+
+	// BodyDiff indicates whether test cases created from the context describe
+	// mismatched JSON response bodies with a unified diff. See WithBodyDiff().
+	BodyDiff bool
+
+	// CacheBusters are the strategies used to bypass caching layers for the
+	// requests of test cases created from the context. See WithCacheBuster().
+	CacheBusters []CacheBuster
+
+	// Clock is made available to handlers through the request context.
+	// See WithClock().
+	Clock Clock
+
+	// Credentials authenticate the requests of test cases created from the
+	// context. See NewRoleContexts().
+	Credentials Credentials
+
+	// FailFast indicates whether test cases created from the context stop
+	// evaluating expectations once a category of expectations fails. See
+	// WithFailFast().
+	FailFast bool
+
+	// FlagProvider resolves feature flags for test cases that depend on them.
+	// See HTTPTestCase.WhenFlag().
+	FlagProvider FlagProvider
+
+	// GoldenNormalization is the set of differences between golden file bodies
+	// and response bodies that are ignored by test cases created from the
+	// context. If nil, golden.DefaultNormalization is used. See
+	// WithGoldenNormalization().
+	GoldenNormalization *golden.Normalization
+
+	// Memoize indicates whether test cases created from the context reuse the
+	// responses of identical GET requests. See WithMemoization().
+	Memoize bool
+
+	// Shadow is a target that receives a copy of the request of every test
+	// case created from the context. See WithShadow().
+	Shadow *HTTPTestContext
+
+	// ShadowIgnore are the JSON paths of response body values that are excluded
+	// when comparing primary and shadow responses.
+	ShadowIgnore []string
+
+	// Strictness controls body comparisons for test cases created from the
+	// context. See WithStrictness().
+	Strictness *Strictness
+
+	// UserAgent is the User-Agent sent by test cases created from the context.
+	// See WithUserAgent().
+	UserAgent string
+
+	memo *memo
 }
 
 // DefaultContext returns an HTTPTestContext using the default HTTP client.
@@ -45,6 +100,15 @@ func (c *HTTPTestContext) WithHTTPClient(client *http.Client) *HTTPTestContext {
 	return c
 }
 
+// WithGoldenNormalization sets the differences between golden file bodies and
+// response bodies, such as byte order marks and line endings, that are ignored
+// by test cases created from the context, and returns the context. By default,
+// golden.DefaultNormalization is used.
+func (c *HTTPTestContext) WithGoldenNormalization(normalization golden.Normalization) *HTTPTestContext {
+	c.GoldenNormalization = &normalization
+	return c
+}
+
 // DELETE is a shortcut for NewTestCase(http.MethodDelete, path).
 func (c *HTTPTestContext) DELETE(path string, description ...string) *HTTPTestCase {
 	return c.newHTTPTestCase(http.MethodDelete, path, description...)
@@ -83,7 +147,7 @@ func (c *HTTPTestContext) PUT(path string, description ...string) *HTTPTestCase
 // DO creates a test case from a custom HTTP request.
 func (c *HTTPTestContext) DO(request *http.Request, description ...string) *HTTPTestCase {
 	tc := c.newHTTPTestCase(request.Method, request.URL.Path, description...)
-	tc.request = request
+	tc.useRequest(request)
 	return tc
 }
 
@@ -121,17 +185,24 @@ func (c *HTTPTestContext) newHTTPTestCase(method, path string, description ...st
 		log.Fatalf("failed to create URL for path %q: %v", path, err)
 	}
 
-	req, cancel, err := createRequest(method, u.String())
+	req, err := createRequest(method, u.String())
 	if err != nil {
 		log.Fatalf("failed to create request %v", err)
 	}
 
-	return &HTTPTestCase{
+	if c.Credentials != nil {
+		c.Credentials(req)
+	}
+
+	tc := &HTTPTestCase{
 		Desc:        strings.Join(description, " "),
 		tctx:        c,
 		pathParams:  parameters{},
 		queryParams: parameters{},
-		request:     req,
-		cancel:      cancel,
+		timeout:     defaultRequestTimeout,
+		source:      callerLocation(),
 	}
+	tc.useRequest(req)
+
+	return tc
 }
@@ -3,15 +3,21 @@ package mt
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
+	"github.com/jefflinse/melatonin/expect"
 	"github.com/jefflinse/melatonin/golden"
+	"github.com/jefflinse/melatonin/jq"
 	mtjson "github.com/jefflinse/melatonin/json"
 )
 
@@ -48,6 +54,12 @@ type HTTPTestCase struct {
 	// values from the golden file.
 	GoldenFilePath string
 
+	// RequirementIDs are the IDs of the requirements covered by the test case.
+	RequirementIDs []string
+
+	// SeverityLevel is the severity of the test case's failures. Default is Critical.
+	SeverityLevel Severity
+
 	// Path parameters to be mapped into the request path.
 	pathParams parameters
 
@@ -60,18 +72,146 @@ type HTTPTestCase struct {
 	// Configuration for the test
 	tctx *HTTPTestContext
 
+	// TLS client certificate presented for this test case only, overriding
+	// any certificates configured on the context's HTTP client.
+	clientCert *tls.Certificate
+
+	// Errors in the definition of the test case, such as invalid arguments to
+	// its methods, which are reported when the test case is validated.
+	definitionErrors []error
+
+	// Transport presenting the client certificate, cloned from the transport
+	// of the context's HTTP client once and reused by later executions.
+	certTransport     *http.Transport
+	certTransportBase *http.Transport
+
+	// Location in the source where the test case was defined.
+	source sourceLocation
+
+	// Expectations that only apply when a condition holds for the response.
+	conditionals []conditional
+
+	// Hooks called at each phase of execution.
+	phaseHooks []PhaseFunc
+
+	// Expectations whose mismatches are reported as warnings.
+	warnExpectations []ExpectFunc
+
+	// Sets of acceptable outcomes, any one of which the response must match.
+	outcomes [][]ExpectFunc
+
+	// Expectations that depend on the state of feature flags.
+	flagVariants []flagVariant
+
+	// Amount by which to advance the context's fake clock before the request
+	// is handled.
+	clockAdvance time.Duration
+
+	// Body comparison strictness set by the test runner, used unless the
+	// test context sets its own.
+	defaultStrictness *Strictness
+
+	// Result of the most recent execution of the test case.
+	lastResult *HTTPTestCaseResult
+
+	// Whether to stop evaluating expectations once a category of expectations
+	// fails, overriding the context's.
+	failFastOverride *bool
+
+	// Whether a mismatched JSON response body is described by a unified diff,
+	// overriding the context's.
+	bodyDiffOverride *bool
+
+	// User-Agent sent by the test case, overriding the context's.
+	userAgent string
+
+	// Strategies for bypassing caching layers, overriding the context's.
+	cacheBusters []CacheBuster
+
+	// Whether the request is always sent, even if the context memoizes
+	// responses.
+	noMemoize bool
+
+	// Batch the test case's request is sent in, and the index of the request
+	// within the batch.
+	batch      *Batch
+	batchIndex int
+
+	// Cookie jar shared with other steps of the scenario the test case is part of.
+	jar http.CookieJar
+
 	// Underlying HTTP request for the test case.
 	request *http.Request
 
-	// Cancel function for the underlying HTTP request.
-	cancel context.CancelFunc
+	// Context, path, and query of the underlying HTTP request as originally
+	// specified, from which each execution of the test case starts.
+	ctx      context.Context
+	path     string
+	rawQuery string
+
+	// Timeout for each execution of the test case.
+	timeout time.Duration
+
+	// Whether the test case intentionally sends a body with a GET request.
+	allowGETBody bool
+
+	// Path of the file the response body is streamed to rather than buffered
+	// in memory.
+	responseFile string
+
+	// Hooks called as a streamed request body is sent.
+	uploadProgress []UploadProgressFunc
+
+	// Budget for waits on Retry-After headers set by the test runner, or nil
+	// if throttled requests are not sent again.
+	retryAfter *retryAfterBudget
 }
 
 // expectatons represents the expected values for single HTTP response.
 type expectatons struct {
+	// AbsentHeaders are the names of HTTP headers that are expected not to be
+	// present in the HTTP response.
+	AbsentHeaders []string
+
 	// Body is the expected HTTP response body content.
 	Body any
 
+	// BodyBytes is the expected HTTP response body, compared byte for byte
+	// without any interpretation of its content.
+	BodyBytes []byte
+
+	// BodyCodec is the media type of the registered codec used to decode the
+	// HTTP response body, rather than that of the response's Content-Type.
+	BodyCodec string
+
+	// BodyNormalization is the set of differences between the expected body and
+	// the response body that are ignored when comparing them, such as those of
+	// golden files edited on other platforms.
+	BodyNormalization golden.Normalization
+
+	// BodyPatterns are regular expressions that the entire raw HTTP response
+	// body is expected to match.
+	BodyPatterns []*regexp.Regexp
+
+	// BodyRanges are the expected bytes at offsets within the HTTP response
+	// body.
+	BodyRanges []BodyRange
+
+	// BodySHA256 is the expected hex-encoded SHA-256 checksum of the HTTP
+	// response body.
+	BodySHA256 string
+
+	// BodySize is the expected size in bytes of the HTTP response body.
+	BodySize *int64
+
+	// Certificate are matchers for the leaf certificate presented by the server.
+	Certificate []CertificateMatcher
+
+	// ContentType is the expected media type of the HTTP response, such as
+	// "application/json". Parameters, such as "charset", are only compared if
+	// they are part of the expected value.
+	ContentType string
+
 	// ExactHeaders indicates whether or not any unexpected response headers
 	// should be treated as a test failure.
 	WantExactHeaders bool
@@ -80,14 +220,100 @@ type expectatons struct {
 	// exactly (true) or treated as a subset of the response JSON (false).
 	WantExactJSONBody bool
 
+	// GoldenFile is the path of the golden file the expectations were loaded
+	// from, if any.
+	GoldenFile string
+
 	// Headers is a map of HTTP headers that are expected to be present in
 	// the HTTP response.
 	Headers http.Header
 
+	// Invariants are predicates evaluated against the entire decoded response
+	// body, in addition to any expected body.
+	Invariants []expect.Predicate
+
+	// JQ are the expected values output by jq programs evaluated against the
+	// response body.
+	JQ []JQExpectation
+
+	// JSONPaths are the expected values at JSON paths within the response body.
+	JSONPaths []JSONPathExpectation
+
+	// NoBody indicates whether the HTTP response is expected to have an empty
+	// body.
+	NoBody bool
+
+	// Proto is the expected protocol version of the response.
+	Proto string
+
+	// RateLimit is the expected rate limit quota advertised by the response's
+	// headers.
+	RateLimit *RateLimitExpectation
+
+	// Schema is the JSON Schema the response body is expected to be valid
+	// against.
+	Schema any
+
+	// TLSCipherSuite is the expected TLS cipher suite of the connection.
+	TLSCipherSuite uint16
+
+	// TLSVersion is the expected TLS version of the connection.
+	TLSVersion uint16
+
+	// Trailers is a map of HTTP trailers that are expected to be present in the
+	// HTTP response.
+	Trailers http.Header
+
+	// IgnoredBodyPaths are the JSON paths of response body values that are
+	// excluded when matching the expected body.
+	IgnoredBodyPaths []string
+
 	// Status is the expected HTTP status code of the response. Default is 200.
 	Status int
+
+	// StatusText is the expected reason phrase of the response's status line.
+	StatusText string
+
+	// UnwantedStatuses are HTTP status codes the response is expected not to
+	// have.
+	UnwantedStatuses []int
 }
 
+// clone returns a copy of the expectations that can be added to without
+// changing the original.
+func (e expectatons) clone() expectatons {
+	e.AbsentHeaders = e.AbsentHeaders[:len(e.AbsentHeaders):len(e.AbsentHeaders)]
+	e.BodyPatterns = e.BodyPatterns[:len(e.BodyPatterns):len(e.BodyPatterns)]
+	e.BodyRanges = e.BodyRanges[:len(e.BodyRanges):len(e.BodyRanges)]
+	e.Certificate = e.Certificate[:len(e.Certificate):len(e.Certificate)]
+	e.IgnoredBodyPaths = e.IgnoredBodyPaths[:len(e.IgnoredBodyPaths):len(e.IgnoredBodyPaths)]
+	e.Invariants = e.Invariants[:len(e.Invariants):len(e.Invariants)]
+	e.JQ = e.JQ[:len(e.JQ):len(e.JQ)]
+	e.JSONPaths = e.JSONPaths[:len(e.JSONPaths):len(e.JSONPaths)]
+	e.UnwantedStatuses = e.UnwantedStatuses[:len(e.UnwantedStatuses):len(e.UnwantedStatuses)]
+	e.Headers = e.Headers.Clone()
+	e.Trailers = e.Trailers.Clone()
+	return e
+}
+
+// IgnorePaths is a set of JSON paths of response body values to exclude when
+// matching an expected body. See Ignore().
+type IgnorePaths []string
+
+// Ignore creates a set of JSON paths to exclude when matching an expected body.
+// Paths are dot-separated and may contain "*" wildcards and "[n]" indices, for
+// example "data.*.updated_at" or "items[0].id".
+func Ignore(paths ...string) IgnorePaths {
+	return IgnorePaths(paths)
+}
+
+// An ExpectFunc configures a set of expectations on a test case, for example:
+//
+//	func(tc *mt.HTTPTestCase) {
+//		tc.ExpectStatus(200).ExpectBody("OK")
+//	}
+type ExpectFunc func(tc *HTTPTestCase)
+
 var _ TestCase = &HTTPTestCase{}
 
 // Action returns a short, uppercase verb describing the action performed by the
@@ -129,13 +355,19 @@ func (tc *HTTPTestCase) Description() string {
 
 // Execute runs the test case.
 func (tc *HTTPTestCase) Execute() TestResult {
-	if tc.cancel != nil {
-		defer tc.cancel()
-	}
+	ctx, cancel := context.WithTimeout(tc.ctx, tc.timeout)
+	defer cancel()
+	tc.request = tc.request.WithContext(ctx)
 
+	start := time.Now()
 	result := &HTTPTestCaseResult{
 		testCase: tc,
 	}
+	tc.lastResult = result
+
+	if err := tc.Validate(); err != nil {
+		return result.addFailures(err)
+	}
 
 	if tc.BeforeFunc != nil {
 		if err := tc.BeforeFunc(); err != nil {
@@ -143,49 +375,125 @@ func (tc *HTTPTestCase) Execute() TestResult {
 		}
 	}
 
-	// apply path parameters
-	expandedPath, err := tc.pathParams.applyTo(tc.request.URL.Path)
+	expectations, err := tc.flagExpectations()
 	if err != nil {
 		return result.addFailures(err)
 	}
-	tc.request.URL.Path = expandedPath
+	result.expectations = expectations
 
-	rawQuery, err := tc.queryParams.asRawQuery()
-	if err != nil {
-		return result.addFailures(err)
-	}
-	tc.request.URL.RawQuery = rawQuery
-
-	// resolve deferred values
-	resolvedBody, err := mtjson.ResolveDeferred(tc.requestBody)
+	b, err := tc.prepareRequest()
 	if err != nil {
 		return result.addFailures(err)
 	}
 
-	b, err := toBytes(resolvedBody)
-	if err != nil {
-		return result.addFailures(err)
-	}
+	// the user agent is not part of the test case's definition, so it's only
+	// set for the duration of the request
+	defer tc.applyUserAgent()()
+	result.Configuration = tc.effectiveConfiguration()
+
+	shadowed := tc.sendShadow(b)
+	clockApplied := false
+	for {
+		if tc.recall(b, result) {
+			tc.beginSend(result, start)
+		} else if tc.batch != nil {
+			sent := tc.beginSend(result, start)
+			err := tc.batch.receive(tc.batchIndex, result)
+			result.Timings.Network = time.Since(sent)
+			if err != nil {
+				result.Status = -1
+				return result.addFailures(err)
+			}
+		} else if tc.tctx.Handler != nil {
+			// the clock only advances once, however many times the request is
+			// sent again
+			if !clockApplied {
+				if err := tc.applyClock(); err != nil {
+					return result.addFailures(err)
+				}
+				clockApplied = true
+			}
+
+			if tc.clientCert != nil {
+				state, err := peerCertificateState(tc.clientCert)
+				if err != nil {
+					return result.addFailures(err)
+				}
+
+				tc.request.TLS = state
+			}
+
+			sent := tc.beginSend(result, start)
+			resp, body, err := handleRequestWithJar(tc.tctx.Handler, tc.request, tc.jar)
+			if err == nil && tc.responseFile != "" {
+				result.BodyFile, err = streamToFile(tc.responseFile, bytes.NewReader(body))
+				body = nil
+			}
+			result.Timings.Network = time.Since(sent)
+			if err != nil {
+				result.Status = -1
+				return result.addFailures(fmt.Errorf("failed to handle HTTP request: %w", err))
+			}
+
+			result.setResponse(resp, body)
+		} else {
+			if tc.tctx.Client == nil {
+				tc.tctx.Client = http.DefaultClient
+			}
+
+			client, err := tc.httpClient()
+			if err != nil {
+				return result.addFailures(err)
+			}
+
+			sent := tc.beginSend(result, start)
+			var resp *http.Response
+			var body []byte
+			if tc.responseFile != "" {
+				resp, result.BodyFile, err = doRequestToFile(client, tc.request, tc.responseFile)
+			} else {
+				resp, body, err = doRequest(client, tc.request)
+			}
+			result.Timings.Network = time.Since(sent)
+			if err != nil {
+				result.Status = -1
+				return result.addFailures(fmt.Errorf("failed to execute HTTP request: %w", err))
+			}
+
+			result.setResponse(resp, body)
+		}
 
-	tc.request.Body = io.NopCloser(bytes.NewReader(b))
+		// throttled requests are sent again once the server allows, if the
+		// test runner honors Retry-After headers
+		wait, ok := tc.retryAfterWait(result)
+		if !ok {
+			break
+		}
 
-	if tc.tctx.Handler != nil {
-		result.Status, result.Headers, result.Body, err = handleRequest(tc.tctx.Handler, tc.request)
-		if err != nil {
-			return result.addFailures(fmt.Errorf("failed to handle HTTP request: %w", err))
+		if err := tc.waitToRetry(result, wait); err != nil {
+			return result.addFailures(err)
 		}
-	} else {
-		if tc.tctx.Client == nil {
-			tc.tctx.Client = http.DefaultClient
+
+		if b, err = tc.prepareRequest(); err != nil {
+			return result.addFailures(err)
 		}
+	}
 
-		result.Status, result.Headers, result.Body, err = doRequest(tc.tctx.Client, tc.request)
-		if err != nil {
-			return result.addFailures(fmt.Errorf("failed to execute HTTP request: %w", err))
+	tc.remember(b, result)
+	if cfg.UpdateGolden && tc.GoldenFilePath != "" {
+		if err := tc.updateGoldenFile(result); err != nil {
+			return result.addFailures(err)
 		}
 	}
 
+	tc.enterPhase(AfterReceive, result)
+	received := time.Now()
 	result.validateExpectations()
+	result.Timings.Assert = time.Since(received)
+	if shadowed != nil {
+		result.compareShadow(<-shadowed)
+	}
+	tc.enterPhase(AfterAssert, result)
 
 	if tc.AfterFunc != nil {
 		if err := tc.AfterFunc(); err != nil {
@@ -196,6 +504,49 @@ func (tc *HTTPTestCase) Execute() TestResult {
 	return result
 }
 
+// prepareRequest applies the test case's path parameters, query parameters, and
+// body to its underlying request, returning the request body.
+func (tc *HTTPTestCase) prepareRequest() ([]byte, error) {
+	// apply path parameters
+	expandedPath, err := tc.pathParams.applyTo(tc.path)
+	if err != nil {
+		return nil, err
+	}
+	tc.request.URL.Path = expandedPath
+
+	// apply query parameters in addition to any specified in the original URL
+	rawQuery, err := tc.queryParams.asRawQuery()
+	if err != nil {
+		return nil, err
+	}
+	if tc.rawQuery != "" && rawQuery != "" {
+		rawQuery = tc.rawQuery + "&" + rawQuery
+	} else if tc.rawQuery != "" {
+		rawQuery = tc.rawQuery
+	}
+	tc.request.URL.RawQuery = rawQuery
+	tc.bustCache()
+
+	if body, ok := tc.requestBody.(*bodyReader); ok {
+		tc.prepareStreamedBody(body)
+		return nil, nil
+	}
+
+	// resolve deferred values
+	resolvedBody, err := mtjson.ResolveDeferred(tc.requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := encodeBody(tc.request.Header.Get("Content-Type"), resolvedBody)
+	if err != nil {
+		return nil, err
+	}
+
+	tc.request.Body = io.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
+
 // Target returns a string representing the target of the action performed by the
 // test case.
 func (tc *HTTPTestCase) Target() string {
@@ -206,12 +557,59 @@ func (tc *HTTPTestCase) Target() string {
 // Chainable qualifier methods that can be used to configure the test case.
 //
 
+// AllowGETBody indicates that the test case intentionally sends a body with a
+// GET request, which Lint() and strict test runs otherwise reject.
+func (tc *HTTPTestCase) AllowGETBody() *HTTPTestCase {
+	tc.allowGETBody = true
+	return tc
+}
+
 // WithBody sets the request body for the test case.
 func (tc *HTTPTestCase) WithBody(body any) *HTTPTestCase {
 	tc.requestBody = body
 	return tc
 }
 
+// WithBodyFromResult sets the request body for the test case to the parsed
+// response body of the most recent execution of a previous test case, after
+// applying an optional transform, for example:
+//
+//	get := ctx.GET("/users/1")
+//	put := ctx.PUT("/users/1").WithBodyFromResult(get, func(body any) (any, error) {
+//		body.(map[string]any)["name"] = "updated"
+//		return body, nil
+//	})
+//
+// The body is resolved each time the test case is executed, and the transform
+// receives a fresh copy of the previous response body that it is free to modify.
+func (tc *HTTPTestCase) WithBodyFromResult(prev *HTTPTestCase, transform func(body any) (any, error)) *HTTPTestCase {
+	return tc.WithBody(func() (any, error) {
+		if prev.lastResult == nil {
+			return nil, fmt.Errorf("cannot use response body of %q, which has not been executed", prev.Description())
+		}
+
+		body := prev.lastResult.body()
+		if transform != nil {
+			return transform(body)
+		}
+
+		return body, nil
+	})
+}
+
+// WithClientCert sets the TLS client certificate presented by the test case,
+// overriding any client certificates configured on the context's HTTP client.
+//
+// For handler contexts, the certificate's leaf is made available to the
+// handler as the request's peer certificate. It is not verified, so the
+// request's verified chains are empty.
+func (tc *HTTPTestCase) WithClientCert(cert tls.Certificate) *HTTPTestCase {
+	tc.closeIdleConnections()
+	tc.certTransport, tc.certTransportBase = nil, nil
+	tc.clientCert = &cert
+	return tc
+}
+
 // WithHeader adds a request header to the test case.
 func (tc *HTTPTestCase) WithHeader(key, value string) *HTTPTestCase {
 	tc.request.Header.Set(key, value)
@@ -250,9 +648,7 @@ func (tc *HTTPTestCase) WithQueryParams(params map[string]any) *HTTPTestCase {
 
 // WithTimeout sets a timeout for the test case.
 func (tc *HTTPTestCase) WithTimeout(timeout time.Duration) *HTTPTestCase {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	tc.request = tc.request.WithContext(ctx)
-	tc.cancel = cancel
+	tc.timeout = timeout
 	return tc
 }
 
@@ -261,11 +657,92 @@ func (tc *HTTPTestCase) WithTimeout(timeout time.Duration) *HTTPTestCase {
 //
 
 // ExpectBody sets the expected HTTP response body for the test case.
+//
+// If the response has an XML Content-Type, its body is decoded and compared
+// structurally the same way as a JSON body, and an expected string is decoded
+// as an XML document. Elements are objects keyed by their child elements and
+// their attributes, prefixed with "@", whose values are strings, for example:
+//
+//	tc.ExpectBody(json.Object{"user": json.Object{"@id": "1", "name": "alice"}})
 func (tc *HTTPTestCase) ExpectBody(body any) *HTTPTestCase {
 	tc.Expectations.Body = body
 	return tc
 }
 
+// ExpectBodyBytes sets the expected HTTP response body of the test case as raw
+// bytes, for binary content such as images, PDFs, or archives. Unlike
+// ExpectBody, the body is compared byte for byte and is never interpreted as
+// JSON or text.
+func (tc *HTTPTestCase) ExpectBodyBytes(body []byte) *HTTPTestCase {
+	tc.Expectations.BodyBytes = body
+	return tc
+}
+
+// ExpectBodyJQ adds an expectation for the value output by a jq program, such
+// as ".items | length" or "[.items[].price] | add", evaluated against the
+// response body. The expected value may be a literal, a predicate, or a JSON
+// object or array matched as in ExpectBody(). If the program outputs more than
+// one value, every value must match the expected value. Numbers output by the
+// program are float64. See the jq package for the supported subset of the
+// language.
+//
+// Any number of jq expectations can be added to a test case. An invalid program
+// fails the test case.
+func (tc *HTTPTestCase) ExpectBodyJQ(program string, expected any) *HTTPTestCase {
+	compiled, err := jq.Compile(program)
+	if err != nil {
+		return tc.addDefinitionError(fmt.Errorf("invalid jq program %q: %w", program, err))
+	}
+
+	tc.Expectations.JQ = append(tc.Expectations.JQ, JQExpectation{
+		Program:  program,
+		Value:    expected,
+		compiled: compiled,
+	})
+	return tc
+}
+
+// ExpectBodyMatches adds a regular expression, either a pattern string or a
+// *regexp.Regexp, that the entire raw HTTP response body is expected to match,
+// for HTML or plain-text responses that can't be matched structurally. An
+// invalid pattern fails the test case.
+func (tc *HTTPTestCase) ExpectBodyMatches(pattern any) *HTTPTestCase {
+	var regex *regexp.Regexp
+	switch p := pattern.(type) {
+	case string:
+		var err error
+		if regex, err = regexp.Compile(p); err != nil {
+			return tc.addDefinitionError(fmt.Errorf("invalid body pattern %q: %w", p, err))
+		}
+	case *regexp.Regexp:
+		regex = p
+	default:
+		return tc.addDefinitionError(fmt.Errorf("invalid body pattern of type %T, expected a string or *regexp.Regexp", pattern))
+	}
+
+	tc.Expectations.BodyPatterns = append(tc.Expectations.BodyPatterns, regex)
+	return tc
+}
+
+// ExpectNoBody causes the test case to fail if the HTTP response has a body,
+// such as for "204 No Content" responses.
+func (tc *HTTPTestCase) ExpectNoBody() *HTTPTestCase {
+	tc.Expectations.NoBody = true
+	return tc
+}
+
+// ExpectBodySHA256 sets the expected hex-encoded SHA-256 checksum of the HTTP
+// response body, for binary content too large to keep alongside the test. An
+// invalid checksum fails the test case.
+func (tc *HTTPTestCase) ExpectBodySHA256(checksum string) *HTTPTestCase {
+	if sum, err := hex.DecodeString(checksum); err != nil || len(sum) != sha256.Size {
+		return tc.addDefinitionError(fmt.Errorf("invalid SHA-256 checksum %q", checksum))
+	}
+
+	tc.Expectations.BodySHA256 = strings.ToLower(checksum)
+	return tc
+}
+
 // ExpectExactBody sets the expected HTTP response body for the test case.
 //
 // Unlike ExpectBody, ExpectExactBody willl cause the test case to fail
@@ -273,8 +750,17 @@ func (tc *HTTPTestCase) ExpectBody(body any) *HTTPTestCase {
 // additional fields or values not present in the expected JSON content.
 //
 // For non-JSON values, ExpectExactBody behaves identically to ExpectBody.
-func (tc *HTTPTestCase) ExpectExactBody(body any) *HTTPTestCase {
+//
+// Values that are expected to vary between responses can be excluded from
+// matching using Ignore(), for example:
+//
+//	tc.ExpectExactBody(body, mt.Ignore("data.*.updated_at", "meta.request_id"))
+func (tc *HTTPTestCase) ExpectExactBody(body any, ignore ...IgnorePaths) *HTTPTestCase {
 	tc.Expectations.WantExactJSONBody = true
+	for _, paths := range ignore {
+		tc.Expectations.IgnoredBodyPaths = append(tc.Expectations.IgnoredBodyPaths, paths...)
+	}
+
 	return tc.ExpectBody(body)
 }
 
@@ -306,6 +792,22 @@ func (tc *HTTPTestCase) ExpectHeaders(headers http.Header) *HTTPTestCase {
 	return tc
 }
 
+// ExpectContentType sets the expected media type of the HTTP response's
+// Content-Type header. Unlike ExpectHeader, media types are compared
+// case-insensitively, and parameters of the response's media type, such as
+// "; charset=utf-8", are ignored unless they are part of the expected value.
+func (tc *HTTPTestCase) ExpectContentType(contentType string) *HTTPTestCase {
+	tc.Expectations.ContentType = contentType
+	return tc
+}
+
+// ExpectNoHeader adds HTTP headers that are expected not to be present in the
+// response, for verifying that internal headers such as "X-Debug" don't leak.
+func (tc *HTTPTestCase) ExpectNoHeader(names ...string) *HTTPTestCase {
+	tc.Expectations.AbsentHeaders = append(tc.Expectations.AbsentHeaders, names...)
+	return tc
+}
+
 // ExpectGolden causes the test case to load its HTTP response expectations
 // from a golden file.
 func (tc *HTTPTestCase) ExpectGolden(path string) *HTTPTestCase {
@@ -313,24 +815,91 @@ func (tc *HTTPTestCase) ExpectGolden(path string) *HTTPTestCase {
 	return tc
 }
 
+// ExpectInvariant adds a predicate that is evaluated against the entire decoded
+// response body, for asserting invariants that span multiple fields, for
+// example:
+//
+//	tc.ExpectInvariant(expect.SumEquals("items[*].amount", "total"))
+//
+// Invariants are evaluated in addition to any expected body.
+func (tc *HTTPTestCase) ExpectInvariant(predicate expect.Predicate) *HTTPTestCase {
+	tc.Expectations.Invariants = append(tc.Expectations.Invariants, predicate)
+	return tc
+}
+
+// ExpectJSONPath adds an expectation for the value at a JSON path within the
+// response body, such as "$.items[0].id" or "data.*.status", without specifying
+// the rest of the body. The expected value may be a literal, a predicate, or a
+// JSON object or array matched as in ExpectBody(). If the path contains
+// wildcards, every value it matches must match the expected value.
+//
+// Any number of JSON path expectations can be added to a test case.
+func (tc *HTTPTestCase) ExpectJSONPath(path string, expected any) *HTTPTestCase {
+	tc.Expectations.JSONPaths = append(tc.Expectations.JSONPaths, JSONPathExpectation{
+		Path:  path,
+		Value: expected,
+	})
+	return tc
+}
+
+// ExpectLike sets the expected HTTP response body for the test case to the
+// expected JSON value derived from a Go value, such as an instance of a domain
+// struct. See expect.Like() for how the expected value is derived.
+func (tc *HTTPTestCase) ExpectLike(v any) *HTTPTestCase {
+	return tc.ExpectBody(expect.Like(v))
+}
+
+// ExpectSchema sets the JSON Schema the HTTP response body is expected to be
+// valid against, for validating the structure of payloads too large to match
+// exactly. The schema is the JSON text of the schema as a string, []byte, or
+// io.Reader, or a decoded schema document. See expect.Schema() for the
+// supported keywords. A schema that can't be read fails the test case.
+func (tc *HTTPTestCase) ExpectSchema(schema any) *HTTPTestCase {
+	if r, ok := schema.(io.Reader); ok {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return tc.addDefinitionError(fmt.Errorf("failed to read schema: %w", err))
+		}
+		schema = b
+	}
+
+	tc.Expectations.Schema = schema
+	return tc
+}
+
 // ExpectStatus sets the expected HTTP status code for the test case.
 func (tc *HTTPTestCase) ExpectStatus(status int) *HTTPTestCase {
 	tc.Expectations.Status = status
 	return tc
 }
 
+// ExpectStatusNot adds HTTP status codes that the response is expected not to
+// have, such as 500, for test cases where any other status is acceptable.
+func (tc *HTTPTestCase) ExpectStatusNot(statuses ...int) *HTTPTestCase {
+	tc.Expectations.UnwantedStatuses = append(tc.Expectations.UnwantedStatuses, statuses...)
+	return tc
+}
+
+// ExpectStatusText sets the expected reason phrase of the HTTP response's status
+// line, such as "Created", for clients that depend on the reason phrase rather
+// than the status code.
+func (tc *HTTPTestCase) ExpectStatusText(text string) *HTTPTestCase {
+	tc.Expectations.StatusText = text
+	return tc
+}
+
 // Validate ensures that the test case is valid can can be run.
 func (tc *HTTPTestCase) Validate() error {
+	if len(tc.definitionErrors) > 0 {
+		return tc.definitionErrors[0]
+	}
+
 	if tc.tctx.BaseURL != "" && tc.tctx.Handler != nil {
 		return fmt.Errorf("HTTP test context %q cannot specify both a base URL and handler", tc.tctx.BaseURL)
 	}
 
 	if tc.GoldenFilePath != "" {
-		path := tc.GoldenFilePath
-		if !filepath.IsAbs(path) {
-			path = filepath.Join(cfg.WorkingDir, path)
-		}
-
+		path := tc.goldenPath()
 		golden, err := golden.LoadFile(path)
 		if err != nil {
 			return err
@@ -341,11 +910,117 @@ func (tc *HTTPTestCase) Validate() error {
 		tc.Expectations.Body = golden.WantBody
 		tc.Expectations.WantExactHeaders = golden.MatchHeadersExactly
 		tc.Expectations.WantExactJSONBody = golden.MatchBodyJSONExactly
+		tc.Expectations.BodyNormalization = tc.goldenNormalization()
+		tc.Expectations.GoldenFile = path
 	}
 
 	return nil
 }
 
+// addDefinitionError records an error in the definition of the test case, which
+// fails the test case when it's executed rather than aborting the test run, and
+// returns the test case.
+func (tc *HTTPTestCase) addDefinitionError(err error) *HTTPTestCase {
+	tc.definitionErrors = append(tc.definitionErrors, err)
+	return tc
+}
+
+// goldenNormalization returns the normalization applied when comparing the
+// response body to the body of a golden file.
+func (tc *HTTPTestCase) goldenNormalization() golden.Normalization {
+	if tc.tctx.GoldenNormalization != nil {
+		return *tc.tctx.GoldenNormalization
+	}
+
+	return golden.DefaultNormalization
+}
+
+// useRequest sets the underlying HTTP request of the test case.
+func (tc *HTTPTestCase) useRequest(req *http.Request) {
+	tc.request = req
+	tc.ctx = req.Context()
+	tc.path = req.URL.Path
+	tc.rawQuery = req.URL.RawQuery
+}
+
+// httpClient returns the HTTP client used to execute the test case's request.
+func (tc *HTTPTestCase) httpClient() (*http.Client, error) {
+	if tc.clientCert == nil && tc.jar == nil {
+		return tc.tctx.Client, nil
+	}
+
+	client := *tc.tctx.Client
+	if tc.jar != nil {
+		client.Jar = tc.jar
+	}
+
+	if tc.clientCert != nil {
+		transport, ok := client.Transport.(*http.Transport)
+		if !ok {
+			if client.Transport != nil {
+				return nil, fmt.Errorf("client certificate requires an *http.Transport, got %T", client.Transport)
+			}
+			transport = http.DefaultTransport.(*http.Transport)
+		}
+
+		client.Transport = tc.clientCertTransport(transport)
+	}
+
+	return &client, nil
+}
+
+// clientCertTransport returns a transport presenting the test case's client
+// certificate, cloned from base. The clone is reused by later executions of the
+// test case, so that its connections are too.
+func (tc *HTTPTestCase) clientCertTransport(base *http.Transport) *http.Transport {
+	if tc.certTransport != nil && tc.certTransportBase == base {
+		return tc.certTransport
+	}
+
+	tc.closeIdleConnections()
+	transport := base.Clone()
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	transport.TLSClientConfig.Certificates = []tls.Certificate{*tc.clientCert}
+	tc.certTransport, tc.certTransportBase = transport, base
+	return transport
+}
+
+// An idleConnectionCloser is a test case that holds connections of its own,
+// which are closed by the test runner once the test run finishes.
+type idleConnectionCloser interface {
+	closeIdleConnections()
+}
+
+func (tc *HTTPTestCase) closeIdleConnections() {
+	if tc.certTransport != nil {
+		tc.certTransport.CloseIdleConnections()
+	}
+}
+
+// peerCertificateState creates a TLS connection state presenting cert as the
+// peer's certificate, as a TLS-terminating server would see it.
+func peerCertificateState(cert *tls.Certificate) (*tls.ConnectionState, error) {
+	leaf := cert.Leaf
+	if leaf == nil {
+		if len(cert.Certificate) == 0 {
+			return nil, fmt.Errorf("client certificate contains no certificate data")
+		}
+
+		var err error
+		leaf, err = x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+	}
+
+	return &tls.ConnectionState{
+		HandshakeComplete: true,
+		PeerCertificates:  []*x509.Certificate{leaf},
+	}, nil
+}
+
 type jsonTestCase struct {
 	Headers      http.Header              `json:"headers,omitempty"`
 	Body         any                      `json:"body,omitempty"`
@@ -354,10 +1029,12 @@ type jsonTestCase struct {
 
 type jsonTestCaseExpectations struct {
 	Status            int         `json:"status,omitempty"`
+	StatusText        string      `json:"status_text,omitempty"`
 	Headers           http.Header `json:"headers,omitempty"`
 	Body              any         `json:"body,omitempty"`
 	WantExactHeaders  bool        `json:"want_exact_headers"`
 	WantExactJSONBody bool        `json:"want_exact_json_body"`
+	IgnoredBodyPaths  []string    `json:"ignored_body_paths,omitempty"`
 }
 
 // MarshalJSON customizes the JSON representaton of the test case.
@@ -367,10 +1044,12 @@ func (tc HTTPTestCase) MarshalJSON() ([]byte, error) {
 		Body:    tc.request.Body,
 		Expectations: jsonTestCaseExpectations{
 			Status:            tc.Expectations.Status,
+			StatusText:        tc.Expectations.StatusText,
 			Headers:           tc.Expectations.Headers,
 			Body:              tc.Expectations.Body,
 			WantExactHeaders:  tc.Expectations.WantExactHeaders,
 			WantExactJSONBody: tc.Expectations.WantExactJSONBody,
+			IgnoredBodyPaths:  tc.Expectations.IgnoredBodyPaths,
 		},
 	}
 
@@ -1,11 +1,18 @@
 package mt
 
 import (
+	"bytes"
+	"crypto/tls"
 	"fmt"
+	"mime"
 	"net/http"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jefflinse/melatonin/expect"
+	mtjson "github.com/jefflinse/melatonin/json"
 )
 
 // HTTPTestCaseResult represents the result of running a single test case.
@@ -13,14 +20,55 @@ type HTTPTestCaseResult struct {
 	// Status is the HTTP status code returned in the response.
 	Status int `json:"status"`
 
+	// StatusText is the reason phrase of the response's status line, such as
+	// "Created".
+	StatusText string `json:"status_text,omitempty"`
+
 	// Headers is the HTTP response headers.
 	Headers http.Header `json:"headers"`
 
 	// Body is the HTTP response body.
 	Body []byte `json:"body"`
 
+	// BodyFile is the file the HTTP response body was streamed to in place of
+	// Body, if any. See WithResponseToFile().
+	BodyFile *ResponseFile `json:"body_file,omitempty"`
+
+	// Proto is the protocol version of the response, such as "HTTP/2.0".
+	Proto string `json:"proto"`
+
+	// TLS is the state of the TLS connection the response was received on, or
+	// nil if the connection was not encrypted.
+	TLS *tls.ConnectionState `json:"-"`
+
+	// Trailers is the HTTP response trailers.
+	Trailers http.Header `json:"trailers,omitempty"`
+
+	// Memoized indicates whether the response was reused from an identical
+	// earlier request rather than received. See WithMemoization().
+	Memoized bool `json:"memoized,omitempty"`
+
+	// Timings are the durations of the phases of the test case's execution.
+	Timings PhaseTimings `json:"timings"`
+
+	// RetryAfterWaits are the waits on Retry-After headers of throttled
+	// responses before the request was sent again. See WithRetryAfter().
+	RetryAfterWaits []time.Duration `json:"retry_after_waits,omitempty"`
+
+	// Attachments are the content attached to the result. See Attach().
+	Attachments []Attachment `json:"attachments,omitempty"`
+
+	// Configuration are the settings that affected the execution of the test
+	// case, such as its timeout and the options of its context.
+	Configuration []ConfigurationSetting `json:"configuration,omitempty"`
+
 	testCase *HTTPTestCase
 	failures []error
+	warnings []error
+
+	// expectations the response is validated against, which include those of
+	// the test case's resolved feature flag variants
+	expectations expectatons
 }
 
 // Failures returns a list of test case failures.
@@ -33,6 +81,17 @@ func (r *HTTPTestCaseResult) TestCase() TestCase {
 	return r.testCase
 }
 
+// Warnings returns a list of problems with the response that were reported
+// without causing the test case to fail.
+func (r *HTTPTestCaseResult) Warnings() []error {
+	return r.warnings
+}
+
+func (r *HTTPTestCaseResult) addWarnings(errs ...error) *HTTPTestCaseResult {
+	r.warnings = append(r.warnings, errs...)
+	return r
+}
+
 func (r *HTTPTestCaseResult) addFailures(errs ...error) *HTTPTestCaseResult {
 	if len(errs) == 0 {
 		return r
@@ -42,29 +101,278 @@ func (r *HTTPTestCaseResult) addFailures(errs ...error) *HTTPTestCaseResult {
 	return r
 }
 
+// setResponse records the details of an HTTP response whose body has been read.
+func (r *HTTPTestCaseResult) setResponse(resp *http.Response, body []byte) {
+	r.Status = resp.StatusCode
+	r.StatusText = strings.TrimSpace(strings.TrimPrefix(resp.Status, strconv.Itoa(resp.StatusCode)))
+	r.Headers = resp.Header
+	r.Body = body
+	r.Proto = resp.Proto
+	r.TLS = resp.TLS
+	if len(resp.Trailer) > 0 {
+		r.Trailers = resp.Trailer
+	}
+}
+
 func (r *HTTPTestCaseResult) validateExpectations() {
 	tc := r.TestCase().(*HTTPTestCase)
-	if tc.Expectations.Status != 0 {
-		if err := compareStatus(tc.Expectations.Status, r.Status); err != nil {
-			r.addFailures(err)
+	failures, warnings := r.validate(r.expectations)
+	r.addFailures(failures...).addWarnings(warnings...)
+	if len(failures) > 0 && tc.failFast() {
+		return
+	}
+
+	for _, conditional := range tc.conditionals {
+		if conditional.condition(r) {
+			expectations, err := tc.collectExpectations(expectatons{}, conditional.expectations...)
+			if err != nil {
+				r.addFailures(err)
+				continue
+			}
+
+			failures, warnings := r.validate(expectations)
+			r.addFailures(failures...).addWarnings(warnings...)
 		}
 	}
 
-	if tc.Expectations.Headers != nil {
-		if errs := compareHeaders(tc.Expectations.Headers, r.Headers); len(errs) > 0 {
-			r.addFailures(errs...)
+	if len(tc.warnExpectations) > 0 {
+		expectations, err := tc.collectExpectations(expectatons{}, tc.warnExpectations...)
+		if err != nil {
+			r.addFailures(err)
+		} else {
+			failures, warnings := r.validate(expectations)
+			r.addWarnings(failures...).addWarnings(warnings...)
 		}
 	}
 
-	if tc.Expectations.Body != nil {
-		body := toInterface(r.Body)
-		for _, err := range expect.CompareValues(tc.Expectations.Body, body, tc.Expectations.WantExactJSONBody) {
-			err.PushField("") // enables a leading dot in the error message field stack string
+	for _, outcomes := range tc.outcomes {
+		if err := r.validateOutcomes(outcomes); err != nil {
 			r.addFailures(err)
 		}
 	}
 }
 
+// validate compares the result against a set of expectations and returns
+// any failures and warnings.
+func (r *HTTPTestCaseResult) validate(expectations expectatons) ([]error, []error) {
+	var errs, warnings []error
+	for _, category := range ExpectationOrder() {
+		failures, categoryWarnings := r.validateCategory(category, expectations)
+		errs = append(errs, failures...)
+		warnings = append(warnings, categoryWarnings...)
+		if len(failures) > 0 && r.testCase.failFast() {
+			break
+		}
+	}
+
+	return errs, warnings
+}
+
+// validateCategory evaluates the expectations of a single category.
+func (r *HTTPTestCaseResult) validateCategory(category ExpectationCategory, expectations expectatons) ([]error, []error) {
+	var errs, warnings []error
+	switch category {
+	case StatusExpectations:
+		if expectations.Status != 0 {
+			if err := compareStatus(expectations.Status, r.Status); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		for _, status := range expectations.UnwantedStatuses {
+			if r.Status == status {
+				errs = append(errs, fmt.Errorf("expected status other than %d, got %d", status, r.Status))
+			}
+		}
+
+		if expectations.StatusText != "" && expectations.StatusText != r.StatusText {
+			errs = append(errs, fmt.Errorf("expected status text %q, got %q", expectations.StatusText, r.StatusText))
+		}
+
+	case HeaderExpectations:
+		if expectations.Headers != nil {
+			errs = append(errs, compareHeaders(expectations.Headers, r.Headers)...)
+		}
+
+		for _, name := range expectations.AbsentHeaders {
+			if values := r.Headers.Values(name); len(values) > 0 {
+				errs = append(errs, fmt.Errorf("expected no header %q, got %q", http.CanonicalHeaderKey(name), values))
+			}
+		}
+
+		if expectations.ContentType != "" {
+			if actual := r.Headers.Get("Content-Type"); !matchContentType(expectations.ContentType, actual) {
+				errs = append(errs, fmt.Errorf("expected content type %q, got %q", expectations.ContentType, actual))
+			}
+		}
+
+		if expectations.RateLimit != nil {
+			errs = append(errs, r.validateRateLimit(*expectations.RateLimit)...)
+		}
+
+	case TransportExpectations:
+		errs = append(errs, r.validateTransport(expectations)...)
+
+	case BodyExpectations:
+		if expectations.Body != nil {
+			contentType := r.Headers.Get("Content-Type")
+			if expectations.BodyCodec != "" {
+				contentType = expectations.BodyCodec
+			}
+
+			expected, body := expectations.Body, decodeBody(contentType, expectations.BodyNormalization.Apply(r.Body))
+			if s, ok := expected.(string); ok {
+				expected = string(expectations.BodyNormalization.Apply([]byte(s)))
+			}
+			if isXMLContentType(contentType) {
+				expected, body = r.xmlBodies(expected, body)
+			}
+			if len(expectations.IgnoredBodyPaths) > 0 {
+				expected = mtjson.Without(expected, expectations.IgnoredBodyPaths...)
+				body = mtjson.Without(body, expectations.IgnoredBodyPaths...)
+			}
+
+			comparison := expect.Compare(expected, body, r.testCase.compareOptions(expectations.WantExactJSONBody))
+			if len(comparison.Failures) > 0 && expectations.GoldenFile != "" {
+				normalized := expectations.BodyNormalization.Apply(r.Body)
+				if diff := goldenDiff(expectations.GoldenFile, expected, body, normalized, expectations.WantExactJSONBody); diff != nil {
+					comparison.Failures = nil
+					errs = append(errs, diff)
+				}
+			}
+
+			if len(comparison.Failures) > 0 && r.testCase.bodyDiff() {
+				if diff := bodyDiff(expected, body, r.testCase.compareOptions(expectations.WantExactJSONBody)); diff != nil {
+					comparison.Failures = nil
+					errs = append(errs, diff)
+				}
+			}
+
+			for _, err := range comparison.Failures {
+				err.PushField("") // enables a leading dot in the error message field stack string
+				errs = append(errs, err)
+			}
+
+			for _, err := range comparison.Warnings {
+				err.PushField("")
+				warnings = append(warnings, err)
+			}
+		}
+
+		if expectations.BodyBytes != nil {
+			if err := compareBodyBytes(expectations.BodyBytes, r.Body); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if expectations.NoBody && r.bodySize() > 0 {
+			errs = append(errs, fmt.Errorf("expected no body, got %d bytes: %q", r.bodySize(), bodyExcerpt(r.Body)))
+		}
+
+		for _, pattern := range expectations.BodyPatterns {
+			if !pattern.Match(r.Body) {
+				errs = append(errs, fmt.Errorf("expected body to match pattern %q, got %q", pattern.String(), bodyExcerpt(r.Body)))
+			}
+		}
+
+		if expectations.BodySHA256 != "" {
+			if actual := r.bodySHA256(); actual != expectations.BodySHA256 {
+				errs = append(errs, fmt.Errorf("expected body SHA-256 %s, got %s", expectations.BodySHA256, actual))
+			}
+		}
+
+		if expectations.BodySize != nil {
+			if actual := r.bodySize(); actual != *expectations.BodySize {
+				errs = append(errs, fmt.Errorf("expected body of %d bytes, got %d bytes", *expectations.BodySize, actual))
+			}
+		}
+
+		for _, expected := range expectations.BodyRanges {
+			if err := r.validateBodyRange(expected); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if expectations.Schema != nil {
+			violations, err := expect.SchemaViolations(expectations.Schema, r.body())
+			if err != nil {
+				errs = append(errs, fmt.Errorf("body schema: %w", err))
+			}
+
+			for _, err := range violations {
+				err.PushField("")
+				errs = append(errs, err)
+			}
+		}
+
+		for _, expectation := range expectations.JSONPaths {
+			errs = append(errs, r.validateJSONPath(expectation)...)
+		}
+
+		for _, expectation := range expectations.JQ {
+			errs = append(errs, r.validateJQ(expectation)...)
+		}
+
+		for _, invariant := range expectations.Invariants {
+			if err := invariant(r.body()); err != nil {
+				errs = append(errs, fmt.Errorf("body invariant: %w", err))
+			}
+		}
+	}
+
+	for i := range errs {
+		errs[i] = &ExpectationError{Category: category, Err: errs[i]}
+	}
+
+	return errs, warnings
+}
+
+// xmlBodies decodes an XML response body, and an expected body given as an XML
+// document, so that they are compared structurally. Bodies that are not valid
+// XML are left as they are.
+func (r *HTTPTestCaseResult) xmlBodies(expected, body any) (any, any) {
+	if decoded, err := decodeXML(r.Body); err == nil {
+		body = decoded
+	}
+
+	if s, ok := expected.(string); ok {
+		if decoded, err := decodeXML([]byte(s)); err == nil {
+			expected = decoded
+		}
+	}
+
+	return expected, body
+}
+
+// bodyExcerpt returns the beginning of a body for failure messages.
+func bodyExcerpt(body []byte) string {
+	const maxExcerptLength = 200
+	if len(body) <= maxExcerptLength {
+		return string(body)
+	}
+
+	return string(body[:maxExcerptLength]) + "..."
+}
+
+// compareBodyBytes compares an expected body against an actual body byte for
+// byte, reporting the offset of the first difference.
+func compareBodyBytes(expected, actual []byte) error {
+	if bytes.Equal(expected, actual) {
+		return nil
+	}
+
+	offset := 0
+	for offset < len(expected) && offset < len(actual) && expected[offset] == actual[offset] {
+		offset++
+	}
+
+	if offset == len(expected) || offset == len(actual) {
+		return fmt.Errorf("expected body of %d bytes, got %d bytes", len(expected), len(actual))
+	}
+
+	return fmt.Errorf("expected body byte 0x%02x at offset %d, got 0x%02x", expected[offset], offset, actual[offset])
+}
+
 // Compares a set of expected headers against a set of actual headers,
 func compareHeaders(expected http.Header, actual http.Header) []error {
 	var errs []error
@@ -103,3 +411,33 @@ func compareStatus(expected, actual int) error {
 	}
 	return nil
 }
+
+// matchContentType returns true if the actual Content-Type header value has the
+// expected media type and the parameters of the expected value, if any.
+func matchContentType(expected, actual string) bool {
+	expectedType, expectedParams, err := mime.ParseMediaType(expected)
+	if err != nil {
+		return false
+	}
+
+	actualType, actualParams, err := mime.ParseMediaType(actual)
+	if err != nil || expectedType != actualType {
+		return false
+	}
+
+	for name, value := range expectedParams {
+		if !strings.EqualFold(actualParams[name], value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// stabilized returns a copy of the result without its phase timings, for stable
+// JSON output.
+func (r *HTTPTestCaseResult) stabilized() TestResult {
+	stable := *r
+	stable.Timings = PhaseTimings{}
+	return &stable
+}
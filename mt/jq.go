@@ -0,0 +1,65 @@
+package mt
+
+import (
+	"fmt"
+
+	"github.com/jefflinse/melatonin/expect"
+	"github.com/jefflinse/melatonin/jq"
+)
+
+// A JQExpectation is an expected value output by a jq program evaluated against
+// a response body. See ExpectBodyJQ().
+type JQExpectation struct {
+	Program string `json:"program"`
+	Value   any    `json:"value"`
+
+	compiled *jq.Program
+}
+
+// validateJQ returns a failure for each value output by the expectation's
+// program that does not match the expected value.
+func (r *HTTPTestCaseResult) validateJQ(expectation JQExpectation) []error {
+	program := expectation.compiled
+	if program == nil {
+		var err error
+		if program, err = jq.Compile(expectation.Program); err != nil {
+			return []error{err}
+		}
+	}
+
+	values, err := program.Run(r.body())
+	if err != nil {
+		return []error{fmt.Errorf("%s: %w", expectation.Program, err)}
+	}
+
+	if len(values) == 0 {
+		return []error{fmt.Errorf("%s: expected %+v, got nothing", expectation.Program, expectation.Value)}
+	}
+
+	expected := normalizeInt(expectation.Value)
+
+	var errs []error
+	for _, value := range values {
+		comparison := expect.Compare(expected, value, r.testCase.compareOptions(false))
+		for _, err := range comparison.Failures {
+			err.PushField(expectation.Program)
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// normalizeInt converts an expected integer to an int64. The body comparison
+// matches int64 but not other integer types, which are the natural way to write
+// the expected value of an aggregate or a header.
+func normalizeInt(expected any) any {
+	switch v := expected.(type) {
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	}
+
+	return expected
+}
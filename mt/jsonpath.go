@@ -0,0 +1,45 @@
+package mt
+
+import (
+	"fmt"
+
+	"github.com/jefflinse/melatonin/expect"
+	mtjson "github.com/jefflinse/melatonin/json"
+)
+
+// A JSONPathExpectation is an expected value at a JSON path within a response
+// body. See ExpectJSONPath().
+type JSONPathExpectation struct {
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+// validateJSONPath returns a failure for each value at the expectation's path
+// that does not match the expected value.
+func (r *HTTPTestCaseResult) validateJSONPath(expectation JSONPathExpectation) []error {
+	values := mtjson.Lookup(r.body(), expectation.Path)
+	if len(values) == 0 {
+		return []error{fmt.Errorf("%s: expected %+v, got nothing", expectation.Path, expectation.Value)}
+	}
+
+	// the body comparison matches int64 but not other integer types, which are
+	// the natural way to write the expected value of a single field
+	expected := expectation.Value
+	switch v := expected.(type) {
+	case int:
+		expected = int64(v)
+	case int32:
+		expected = int64(v)
+	}
+
+	var errs []error
+	for _, value := range values {
+		comparison := expect.Compare(expected, value, r.testCase.compareOptions(false))
+		for _, err := range comparison.Failures {
+			err.PushField(expectation.Path)
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,148 @@
+package mt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// portForwardPattern matches the line kubectl prints once a port-forward is
+// established, e.g. "Forwarding from 127.0.0.1:54321 -> 8080".
+var portForwardPattern = regexp.MustCompile(`Forwarding from 127\.0\.0\.1:(\d+) ->`)
+
+// A KubeService identifies a port of a Kubernetes Service to target with tests.
+type KubeService struct {
+	// Namespace is the namespace of the Service. Default is "default".
+	Namespace string
+
+	// Name is the name of the Service.
+	Name string
+
+	// Port is the port of the Service to target.
+	Port int
+
+	// Scheme is the URL scheme used to reach the Service. Default is "http".
+	Scheme string
+}
+
+// A PortForward is a kubectl port-forward to a Kubernetes Service managed by
+// melatonin. Close it when done testing.
+type PortForward struct {
+	// URL is the base URL of the forwarded port on the local host.
+	URL string
+
+	cmd *exec.Cmd
+}
+
+// Close stops the port-forward.
+func (pf *PortForward) Close() error {
+	if pf.cmd == nil || pf.cmd.Process == nil {
+		return nil
+	}
+
+	if err := pf.cmd.Process.Kill(); err != nil {
+		return err
+	}
+
+	pf.cmd.Wait()
+	return nil
+}
+
+// ClusterURL returns the in-cluster base URL of the Service, resolvable only from
+// within the cluster.
+func (s KubeService) ClusterURL() string {
+	return fmt.Sprintf("%s://%s.%s.svc.cluster.local:%d", s.scheme(), s.Name, s.namespace(), s.Port)
+}
+
+// PortForward starts a kubectl port-forward to the Service on a random local port
+// and waits up to the given timeout for it to be established.
+func (s KubeService) PortForward(timeout time.Duration) (*PortForward, error) {
+	cmd := exec.Command("kubectl", "port-forward",
+		"--namespace", s.namespace(),
+		fmt.Sprintf("service/%s", s.Name),
+		fmt.Sprintf(":%d", s.Port))
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stderr := &strings.Builder{}
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("kubectl port-forward: %w", err)
+	}
+
+	pf := &PortForward{cmd: cmd}
+	ports := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			if match := portForwardPattern.FindStringSubmatch(scanner.Text()); match != nil {
+				ports <- match[1]
+				break
+			}
+		}
+
+		// keep draining output so kubectl never blocks writing to it
+		io.Copy(io.Discard, stdout)
+		close(ports)
+	}()
+
+	select {
+	case port, ok := <-ports:
+		if !ok {
+			pf.Close()
+			return nil, fmt.Errorf("kubectl port-forward to %s/%s exited: %s", s.namespace(), s.Name, strings.TrimSpace(stderr.String()))
+		}
+
+		pf.URL = fmt.Sprintf("%s://127.0.0.1:%s", s.scheme(), port)
+		return pf, nil
+
+	case <-time.After(timeout):
+		pf.Close()
+		return nil, fmt.Errorf("timed out waiting for kubectl port-forward to %s/%s", s.namespace(), s.Name)
+	}
+}
+
+// NewKubeServiceContext creates a new HTTPTestContext for creating tests that
+// target a Kubernetes Service.
+//
+// When running inside a cluster, the Service's in-cluster URL is targeted
+// directly. Otherwise, a port-forward to the Service is started, which must be
+// closed by the caller when done testing. The returned PortForward is nil when
+// no port-forward was needed.
+func NewKubeServiceContext(service KubeService) (*HTTPTestContext, *PortForward, error) {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return NewURLContext(service.ClusterURL()), nil, nil
+	}
+
+	pf, err := service.PortForward(30 * time.Second)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return NewURLContext(pf.URL), pf, nil
+}
+
+func (s KubeService) namespace() string {
+	if s.Namespace == "" {
+		return "default"
+	}
+
+	return s.Namespace
+}
+
+func (s KubeService) scheme() string {
+	if s.Scheme == "" {
+		return "http"
+	}
+
+	return s.Scheme
+}
@@ -0,0 +1,180 @@
+package mt
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// A Diagnostic describes a suspicious test case definition found by Lint().
+type Diagnostic struct {
+	// Test is the description of the test case.
+	Test string `json:"test"`
+
+	// Location is the location in the source where the test case was defined,
+	// if known.
+	Location string `json:"location,omitempty"`
+
+	// Rule identifies the check that produced the diagnostic, such as
+	// "no-expectations".
+	Rule string `json:"rule"`
+
+	// Message describes the problem.
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	location := ""
+	if d.Location != "" {
+		location = d.Location + ": "
+	}
+
+	return fmt.Sprintf("%s%s: %s (%s)", location, d.Test, d.Message, d.Rule)
+}
+
+// Lint inspects test cases without executing them and returns a diagnostic for
+// each suspicious definition, such as:
+//
+//   - "invalid-definition": an HTTP test defined with an invalid argument, such
+//     as a body pattern that is not a valid regular expression
+//   - "no-expectations": an HTTP test that makes no assertions about the response
+//   - "head-body": a HEAD request with expectations about the response body,
+//     which a HEAD response never has
+//   - "get-body": a GET request with a body, unless allowed by AllowGETBody()
+//   - "conflicting-body": a test expecting no response body that also has
+//     expectations about the response body
+//   - "duplicate-description": several tests with the same description
+//   - "unused-variable": a scenario variable that is bound but never used
+//
+// Lint can be run as a pre-flight step before the test run, for example:
+//
+//	if diagnostics := mt.Lint(tests...); len(diagnostics) > 0 {
+//		for _, d := range diagnostics {
+//			t.Error(d)
+//		}
+//		t.FailNow()
+//	}
+func Lint(tests ...TestCase) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	descriptions := map[string]int{}
+	for _, test := range tests {
+		descriptions[test.Description()]++
+		if descriptions[test.Description()] == 2 {
+			diagnostics = append(diagnostics, newDiagnostic(test, "duplicate-description",
+				"description is shared by more than one test"))
+		}
+
+		diagnostics = append(diagnostics, lintTestCase(test)...)
+	}
+
+	return diagnostics
+}
+
+// lintTestCase returns the diagnostics for a single test case.
+func lintTestCase(test TestCase) []Diagnostic {
+	var diagnostics []Diagnostic
+	switch tc := test.(type) {
+	case *HTTPTestCase:
+		for _, err := range tc.definitionErrors {
+			diagnostics = append(diagnostics, newDiagnostic(tc, "invalid-definition", err.Error()))
+		}
+
+		if !tc.hasExpectations() {
+			diagnostics = append(diagnostics, newDiagnostic(tc, "no-expectations",
+				"test makes no assertions about the response"))
+		}
+
+		if tc.request.Method == http.MethodHead && tc.hasBodyExpectations() {
+			diagnostics = append(diagnostics, newDiagnostic(tc, "head-body",
+				"response to a HEAD request has no body to match expectations against"))
+		}
+
+		if tc.request.Method == http.MethodGet && tc.requestBody != nil && !tc.allowGETBody {
+			diagnostics = append(diagnostics, newDiagnostic(tc, "get-body",
+				"GET request has a body, use AllowGETBody() if this is intended"))
+		}
+
+		if tc.Expectations.NoBody && tc.hasBodyExpectations() {
+			diagnostics = append(diagnostics, newDiagnostic(tc, "conflicting-body",
+				"test expects no response body but also has expectations about the body"))
+		}
+
+	case *Scenario:
+		for _, step := range tc.Steps {
+			diagnostics = append(diagnostics, lintTestCase(step)...)
+		}
+
+		// end hooks can read any variable, so no variable is known to be unused
+		if len(tc.EndHooks) == 0 {
+			for _, name := range tc.unusedVars() {
+				diagnostics = append(diagnostics, newDiagnostic(tc, "unused-variable",
+					fmt.Sprintf("scenario variable %q is bound but never used", name)))
+			}
+		}
+	}
+
+	return diagnostics
+}
+
+func newDiagnostic(test TestCase, rule, message string) Diagnostic {
+	return Diagnostic{
+		Test:     test.Description(),
+		Location: testCaseLocation(test),
+		Rule:     rule,
+		Message:  message,
+	}
+}
+
+// hasExpectations returns true if the test case makes any assertion about the
+// response.
+func (tc *HTTPTestCase) hasExpectations() bool {
+	e := tc.Expectations
+	return e.Status != 0 ||
+		len(e.UnwantedStatuses) > 0 ||
+		e.StatusText != "" ||
+		len(e.Headers) > 0 ||
+		len(e.AbsentHeaders) > 0 ||
+		e.ContentType != "" ||
+		e.RateLimit != nil ||
+		e.NoBody ||
+		len(e.Trailers) > 0 ||
+		len(e.Certificate) > 0 ||
+		e.Proto != "" ||
+		e.TLSCipherSuite != 0 ||
+		e.TLSVersion != 0 ||
+		tc.hasBodyExpectations() ||
+		tc.GoldenFilePath != "" ||
+		len(tc.conditionals) > 0 ||
+		len(tc.warnExpectations) > 0 ||
+		len(tc.outcomes) > 0 ||
+		len(tc.flagVariants) > 0
+}
+
+// hasBodyExpectations returns true if the test case makes any assertion about
+// the response body.
+func (tc *HTTPTestCase) hasBodyExpectations() bool {
+	return tc.Expectations.Body != nil ||
+		tc.Expectations.BodyBytes != nil ||
+		len(tc.Expectations.BodyPatterns) > 0 ||
+		tc.Expectations.BodySHA256 != "" ||
+		tc.Expectations.BodySize != nil ||
+		len(tc.Expectations.BodyRanges) > 0 ||
+		tc.Expectations.Schema != nil ||
+		len(tc.Expectations.JSONPaths) > 0 ||
+		len(tc.Expectations.JQ) > 0 ||
+		len(tc.Expectations.Invariants) > 0
+}
+
+// unusedVars returns the sorted names of the scenario variables that are bound
+// but never referenced.
+func (s *Scenario) unusedVars() []string {
+	var unused []string
+	for name := range s.bound {
+		if !s.referenced[name] {
+			unused = append(unused, name)
+		}
+	}
+
+	sort.Strings(unused)
+	return unused
+}
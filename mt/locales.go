@@ -0,0 +1,71 @@
+package mt
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Translations maps locales (Accept-Language values) to message keys to the
+// expected translated strings.
+type Translations map[string]map[string]string
+
+// LoadTranslations loads translations from a JSON file of the form:
+//
+//	{
+//	  "en-US": {"not_found": "Not found"},
+//	  "de-DE": {"not_found": "Nicht gefunden"}
+//	}
+func LoadTranslations(path string) (Translations, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("translations file %q: %w", path, err)
+	}
+
+	translations := Translations{}
+	if err := json.Unmarshal(b, &translations); err != nil {
+		return nil, fmt.Errorf("translations file %q: %w", path, err)
+	}
+
+	return translations, nil
+}
+
+// Locales returns the locales of the translations, sorted.
+func (t Translations) Locales() []string {
+	locales := make([]string, 0, len(t))
+	for locale := range t {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+
+	return locales
+}
+
+// ForEachLocale expands a test case across every locale in a set of
+// translations. For each locale, build is called with the locale's translation
+// of the given message key, and the resulting test case is sent with the locale
+// as its Accept-Language header.
+//
+// If a locale has no translation for the key, its test case fails.
+//
+//	mt.RunTests(mt.ForEachLocale(translations, "not_found", func(expected string) *mt.HTTPTestCase {
+//		return api.GET("/missing").
+//			ExpectStatus(404).
+//			ExpectBody(json.Object{"error": expected})
+//	})...)
+func ForEachLocale(translations Translations, key string, build func(expected string) *HTTPTestCase) []TestCase {
+	tests := []TestCase{}
+	for _, locale := range translations.Locales() {
+		expected, ok := translations[locale][key]
+		tc := build(expected).WithHeader("Accept-Language", locale)
+		tc.Desc = fmt.Sprintf("%s [%s]", tc.Description(), locale)
+		if !ok {
+			tc.addDefinitionError(fmt.Errorf("no translation of %q for locale %q", key, locale))
+		}
+
+		tests = append(tests, tc)
+	}
+
+	return tests
+}
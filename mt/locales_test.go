@@ -0,0 +1,44 @@
+package mt_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jefflinse/melatonin/mt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForEachLocale(t *testing.T) {
+	ctx := mt.NewHandlerContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Accept-Language") {
+		case "de-DE":
+			w.Write([]byte("Nicht gefunden"))
+		default:
+			w.Write([]byte("Not found"))
+		}
+	}))
+
+	translations := mt.Translations{
+		"de-DE": {"not_found": "Nicht gefunden"},
+		"en-US": {"not_found": "Not found"},
+		"fr-FR": {},
+	}
+
+	beforeCalls := 0
+	tests := mt.ForEachLocale(translations, "not_found", func(expected string) *mt.HTTPTestCase {
+		return ctx.GET("/missing").ExpectBody(expected).Before(func() error {
+			beforeCalls++
+			return nil
+		})
+	})
+
+	result := mt.NewTestRunner().WithContinueOnFailure(true).RunTests(tests...)
+	assert.Equal(t, 2, result.Passed)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, 2, beforeCalls)
+
+	failures := result.TestResults[2].TestResult.Failures()
+	if assert.Len(t, failures, 1) {
+		assert.EqualError(t, failures[0], `no translation of "not_found" for locale "fr-FR"`)
+	}
+}
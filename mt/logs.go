@@ -0,0 +1,196 @@
+package mt
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// requestIDHeaders are the headers checked, in order, for an ID correlating a
+// request with server-side log lines.
+var requestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id", "Request-Id"}
+
+// A LogQuery describes the server-side log lines of interest for a test.
+type LogQuery struct {
+	// Start and End bound the time window during which the test ran.
+	Start time.Time
+	End   time.Time
+
+	// RequestID is the ID of the test's request, if one could be determined
+	// from the request or response headers.
+	RequestID string
+}
+
+// A LogSource captures server-side log lines. When a test fails, the runner
+// queries its log source and attaches the matching lines to the test's result.
+type LogSource interface {
+	Logs(query LogQuery) ([]string, error)
+}
+
+// LogSourceFunc adapts a function to a LogSource.
+type LogSourceFunc func(query LogQuery) ([]string, error)
+
+// Logs calls f(query).
+func (f LogSourceFunc) Logs(query LogQuery) ([]string, error) {
+	return f(query)
+}
+
+// FileLogSource creates a LogSource that reads log lines from a file, such as a
+// tailed server log. Since arbitrary log lines carry no parsable timestamp, only
+// lines containing the test's request ID are returned.
+func FileLogSource(path string) LogSource {
+	return LogSourceFunc(func(query LogQuery) ([]string, error) {
+		if query.RequestID == "" {
+			return nil, nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		lines := []string{}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if strings.Contains(scanner.Text(), query.RequestID) {
+				lines = append(lines, scanner.Text())
+			}
+		}
+
+		return lines, scanner.Err()
+	})
+}
+
+// DockerLogSource creates a LogSource that captures the logs a Docker container
+// produced while a test ran, using the docker CLI. If the test's request ID is
+// known, only lines containing it are returned.
+func DockerLogSource(container string) LogSource {
+	return LogSourceFunc(func(query LogQuery) ([]string, error) {
+		out, err := exec.Command("docker", "logs",
+			"--since", query.Start.Format(time.RFC3339Nano),
+			"--until", query.End.Format(time.RFC3339Nano),
+			container).CombinedOutput()
+		if err != nil {
+			return nil, fmt.Errorf("docker logs: %w: %s", err, strings.TrimSpace(string(out)))
+		}
+
+		return filterLogLines(strings.Split(string(out), "\n"), query.RequestID), nil
+	})
+}
+
+// LokiLogSource creates a LogSource that queries a Grafana Loki server for the
+// lines matching a LogQL stream selector, such as `{app="my-service"}`, during
+// the time a test ran. If the test's request ID is known, only lines containing
+// it are returned. Queries time out after the default request timeout, so that
+// an unresponsive server doesn't stall the reporting of failures.
+func LokiLogSource(baseURL, selector string) LogSource {
+	client := &http.Client{Timeout: defaultRequestTimeout}
+	return LogSourceFunc(func(query LogQuery) ([]string, error) {
+		logQL := selector
+		if query.RequestID != "" {
+			logQL += fmt.Sprintf(" |= %q", query.RequestID)
+		}
+
+		params := url.Values{}
+		params.Set("query", logQL)
+		params.Set("start", strconv.FormatInt(query.Start.UnixNano(), 10))
+		params.Set("end", strconv.FormatInt(query.End.UnixNano(), 10))
+		params.Set("direction", "forward")
+
+		resp, err := client.Get(strings.TrimSuffix(baseURL, "/") + "/loki/api/v1/query_range?" + params.Encode())
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("loki query: unexpected status %d", resp.StatusCode)
+		}
+
+		var body struct {
+			Data struct {
+				Result []struct {
+					Values [][2]string `json:"values"`
+				} `json:"result"`
+			} `json:"data"`
+		}
+
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return nil, fmt.Errorf("loki query: %w", err)
+		}
+
+		lines := []string{}
+		for _, stream := range body.Data.Result {
+			for _, value := range stream.Values {
+				lines = append(lines, value[1])
+			}
+		}
+
+		return lines, nil
+	})
+}
+
+// WithLogSource sets the source from which server-side logs are captured for
+// failed tests, and returns the TestRunner.
+func (r *TestRunner) WithLogSource(source LogSource) *TestRunner {
+	r.LogSource = source
+	return r
+}
+
+// captureServerLogs queries the runner's log source for the log lines produced
+// while a test ran.
+func (r *TestRunner) captureServerLogs(result TestRunResult) []string {
+	lines, err := r.LogSource.Logs(LogQuery{
+		Start:     result.StartedAt.Add(-r.LogWindowPadding),
+		End:       result.EndedAt.Add(r.LogWindowPadding),
+		RequestID: requestID(result.TestResult),
+	})
+	if err != nil {
+		return []string{fmt.Sprintf("failed to capture server logs: %s", err)}
+	}
+
+	return lines
+}
+
+// requestID returns the ID correlating a test's request with server-side logs,
+// preferring the ID in the response headers over the one in the request headers.
+func requestID(result TestResult) string {
+	httpResult, ok := result.(*HTTPTestCaseResult)
+	if !ok {
+		return ""
+	}
+
+	for _, header := range requestIDHeaders {
+		if id := httpResult.Headers.Get(header); id != "" {
+			return id
+		}
+	}
+
+	for _, header := range requestIDHeaders {
+		if id := httpResult.testCase.request.Header.Get(header); id != "" {
+			return id
+		}
+	}
+
+	return ""
+}
+
+// filterLogLines returns the non-empty lines containing substr.
+func filterLogLines(lines []string, substr string) []string {
+	filtered := []string{}
+	for _, line := range lines {
+		if line != "" && strings.Contains(line, substr) {
+			filtered = append(filtered, line)
+		}
+	}
+
+	return filtered
+}
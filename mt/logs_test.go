@@ -0,0 +1,34 @@
+package mt_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jefflinse/melatonin/mt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLokiLogSource(t *testing.T) {
+	var logQL string
+	loki := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/loki/api/v1/query_range" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		logQL = r.URL.Query().Get("query")
+		w.Write([]byte(`{"data":{"result":[{"values":[["1","first abc"],["2","second abc"]]}]}}`))
+	}))
+	defer loki.Close()
+
+	source := mt.LokiLogSource(loki.URL+"/", `{app="api"}`)
+	lines, err := source.Logs(mt.LogQuery{Start: time.Now(), End: time.Now(), RequestID: "abc"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first abc", "second abc"}, lines)
+	assert.Equal(t, `{app="api"} |= "abc"`, logQL)
+
+	_, err = mt.LokiLogSource(loki.URL+"/nope", `{app="api"}`).Logs(mt.LogQuery{})
+	assert.EqualError(t, err, "loki query: unexpected status 404")
+}
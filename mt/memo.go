@@ -0,0 +1,114 @@
+package mt
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A memo holds the responses to GET requests made by the test cases of a
+// context, keyed by request.
+type memo struct {
+	mu        sync.Mutex
+	responses map[string]*HTTPTestCaseResult
+}
+
+// WithMemoization sets whether test cases created from the context reuse the
+// responses of identical GET requests, and returns the context.
+//
+// When enabled, the response to the first GET request for a given URL, set of
+// headers, and body is recorded, and any later test case making an identical
+// request is validated against the recorded response instead of sending the
+// request again. This can greatly reduce the duration of read-heavy test runs,
+// but should only be used where responses don't depend on earlier tests.
+// Individual test cases can opt out using WithoutMemoization().
+func (c *HTTPTestContext) WithMemoization(memoize bool) *HTTPTestContext {
+	c.Memoize = memoize
+	if memoize && c.memo == nil {
+		c.memo = &memo{responses: map[string]*HTTPTestCaseResult{}}
+	}
+
+	return c
+}
+
+// WithoutMemoization causes the test case to always send its request, even if
+// its context memoizes responses, and returns the test case.
+func (tc *HTTPTestCase) WithoutMemoization() *HTTPTestCase {
+	tc.noMemoize = true
+	return tc
+}
+
+// memoKey returns the key identifying the test case's request, and whether its
+// response can be memoized. Requests sent with a scenario's cookie jar are never
+// memoized, since the cookies they carry aren't part of the request yet and
+// their responses may set cookies of their own.
+func (tc *HTTPTestCase) memoKey(body []byte) (string, bool) {
+	if !tc.tctx.Memoize || tc.tctx.memo == nil || tc.noMemoize || tc.clientCert != nil || tc.jar != nil ||
+		tc.request.Method != http.MethodGet || tc.streamsBody() {
+		return "", false
+	}
+
+	names := make([]string, 0, len(tc.request.Header))
+	for name := range tc.request.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	key := &strings.Builder{}
+	key.WriteString(tc.request.URL.String())
+	for _, name := range names {
+		key.WriteString("\n" + name + ": " + strings.Join(tc.request.Header[name], ", "))
+	}
+	key.WriteString("\n\n")
+	key.Write(body)
+
+	return key.String(), true
+}
+
+// recall copies the memoized response to the test case's request into result,
+// returning true if there was one.
+func (tc *HTTPTestCase) recall(body []byte, result *HTTPTestCaseResult) bool {
+	key, ok := tc.memoKey(body)
+	if !ok {
+		return false
+	}
+
+	tc.tctx.memo.mu.Lock()
+	defer tc.tctx.memo.mu.Unlock()
+	recorded, ok := tc.tctx.memo.responses[key]
+	if !ok {
+		return false
+	}
+
+	result.Status = recorded.Status
+	result.StatusText = recorded.StatusText
+	result.Headers = recorded.Headers.Clone()
+	result.Body = append([]byte(nil), recorded.Body...)
+	result.Proto = recorded.Proto
+	result.TLS = recorded.TLS
+	result.Trailers = recorded.Trailers.Clone()
+	result.Memoized = true
+	return true
+}
+
+// remember memoizes the response to the test case's request, if it can be
+// memoized and wasn't already.
+func (tc *HTTPTestCase) remember(body []byte, result *HTTPTestCaseResult) {
+	key, ok := tc.memoKey(body)
+	if !ok || result.Memoized {
+		return
+	}
+
+	tc.tctx.memo.mu.Lock()
+	defer tc.tctx.memo.mu.Unlock()
+	tc.tctx.memo.responses[key] = &HTTPTestCaseResult{
+		Status:     result.Status,
+		StatusText: result.StatusText,
+		Headers:    result.Headers.Clone(),
+		Body:       append([]byte(nil), result.Body...),
+		Proto:      result.Proto,
+		TLS:        result.TLS,
+		Trailers:   result.Trailers.Clone(),
+	}
+}
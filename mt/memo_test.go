@@ -0,0 +1,80 @@
+package mt_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jefflinse/melatonin/mt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoization(t *testing.T) {
+	requests := 0
+	ctx := mt.NewHandlerContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("OK"))
+	})).WithMemoization(true)
+
+	result := mt.NewTestRunner().RunTests(
+		ctx.GET("/items").ExpectStatus(http.StatusOK).ExpectBody("OK"),
+		ctx.GET("/items").ExpectStatus(http.StatusOK).ExpectBody("OK"),
+		ctx.GET("/items").WithoutMemoization().ExpectStatus(http.StatusOK),
+		ctx.GET("/items").WithHeader("Accept", "text/plain").ExpectStatus(http.StatusOK),
+		ctx.POST("/items").ExpectStatus(http.StatusOK),
+		ctx.POST("/items").ExpectStatus(http.StatusOK),
+	)
+
+	assert.Equal(t, 6, result.Passed)
+	assert.Equal(t, 5, requests)
+	memoized := []bool{}
+	for _, runResult := range result.TestResults {
+		memoized = append(memoized, runResult.TestResult.(*mt.HTTPTestCaseResult).Memoized)
+	}
+	assert.Equal(t, []bool{false, true, false, false, false, false}, memoized)
+}
+
+func TestMemoizationWithSessions(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: r.URL.Query().Get("user"), Path: "/"})
+	})
+	mux.HandleFunc("/me", func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie("session"); err == nil {
+			w.Write([]byte(cookie.Value))
+		}
+	})
+	ctx := mt.NewHandlerContext(mux).WithMemoization(true)
+
+	login := func(user string) mt.TestCase {
+		return mt.NewScenario("login as "+user).AddSteps(
+			ctx.POST("/login").WithQueryParam("user", user).ExpectStatus(http.StatusOK),
+			ctx.GET("/me").ExpectStatus(http.StatusOK).ExpectBody(user),
+		)
+	}
+
+	result := mt.NewTestRunner().RunTests(login("alice"), login("bob"))
+	assert.Equal(t, 2, result.Passed)
+}
+
+func TestMemoizationWithRoles(t *testing.T) {
+	base := mt.NewHandlerContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Role")))
+	})).WithMemoization(true)
+	roles := mt.NewRoleContexts(base, map[string]mt.Credentials{
+		"admin":  func(req *http.Request) { req.Header.Set("X-Role", "admin") },
+		"viewer": func(req *http.Request) { req.Header.Set("X-Role", "viewer") },
+	})
+
+	result := mt.NewTestRunner().RunTests(
+		roles["admin"].GET("/me").ExpectBody("admin"),
+		roles["viewer"].GET("/me").ExpectBody("viewer"),
+		roles["admin"].GET("/me").ExpectBody("admin"),
+	)
+
+	assert.Equal(t, 3, result.Passed)
+	memoized := []bool{}
+	for _, runResult := range result.TestResults {
+		memoized = append(memoized, runResult.TestResult.(*mt.HTTPTestCaseResult).Memoized)
+	}
+	assert.Equal(t, []bool{false, false, true}, memoized)
+}
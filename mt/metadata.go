@@ -0,0 +1,102 @@
+package mt
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jefflinse/tablecloth"
+)
+
+// WithRunMetadata adds metadata describing the test run, such as the git SHA,
+// environment, or build ID, and returns the TestRunner. The metadata is
+// included in the header of every output format, so that reports describe the
+// code and environment they tested. Each call adds to any metadata already set.
+func (r *TestRunner) WithRunMetadata(metadata map[string]string) *TestRunner {
+	if r.RunMetadata == nil {
+		r.RunMetadata = map[string]string{}
+	}
+
+	for key, value := range metadata {
+		r.RunMetadata[key] = value
+	}
+
+	return r
+}
+
+// runMetadata returns a copy of the runner's metadata, or nil if it has none.
+func (r *TestRunner) runMetadata() map[string]string {
+	if len(r.RunMetadata) == 0 {
+		return nil
+	}
+
+	metadata := make(map[string]string, len(r.RunMetadata))
+	for key, value := range r.RunMetadata {
+		metadata[key] = value
+	}
+
+	return metadata
+}
+
+func sortedMetadataKeys(metadata map[string]string) []string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// printRunMetadata prints the metadata of a test run above its results.
+func printRunMetadata(table *tablecloth.Table, metadata map[string]string) {
+	if len(metadata) == 0 {
+		return
+	}
+
+	for _, key := range sortedMetadataKeys(metadata) {
+		printLine(table, 0, fmt.Sprintf("%s %s", faintFG(key+":"), whiteFG(metadata[key])))
+	}
+	printLine(table, 0, "")
+}
+
+// fprintTeamCityMetadata prints the metadata of a test run as a TeamCity build
+// message.
+func fprintTeamCityMetadata(w io.Writer, metadata map[string]string) {
+	if len(metadata) == 0 {
+		return
+	}
+
+	pairs := make([]string, 0, len(metadata))
+	for _, key := range sortedMetadataKeys(metadata) {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, metadata[key]))
+	}
+
+	fprintTeamCityMessage(w, "message", "text", "run metadata: "+strings.Join(pairs, ", "))
+}
+
+var propertiesEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"=", `\=`,
+	":", `\:`,
+)
+
+// writeAllureEnvironment writes the metadata of a test run to the Allure
+// environment file, which Allure displays on the overview of a report.
+func writeAllureEnvironment(dir string, metadata map[string]string) error {
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	b := &strings.Builder{}
+	for _, key := range sortedMetadataKeys(metadata) {
+		fmt.Fprintf(b, "%s=%s\n", propertiesEscaper.Replace(key), propertiesEscaper.Replace(metadata[key]))
+	}
+
+	return os.WriteFile(filepath.Join(dir, "environment.properties"), []byte(b.String()), 0644)
+}
@@ -2,6 +2,7 @@ package mt
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -23,6 +24,8 @@ var (
 	redFGBold           = color.New(color.FgHiRed, color.Bold).SprintFunc()
 	whiteFG             = color.New(color.FgWhite).SprintFunc()
 	whiteFGBold         = color.New(color.FgWhite, color.Bold).SprintFunc()
+	yellowFG            = color.New(color.FgHiYellow).SprintFunc()
+	yellowFGBold        = color.New(color.FgHiYellow, color.Bold).SprintFunc()
 	faintFG             = color.New(color.Faint).SprintFunc()
 	blueBG              = color.New(color.BgBlue, color.FgHiWhite).SprintFunc()
 )
@@ -42,7 +45,9 @@ const (
 //
 // By default, the output is formatted as a table and colors are used if possible.
 // The behavior can be controlled by setting the MELATONIN_OUTPUT environment
-// variable to "json" to produce JSON output, or "none" to disable output all together.
+// variable to "json" to produce JSON output, "teamcity" to produce TeamCity
+// service messages, "github" to follow the formatted table with GitHub Actions
+// annotations for each failure, or "none" to disable output all together.
 func PrintResults(results *GroupRunResult) {
 	FPrintResults(cfg.Stdout, results)
 }
@@ -51,11 +56,19 @@ func PrintResults(results *GroupRunResult) {
 //
 // By default, the output is formatted as a table and colors are used if possible.
 // The behavior can be controlled by setting the MELATONIN_OUTPUT environment
-// variable to "json" to produce JSON output, or "none" to disable output all together.
+// variable to "json" to produce JSON output, "teamcity" to produce TeamCity
+// service messages, "github" to follow the formatted table with GitHub Actions
+// annotations for each failure, or "none" to disable output all together.
 func FPrintResults(w io.Writer, results *GroupRunResult) {
 	switch cfg.OutputType {
 	case outputTypeJSON:
 		fprintJSONResults(w, results, false)
+	case outputTypeTeamCity:
+		FPrintTeamCityResults(w, results)
+	case outputTypeGitHub:
+		table := tablecloth.NewTable(4)
+		fprintFormattedResults(table, results, 0)
+		FPrintGitHubAnnotations(w, results)
 	default:
 		table := tablecloth.NewTable(4)
 		fprintFormattedResults(table, results, 0)
@@ -70,10 +83,16 @@ func printFormattedResults(results *GroupRunResult) {
 
 // fprintFormattedResults prints the results of a group run as a formatted table to the given io.Writer.
 func fprintFormattedResults(table *tablecloth.Table, groupResult *GroupRunResult, depth int) {
+	if depth == 0 {
+		printRunMetadata(table, groupResult.Metadata)
+	}
+
 	printGroupHeader(table, groupResult.Group.Name, depth)
 
 	for i := range groupResult.TestResults {
-		if len(groupResult.TestResults[i].TestResult.Failures()) > 0 {
+		if groupResult.TestResults[i].Suppressed != "" {
+			printTestSuppressed(table, i+1, groupResult.TestResults[i], depth)
+		} else if len(groupResult.TestResults[i].TestResult.Failures()) > 0 {
 			printTestFailure(table, i+1, groupResult.TestResults[i], depth)
 		} else {
 			printTestSuccess(table, i+1, groupResult.TestResults[i], depth)
@@ -90,14 +109,27 @@ func fprintFormattedResults(table *tablecloth.Table, groupResult *GroupRunResult
 		printLine(table, depth+1, "")
 	}
 
+	suppressed := ""
+	if groupResult.Suppressed > 0 {
+		suppressed = fmt.Sprintf(", %d suppressed", groupResult.Suppressed)
+	}
+
+	resumed := ""
+	if groupResult.Resumed > 0 {
+		resumed = fmt.Sprintf(", %d resumed", groupResult.Resumed)
+	}
+
 	printGroupFooter(table, groupResult.Group.Name, depth, fmt.Sprintf(
-		"%d passed, %d failed, %d skipped %s",
+		"%d passed, %d failed%s, %d skipped%s %s",
 		groupResult.Passed,
 		groupResult.Failed,
+		suppressed,
 		groupResult.Skipped,
+		resumed,
 		faintFG(fmt.Sprintf("in %s", groupResult.Duration.String()))))
 
 	if depth == 0 {
+		printRunSummary(table, groupResult)
 		table.Write(os.Stdout)
 	}
 }
@@ -107,32 +139,50 @@ type jsonOutputObj struct {
 }
 
 type jsonGroupRunResult struct {
-	Name     string              `json:"name"`
-	Duration time.Duration       `json:"duration"`
-	Results  []jsonTestRunResult `json:"results"`
+	Name        string               `json:"name"`
+	Metadata    map[string]string    `json:"metadata,omitempty"`
+	Duration    time.Duration        `json:"duration,omitempty"`
+	Results     []jsonTestRunResult  `json:"results"`
+	RunFailures []string             `json:"run_failures,omitempty"`
+	Leaked      []TrackedResource    `json:"leaked_resources,omitempty"`
+	Deprecated  []DeprecationNotice  `json:"deprecations,omitempty"`
+	Budget      []BudgetViolation    `json:"budget_violations,omitempty"`
+	Slower      []DurationRegression `json:"duration_regressions,omitempty"`
+	SLO         *SLOReport           `json:"slo,omitempty"`
 }
 
 type jsonTestRunResult struct {
-	Test      jsonTest      `json:"test"`
-	Result    jsonResult    `json:"result"`
-	StartedAt time.Time     `json:"started_at"`
-	EndedAt   time.Time     `json:"ended_at"`
-	Duration  time.Duration `json:"duration"`
+	Fingerprint string        `json:"fingerprint"`
+	Test        jsonTest      `json:"test"`
+	Result      jsonResult    `json:"result"`
+	Suppressed  string        `json:"suppressed,omitempty"`
+	ServerLogs  []string      `json:"server_logs,omitempty"`
+	StartedAt   *time.Time    `json:"started_at,omitempty"`
+	EndedAt     *time.Time    `json:"ended_at,omitempty"`
+	Duration    time.Duration `json:"duration,omitempty"`
 }
 
 type jsonTest struct {
 	Description string   `json:"description"`
 	Action      string   `json:"action"`
 	Target      string   `json:"target"`
+	Location    string   `json:"location,omitempty"`
+	Severity    string   `json:"severity"`
 	Data        TestCase `json:"data,omitempty"`
 }
 
 type jsonResult struct {
-	Failures []error    `json:"failures"`
+	Failures []string   `json:"failures"`
+	Warnings []string   `json:"warnings,omitempty"`
 	Data     TestResult `json:"data,omitempty"`
 }
 
 // PrintJSONResults prints the results of a group run as JSON to the given io.Writer.
+//
+// Fields are always written in the same order and map keys, such as header
+// names, are sorted. When the MELATONIN_JSON_STABLE environment variable is
+// set, the output is also indented and omits the timestamps and durations that
+// differ between runs, so that reports can be committed and diffed.
 func PrintJSONResults(results *GroupRunResult, deep bool) error {
 	return fprintJSONResults(cfg.Stdout, results, deep)
 }
@@ -140,39 +190,130 @@ func PrintJSONResults(results *GroupRunResult, deep bool) error {
 // fprintJSONResults prints the results of a group run as JSON to the given io.Writer.
 func fprintJSONResults(w io.Writer, result *GroupRunResult, deep bool) error {
 	groupResultObj := jsonGroupRunResult{
-		Name:     result.Group.Name,
-		Duration: result.Duration,
-		Results:  make([]jsonTestRunResult, len(result.TestResults)),
+		Name:       result.Group.Name,
+		Metadata:   result.Metadata,
+		Results:    make([]jsonTestRunResult, len(result.TestResults)),
+		SLO:        result.SLO,
+		Leaked:     result.LeakedResources,
+		Deprecated: result.Deprecations,
+		Budget:     result.BudgetViolations,
+		Slower:     result.DurationRegressions,
+	}
+
+	if !cfg.StableJSON {
+		groupResultObj.Duration = result.Duration
 	}
 
+	groupResultObj.RunFailures = errorStrings(result.RunFailures)
+
 	for i := range result.TestResults {
 		testRunResult := jsonTestRunResult{
+			Fingerprint: result.TestResults[i].Fingerprint,
 			Test: jsonTest{
 				Description: result.TestResults[i].TestCase.Description(),
 				Action:      result.TestResults[i].TestCase.Action(),
 				Target:      result.TestResults[i].TestCase.Target(),
+				Location:    testCaseLocation(result.TestResults[i].TestCase),
+				Severity:    testSeverity(result.TestResults[i].TestCase).String(),
 			},
 			Result: jsonResult{
-				Failures: result.TestResults[i].TestResult.Failures(),
+				Failures: errorStrings(result.TestResults[i].TestResult.Failures()),
+				Warnings: errorStrings(testWarnings(result.TestResults[i].TestResult)),
 			},
-			StartedAt: result.TestResults[i].StartedAt,
-			EndedAt:   result.TestResults[i].EndedAt,
-			Duration:  result.TestResults[i].Duration,
+			Suppressed: result.TestResults[i].Suppressed,
+			ServerLogs: result.TestResults[i].ServerLogs,
+		}
+
+		if !cfg.StableJSON {
+			testRunResult.StartedAt = &result.TestResults[i].StartedAt
+			testRunResult.EndedAt = &result.TestResults[i].EndedAt
+			testRunResult.Duration = result.TestResults[i].Duration
 		}
 
 		if deep {
 			testRunResult.Test.Data = result.TestResults[i].TestCase
 			testRunResult.Result.Data = result.TestResults[i].TestResult
+			if s, ok := testRunResult.Result.Data.(stabilizer); ok && cfg.StableJSON {
+				testRunResult.Result.Data = s.stabilized()
+			}
 		}
 
 		groupResultObj.Results[i] = testRunResult
 	}
 
-	return json.NewEncoder(w).Encode(jsonOutputObj{
+	encoder := json.NewEncoder(w)
+	if cfg.StableJSON {
+		encoder.SetIndent("", "  ")
+	}
+
+	return encoder.Encode(jsonOutputObj{
 		Groups: []jsonGroupRunResult{groupResultObj},
 	})
 }
 
+// A stabilizer is a TestResult that can omit the values that differ between
+// runs, such as timings, from its stable JSON output.
+type stabilizer interface {
+	stabilized() TestResult
+}
+
+// printRunSummary prints information about the test run as a whole.
+func printRunSummary(table *tablecloth.Table, result *GroupRunResult) {
+	if result.SLO != nil {
+		status := greenFG("met")
+		if !result.SLO.Met() {
+			status = redFGBold("missed")
+		}
+
+		table.AddLine(fmt.Sprintf("SLO %s: %.3f%% available, p99 %s %s",
+			status,
+			result.SLO.Availability*100,
+			result.SLO.LatencyP99,
+			faintFG(fmt.Sprintf("over %d requests", result.SLO.Requests))))
+
+		for _, violation := range result.SLO.Violations {
+			table.AddLine(yellowFG(fmt.Sprintf("  %s", violation)))
+		}
+	}
+
+	if len(result.BudgetViolations) > 0 {
+		table.AddLine(yellowFG(fmt.Sprintf("⚠ %d budget violations:", len(result.BudgetViolations))))
+		for _, violation := range result.BudgetViolations {
+			table.AddLine(yellowFG(fmt.Sprintf("  %s", violation)))
+		}
+	}
+
+	if len(result.DurationRegressions) > 0 {
+		table.AddLine(yellowFG(fmt.Sprintf("⚠ %d tests slower than previously:", len(result.DurationRegressions))))
+		for _, regression := range result.DurationRegressions {
+			table.AddLine(yellowFG(fmt.Sprintf("  %s", regression)))
+		}
+	}
+
+	if len(result.Deprecations) > 0 {
+		table.AddLine(yellowFG(fmt.Sprintf("⚠ %d deprecated API usages:", len(result.Deprecations))))
+		for _, notice := range result.Deprecations {
+			table.AddLine(yellowFG(fmt.Sprintf("  %s", notice.summary())))
+		}
+	}
+
+	for _, resource := range result.LeakedResources {
+		status := "leaked"
+		if resource.Swept {
+			status = "swept"
+		} else if resource.SweepError != "" {
+			status = fmt.Sprintf("sweep failed: %s", resource.SweepError)
+		}
+
+		table.AddLine(yellowFG(fmt.Sprintf("⚠ resource %s %s", resource.Path, faintFG(
+			fmt.Sprintf("(%s, created by %q)", status, resource.CreatedBy)))))
+	}
+
+	for _, err := range result.RunFailures {
+		table.AddLine(redFG(fmt.Sprintf("✘ %s", err)))
+	}
+}
+
 func printGroupHeader(table *tablecloth.Table, groupName string, depth int) {
 	if groupName == "" {
 		return
@@ -220,6 +361,30 @@ func printTestSuccess(table *tablecloth.Table, testNum int, result TestRunResult
 			},
 		},
 	)
+
+	printTestWarnings(table, result, depth)
+	printTestConfiguration(table, result, depth)
+}
+
+// printTestConfiguration prints the effective configuration of a test, if
+// verbose output is enabled.
+func printTestConfiguration(table *tablecloth.Table, result TestRunResult, depth int) {
+	httpResult, ok := result.TestResult.(*HTTPTestCaseResult)
+	if !cfg.Verbose || !ok || len(httpResult.Configuration) == 0 {
+		return
+	}
+
+	printLine(table, depth+1, faintFG("  configuration:"))
+	for _, setting := range httpResult.Configuration {
+		printLine(table, depth+1, faintFG(fmt.Sprintf("    %s", setting)))
+	}
+}
+
+// printTestWarnings prints the warnings of a test result, if any.
+func printTestWarnings(table *tablecloth.Table, result TestRunResult, depth int) {
+	for _, warning := range testWarnings(result.TestResult) {
+		printLine(table, depth+1, yellowFG(fmt.Sprintf("  ⚠ %s", warning)))
+	}
 }
 
 func printTestFailure(table *tablecloth.Table, testNum int, result TestRunResult, depth int) {
@@ -251,12 +416,137 @@ func printTestFailure(table *tablecloth.Table, testNum int, result TestRunResult
 		},
 	)
 
-	failures := result.TestResult.Failures()
-	for i := 0; i < len(failures)-1; i++ {
-		// w.printLine(depth+1, redFG(fmt.Sprintf("├╴  %s", failures[i])))
-		printLine(table, depth+1, redFG(fmt.Sprintf("  %s", failures[i])))
+	printTestFailures(table, result.TestResult.Failures(), depth)
+
+	printTestWarnings(table, result, depth)
+
+	if location := testCaseLocation(result.TestCase); location != "" {
+		printLine(table, depth+1, faintFG(fmt.Sprintf("  at %s", location)))
 	}
 
-	printLine(table, depth+1, redFG(fmt.Sprintf("  %s", failures[len(failures)-1])))
+	if len(result.ServerLogs) > 0 {
+		printLine(table, depth+1, faintFG("  server logs:"))
+		for _, line := range result.ServerLogs {
+			printLine(table, depth+1, faintFG(fmt.Sprintf("    %s", line)))
+		}
+	}
+
+	printTestConfiguration(table, result, depth)
 	// w.printLine(depth+1, redFG(fmt.Sprintf("└╴  %s", failures[len(failures)-1])))
 }
+
+// printTestFailures prints the failures of a test. Failures of expectations are
+// grouped by category, in the order that categories are evaluated, followed by
+// any other failures.
+func printTestFailures(table *tablecloth.Table, failures []error, depth int) {
+	grouped := map[ExpectationCategory][]error{}
+	var other []error
+	for _, failure := range failures {
+		if category, ok := CategoryOf(failure); ok {
+			grouped[category] = append(grouped[category], failure)
+		} else {
+			other = append(other, failure)
+		}
+	}
+
+	if len(grouped) == 0 {
+		for _, failure := range failures {
+			printFailure(table, depth+1, "  ", failure)
+		}
+		return
+	}
+
+	for _, category := range ExpectationOrder() {
+		if len(grouped[category]) == 0 {
+			continue
+		}
+
+		printLine(table, depth+1, redFG(fmt.Sprintf("  %s (%d):", category, len(grouped[category]))))
+		for _, failure := range grouped[category] {
+			printFailure(table, depth+1, "    ", failure)
+		}
+	}
+
+	if len(other) > 0 {
+		printLine(table, depth+1, redFG(fmt.Sprintf("  other (%d):", len(other))))
+		for _, failure := range other {
+			printFailure(table, depth+1, "    ", failure)
+		}
+	}
+}
+
+// printFailure prints a single failure of a test. A diff, such as that of a
+// golden file, is printed line by line, colored by the kind of each line.
+func printFailure(table *tablecloth.Table, depth int, indent string, failure error) {
+	var diff diffError
+	if !errors.As(failure, &diff) {
+		printLine(table, depth, redFG(fmt.Sprintf("%s%s", indent, failure)))
+		return
+	}
+
+	printLine(table, depth, redFG(indent+diff.summary()))
+	for _, line := range diff.diffLines() {
+		colorize := faintFG
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			colorize = cyanFG
+		case strings.HasPrefix(line, "-"):
+			colorize = redFG
+		case strings.HasPrefix(line, "+"):
+			colorize = greenFG
+		}
+		printLine(table, depth, indent+"  "+colorize(line))
+	}
+	if hint := diff.hint(); hint != "" {
+		printLine(table, depth, yellowFG(indent+hint))
+	}
+}
+
+func printTestSuppressed(table *tablecloth.Table, testNum int, result TestRunResult, depth int) {
+
+	table.AddRow(
+		tablecloth.Cell{
+			Format: "%s%s %s %s %s",
+			Values: []tablecloth.FormattableCellValue{
+				{Value: strings.Repeat(indentationPrefix, depth+1), Format: faintFG},
+				{Value: "⚠", Format: yellowFGBold},
+				{Value: testNum, Format: yellowFGBold},
+				{Value: result.TestCase.Description(), Format: whiteFG},
+				{Value: fmt.Sprintf("(%s)", result.Suppressed), Format: yellowFG},
+			},
+		},
+		tablecloth.Cell{
+			Format: "%s",
+			Values: []tablecloth.FormattableCellValue{
+				{Value: fmt.Sprintf("%7s ", result.TestCase.Action()), Format: blueBG},
+			},
+		},
+		tablecloth.Cell{
+			Format: result.TestCase.Target(),
+		},
+		tablecloth.Cell{
+			Format: "%s",
+			Values: []tablecloth.FormattableCellValue{
+				{Value: result.Duration.String(), Format: faintFG},
+			},
+		},
+	)
+
+	for _, failure := range result.TestResult.Failures() {
+		printLine(table, depth+1, yellowFG(fmt.Sprintf("  %s", failure)))
+	}
+}
+
+// errorStrings returns the messages of a list of errors.
+func errorStrings(errs []error) []string {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	s := make([]string, len(errs))
+	for i, err := range errs {
+		s[i] = err.Error()
+	}
+
+	return s
+}
@@ -0,0 +1,65 @@
+package mt
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// A PanicError is a failure caused by a panic during a test, such as in a
+// custom predicate, hook, or binder, which is recovered so that the rest of
+// the test run can proceed.
+type PanicError struct {
+	// Value is the value passed to panic().
+	Value any
+
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v\n%s", e.Value, e.Stack)
+}
+
+// A panicResult is the result of a test case whose execution panicked before
+// it produced a result of its own.
+type panicResult struct {
+	testCase TestCase
+	err      error
+}
+
+func (r *panicResult) TestCase() TestCase {
+	return r.testCase
+}
+
+func (r *panicResult) Failures() []error {
+	return []error{r.err}
+}
+
+// executeSafely executes a test case, converting a panic during its execution
+// into a failure of the test case.
+func executeSafely(test TestCase) (result TestResult) {
+	defer func() {
+		if v := recover(); v != nil {
+			err := &PanicError{Value: v, Stack: string(debug.Stack())}
+			if tc, ok := test.(*HTTPTestCase); ok && tc.lastResult != nil {
+				result = tc.lastResult.addFailures(err)
+				return
+			}
+
+			result = &panicResult{testCase: test, err: err}
+		}
+	}()
+
+	return test.Execute()
+}
+
+// callSafely calls a hook, converting a panic into an error.
+func callSafely(hook func() error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: string(debug.Stack())}
+		}
+	}()
+
+	return hook()
+}
@@ -0,0 +1,75 @@
+package mt
+
+import (
+	"time"
+)
+
+// A Phase is a point in the execution of an HTTP test case.
+type Phase int
+
+const (
+	// BeforeSend is the phase after the request has been prepared, immediately
+	// before it is sent.
+	BeforeSend Phase = iota
+
+	// AfterReceive is the phase immediately after the response has been
+	// received, before expectations are validated.
+	AfterReceive
+
+	// AfterAssert is the phase after all expectations have been validated.
+	AfterAssert
+)
+
+func (p Phase) String() string {
+	switch p {
+	case BeforeSend:
+		return "before-send"
+	case AfterReceive:
+		return "after-receive"
+	case AfterAssert:
+		return "after-assert"
+	default:
+		return "unknown"
+	}
+}
+
+// PhaseTimings are the durations of the phases of an HTTP test case's
+// execution. Durations of phases that have not completed are zero.
+type PhaseTimings struct {
+	// Prepare is the time spent preparing the request, including resolving
+	// deferred values and serializing the body.
+	Prepare time.Duration `json:"prepare"`
+
+	// Network is the time spent sending the request and receiving the
+	// response, or handling the request for handler contexts.
+	Network time.Duration `json:"network"`
+
+	// Assert is the time spent validating expectations.
+	Assert time.Duration `json:"assert"`
+}
+
+// A PhaseFunc is called at each phase of an HTTP test case's execution with the
+// result so far, whose Timings reflect the phases completed.
+type PhaseFunc func(phase Phase, result *HTTPTestCaseResult)
+
+// OnPhase adds a hook called at each phase of the test case's execution, for
+// measuring serialization and assertion overhead separately from network time.
+func (tc *HTTPTestCase) OnPhase(hook PhaseFunc) *HTTPTestCase {
+	tc.phaseHooks = append(tc.phaseHooks, hook)
+	return tc
+}
+
+// enterPhase calls the test case's phase hooks.
+func (tc *HTTPTestCase) enterPhase(phase Phase, result *HTTPTestCaseResult) {
+	for _, hook := range tc.phaseHooks {
+		hook(phase, result)
+	}
+}
+
+// beginSend records the time spent preparing the request, calls the BeforeSend
+// hooks, and returns the time at which the request is sent.
+func (tc *HTTPTestCase) beginSend(result *HTTPTestCaseResult, start time.Time) time.Time {
+	result.Timings.Prepare = time.Since(start)
+	tc.enterPhase(BeforeSend, result)
+	return time.Now()
+}
@@ -0,0 +1,100 @@
+package mt
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// WithPrewarm sets the number of connections the TestRunner establishes to each
+// distinct host before the test run begins, and returns the TestRunner.
+//
+// Pre-warming performs connection setup and any TLS handshakes ahead of time,
+// so that the durations of the first tests run against a host aren't skewed by
+// cold connections. Each connection is established by a HEAD request to the
+// root of the host, whose outcome is ignored. Connections are only retained up
+// to the idle connection limits of each context's HTTP client's transport.
+func (r *TestRunner) WithPrewarm(connections int) *TestRunner {
+	r.Prewarm = connections
+	return r
+}
+
+// prewarm establishes connections to each distinct host targeted by the HTTP
+// tests of a group.
+func (r *TestRunner) prewarm(group *TestGroup) {
+	if r.Prewarm < 1 {
+		return
+	}
+
+	type target struct {
+		client *http.Client
+		origin string
+	}
+
+	var targets []target
+	seen := map[target]bool{}
+	var collect func(TestCase)
+	collect = func(test TestCase) {
+		switch tc := test.(type) {
+		case *HTTPTestCase:
+			if tc.tctx.Handler != nil || tc.clientCert != nil || tc.request.URL.Host == "" {
+				return
+			}
+
+			client := tc.tctx.Client
+			if client == nil {
+				client = http.DefaultClient
+			}
+
+			origin := (&url.URL{Scheme: tc.request.URL.Scheme, Host: tc.request.URL.Host}).String()
+			t := target{client, origin}
+			if !seen[t] {
+				seen[t] = true
+				targets = append(targets, t)
+			}
+
+		case *Scenario:
+			for _, step := range tc.Steps {
+				collect(step)
+			}
+		}
+	}
+
+	var collectGroup func(*TestGroup)
+	collectGroup = func(g *TestGroup) {
+		for _, test := range g.Tests {
+			collect(test)
+		}
+
+		for _, subgroup := range g.Subgroups {
+			collectGroup(subgroup)
+		}
+	}
+	collectGroup(group)
+
+	wg := sync.WaitGroup{}
+	for _, t := range targets {
+		for i := 0; i < r.Prewarm; i++ {
+			wg.Add(1)
+			go func(t target) {
+				defer wg.Done()
+				req, err := http.NewRequest(http.MethodHead, t.origin+"/", nil)
+				if err != nil {
+					return
+				}
+
+				resp, err := t.client.Do(req)
+				if err != nil {
+					return
+				}
+
+				// the body must be drained for the connection to be reused
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}(t)
+		}
+	}
+
+	wg.Wait()
+}
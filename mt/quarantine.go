@@ -0,0 +1,77 @@
+package mt
+
+import (
+	"bufio"
+	"errors"
+	"log"
+	"os"
+	"strings"
+)
+
+// WithQuarantine quarantines the tests with the given fingerprints or
+// descriptions and returns the TestRunner.
+//
+// Quarantined tests are still run, but their failures are reported separately
+// and do not cause the test run to fail.
+func (r *TestRunner) WithQuarantine(ids ...string) *TestRunner {
+	if r.Quarantine == nil {
+		r.Quarantine = map[string]bool{}
+	}
+
+	for _, id := range ids {
+		r.Quarantine[id] = true
+	}
+
+	return r
+}
+
+// WithQuarantineFile quarantines the tests listed in a file and returns the
+// TestRunner. See WithQuarantine().
+//
+// The file lists one fingerprint or description per line. Empty lines and
+// lines starting with # are ignored. A missing file quarantines nothing.
+func (r *TestRunner) WithQuarantineFile(path string) *TestRunner {
+	ids, err := readIDFile(path)
+	if err != nil {
+		log.Fatalf("failed to read quarantine file %q: %v", path, err)
+	}
+
+	return r.WithQuarantine(ids...)
+}
+
+// isQuarantined returns true if a test run result refers to a quarantined test.
+func (r *TestRunner) isQuarantined(result TestRunResult) bool {
+	return r.Quarantine[result.Fingerprint] || r.Quarantine[result.TestCase.Description()]
+}
+
+// readIDFile reads a file listing one ID per line, ignoring empty lines and
+// lines starting with #. Anything following " #" on a line is treated as a
+// comment.
+func readIDFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	ids := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, " #"); i != -1 {
+			line = line[:i]
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		ids = append(ids, line)
+	}
+
+	return ids, scanner.Err()
+}
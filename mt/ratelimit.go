@@ -0,0 +1,113 @@
+package mt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jefflinse/melatonin/expect"
+)
+
+// rateLimitHeaderPrefixes are the prefixes of the common conventions for
+// separate rate limit headers, in the order they are looked up.
+var rateLimitHeaderPrefixes = []string{"X-RateLimit-", "RateLimit-", "X-Rate-Limit-"}
+
+// A RateLimitExpectation is the expected rate limit quota advertised by the
+// headers of a response. See ExpectRateLimitHeaders().
+type RateLimitExpectation struct {
+	Limit     any `json:"limit,omitempty"`
+	Remaining any `json:"remaining,omitempty"`
+	Reset     any `json:"reset,omitempty"`
+}
+
+// ExpectRateLimitHeaders adds an expectation for the rate limit quota
+// advertised by the response's headers, for example:
+//
+//	tc.ExpectStatus(429).ExpectRateLimitHeaders(100, 0, expect.Int())
+//
+// Each expected value may be a literal integer or a predicate, and is matched
+// against the integer value of the corresponding header. A nil expected value
+// is not checked. The limit, remaining, and reset values are read from the
+// first of the X-RateLimit-*, RateLimit-*, and X-Rate-Limit-* headers present,
+// or else from the parameters of a combined header such as
+// "RateLimit: limit=100, remaining=50, reset=30". Policy parameters following
+// a value, such as in "RateLimit-Limit: 100, 100;w=60", are ignored.
+func (tc *HTTPTestCase) ExpectRateLimitHeaders(limit, remaining, reset any) *HTTPTestCase {
+	tc.Expectations.RateLimit = &RateLimitExpectation{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     reset,
+	}
+	return tc
+}
+
+// validateRateLimit returns a failure for each value advertised by the
+// response's rate limit headers that does not match the expected value.
+func (r *HTTPTestCaseResult) validateRateLimit(expectation RateLimitExpectation) []error {
+	var errs []error
+	for _, value := range []struct {
+		name     string
+		header   string
+		expected any
+	}{
+		{"limit", "Limit", expectation.Limit},
+		{"remaining", "Remaining", expectation.Remaining},
+		{"reset", "Reset", expectation.Reset},
+	} {
+		if value.expected == nil {
+			continue
+		}
+
+		field, raw, ok := r.rateLimitHeader(value.name, value.header)
+		if !ok {
+			errs = append(errs, fmt.Errorf("expected rate limit %s header, got nothing", value.name))
+			continue
+		}
+
+		actual, err := parseRateLimitValue(raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: expected integer rate limit %s, got %q", field, value.name, raw))
+			continue
+		}
+
+		comparison := expect.Compare(normalizeInt(value.expected), actual, r.testCase.compareOptions(false))
+		for _, err := range comparison.Failures {
+			err.PushField(field)
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// rateLimitHeader returns where a rate limit value was found, such as
+// "X-RateLimit-Remaining", and its raw value.
+func (r *HTTPTestCaseResult) rateLimitHeader(name, suffix string) (string, string, bool) {
+	for _, prefix := range rateLimitHeaderPrefixes {
+		header := prefix + suffix
+		if value := r.Headers.Get(header); value != "" {
+			return header, value, true
+		}
+	}
+
+	for _, param := range strings.FieldsFunc(r.Headers.Get("RateLimit"), func(c rune) bool {
+		return c == ',' || c == ';'
+	}) {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if ok && strings.EqualFold(key, name) {
+			return fmt.Sprintf("RateLimit %s", name), value, true
+		}
+	}
+
+	return "", "", false
+}
+
+// parseRateLimitValue parses the integer value of a rate limit header,
+// ignoring any policy parameters that follow it.
+func parseRateLimitValue(value string) (int64, error) {
+	if i := strings.IndexAny(value, ",;"); i >= 0 {
+		value = value[:i]
+	}
+
+	return strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+}
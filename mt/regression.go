@@ -0,0 +1,122 @@
+package mt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/jefflinse/melatonin/expect"
+	mtjson "github.com/jefflinse/melatonin/json"
+)
+
+// A recordedResponse is the response to a test case recorded during a blessed
+// test run.
+type recordedResponse struct {
+	Description string `json:"description"`
+	Status      int    `json:"status"`
+	Body        any    `json:"body,omitempty"`
+}
+
+// WithRegressionFile sets a file of recorded responses and returns the
+// TestRunner.
+//
+// When RecordRegression is true, the responses of all HTTP tests in the test
+// run are recorded to the file. Otherwise, each response is compared against
+// its recording and any difference in status or body fails the test, catching
+// behavioral changes even where no explicit expectations exist. Values at any
+// of the ignored JSON paths, such as "data.*.updated_at", are excluded from
+// the comparison.
+//
+// Tests are matched to their recordings by fingerprint. Tests without a
+// recording are not compared. A missing file is treated as an empty recording.
+func (r *TestRunner) WithRegressionFile(path string, ignore ...string) *TestRunner {
+	recordings, err := readRegressionFile(path)
+	if err != nil {
+		log.Fatalf("failed to read regression file %q: %v", path, err)
+	}
+
+	r.RegressionFile = path
+	r.RegressionIgnore = ignore
+	r.recordings = recordings
+	return r
+}
+
+// WithRegressionRecord sets the RecordRegression field of the TestRunner and
+// returns the TestRunner.
+func (r *TestRunner) WithRegressionRecord(record bool) *TestRunner {
+	r.RecordRegression = record
+	return r
+}
+
+// compareRecording compares the response to an HTTP test against its recording,
+// adding a failure to the test result for each difference.
+func (r *TestRunner) compareRecording(runResult TestRunResult) {
+	result, ok := runResult.TestResult.(*HTTPTestCaseResult)
+	if !ok {
+		return
+	}
+
+	recorded, ok := r.recordings[runResult.Fingerprint]
+	if !ok {
+		return
+	}
+
+	if recorded.Status != result.Status {
+		result.addFailures(fmt.Errorf("regression: recorded status %d, got %d", recorded.Status, result.Status))
+	}
+
+	want := mtjson.Without(recorded.Body, r.RegressionIgnore...)
+	got := mtjson.Without(result.body(), r.RegressionIgnore...)
+	if want == nil {
+		if got != nil {
+			result.addFailures(fmt.Errorf("regression: recorded no body, got %+v", got))
+		}
+		return
+	}
+
+	for _, err := range expect.CompareValues(want, got, true) {
+		err.PushField("")
+		result.addFailures(fmt.Errorf("regression: %w", err))
+	}
+}
+
+// readRegressionFile reads a file of recorded responses, keyed by fingerprint.
+func readRegressionFile(path string) (map[string]recordedResponse, error) {
+	recordings := map[string]recordedResponse{}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return recordings, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &recordings); err != nil {
+		return nil, err
+	}
+
+	return recordings, nil
+}
+
+// writeRegressionFile records the responses of all HTTP tests in a group run
+// to a regression file.
+func writeRegressionFile(path string, result *GroupRunResult) error {
+	recordings := map[string]recordedResponse{}
+	forEachTestRunResult(result, func(runResult TestRunResult) {
+		if result, ok := runResult.TestResult.(*HTTPTestCaseResult); ok {
+			recordings[runResult.Fingerprint] = recordedResponse{
+				Description: runResult.TestCase.Description(),
+				Status:      result.Status,
+				Body:        result.body(),
+			}
+		}
+	})
+
+	b, err := json.MarshalIndent(recordings, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(b, '\n'), 0644)
+}
@@ -0,0 +1,147 @@
+package mt
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	mtjson "github.com/jefflinse/melatonin/json"
+)
+
+// A ResourceRule describes how to identify resources created during a test run
+// and how to delete them.
+type ResourceRule struct {
+	// Pattern is a regular expression matched against the path of each
+	// successful POST request. A match indicates that the request created a
+	// resource.
+	Pattern string
+
+	// IDPath is the JSON path of the created resource's ID within the response
+	// body, such as "id" or "data.id".
+	IDPath string
+
+	// DeletePath is the path used to delete a created resource, relative to the
+	// test context that created it. Any occurrence of "{id}" is replaced with the
+	// resource's ID, for example "/users/{id}".
+	DeletePath string
+
+	regex *regexp.Regexp
+}
+
+// A TrackedResource is a resource created during a test run that was not
+// deleted by any test.
+type TrackedResource struct {
+	// ID is the ID of the resource.
+	ID string `json:"id"`
+
+	// Path is the path used to delete the resource.
+	Path string `json:"path"`
+
+	// CreatedBy is the description of the test that created the resource.
+	CreatedBy string `json:"created_by"`
+
+	// SweepError is the error encountered deleting the resource during the leak
+	// sweep, if any. It is empty if the resource was deleted successfully or if
+	// the sweep only reported leaks.
+	SweepError string `json:"sweep_error,omitempty"`
+
+	// Swept indicates whether the resource was deleted by the leak sweep.
+	Swept bool `json:"swept"`
+
+	tctx *HTTPTestContext
+}
+
+// WithResourceTracking sets rules for tracking resources created by POST
+// requests during the test run, and returns the TestRunner.
+//
+// Once the test run completes, any tracked resource that was not deleted by a
+// successful DELETE request to its delete path is reported as leaked. If
+// SweepLeaks is true, leaked resources are also deleted.
+func (r *TestRunner) WithResourceTracking(rules ...ResourceRule) *TestRunner {
+	for i := range rules {
+		regex, err := regexp.Compile(rules[i].Pattern)
+		if err != nil {
+			log.Fatalf("invalid resource rule pattern %q: %v", rules[i].Pattern, err)
+		}
+		rules[i].regex = regex
+	}
+
+	r.ResourceRules = append(r.ResourceRules, rules...)
+	return r
+}
+
+// WithLeakSweep sets the SweepLeaks field of the TestRunner and returns the
+// TestRunner.
+func (r *TestRunner) WithLeakSweep(sweep bool) *TestRunner {
+	r.SweepLeaks = sweep
+	return r
+}
+
+// findLeakedResources returns all resources created during a group run that
+// were not deleted by any test, deleting them if the runner sweeps leaks.
+func (r *TestRunner) findLeakedResources(result *GroupRunResult) []TrackedResource {
+	created := []TrackedResource{}
+	deleted := map[string]bool{}
+	forEachTestRunResult(result, func(runResult TestRunResult) {
+		for _, httpResult := range httpResults(runResult.TestResult) {
+			tc := httpResult.testCase
+			path := tc.request.URL.Path
+			switch {
+			case tc.request.Method == http.MethodPost && httpResult.Status >= 200 && httpResult.Status < 300:
+				for _, rule := range r.ResourceRules {
+					if !rule.regex.MatchString(path) {
+						continue
+					}
+
+					for _, id := range mtjson.Lookup(httpResult.body(), rule.IDPath) {
+						created = append(created, TrackedResource{
+							ID:        fmt.Sprint(id),
+							Path:      strings.ReplaceAll(rule.DeletePath, "{id}", fmt.Sprint(id)),
+							CreatedBy: runResult.TestCase.Description(),
+							tctx:      tc.tctx,
+						})
+					}
+				}
+
+			case tc.request.Method == http.MethodDelete && (httpResult.Status < 300 || httpResult.Status == http.StatusNotFound):
+				deleted[path] = true
+			}
+		}
+	})
+
+	leaked := []TrackedResource{}
+	for _, resource := range created {
+		if isDeleted(resource, deleted) {
+			continue
+		}
+
+		if r.SweepLeaks {
+			sweepResult := resource.tctx.DELETE(resource.Path).Execute()
+			if failures := sweepResult.Failures(); len(failures) > 0 {
+				resource.SweepError = failures[0].Error()
+			} else if status := sweepResult.(*HTTPTestCaseResult).Status; status >= 300 && status != http.StatusNotFound {
+				resource.SweepError = fmt.Sprintf("unexpected status %d", status)
+			} else {
+				resource.Swept = true
+			}
+		}
+
+		leaked = append(leaked, resource)
+	}
+
+	return leaked
+}
+
+// isDeleted returns true if a resource's delete path, which is relative to its
+// test context, matches the path of any successful DELETE request.
+func isDeleted(resource TrackedResource, deleted map[string]bool) bool {
+	for path := range deleted {
+		if strings.HasSuffix(path, resource.Path) {
+			return true
+		}
+	}
+
+	return false
+}
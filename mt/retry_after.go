@@ -0,0 +1,133 @@
+package mt
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRetryAfterAttempts is the most times a throttled request is sent again.
+const maxRetryAfterAttempts = 5
+
+// WithRetryAfter causes HTTP requests that receive a "429 Too Many Requests" or
+// "503 Service Unavailable" response with a Retry-After header to be sent again
+// once the wait it asks for has passed, rather than failing, so that test runs
+// against throttled environments behave gracefully.
+//
+// The budget is the longest total time spent waiting across the test run. A
+// request is not sent again if its wait would exceed the remaining budget or
+// the test's timeout, or if it has already been sent again 5 times. The waits
+// are recorded in the test results and reported as warnings.
+func (r *TestRunner) WithRetryAfter(budget time.Duration) *TestRunner {
+	r.RetryAfterBudget = budget
+	return r
+}
+
+// A retryAfterBudget is the time remaining for waits on Retry-After headers
+// during a test run.
+type retryAfterBudget struct {
+	mu        sync.Mutex
+	total     time.Duration
+	remaining time.Duration
+}
+
+// take reserves a wait from the budget, returning false if too little of the
+// budget remains.
+func (b *retryAfterBudget) take(wait time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if wait > b.remaining {
+		return false
+	}
+
+	b.remaining -= wait
+	return true
+}
+
+// A retryAfterHonorer is a test case that accepts a budget for waits on
+// Retry-After headers from the test runner.
+type retryAfterHonorer interface {
+	setRetryAfterBudget(budget *retryAfterBudget)
+}
+
+func (tc *HTTPTestCase) setRetryAfterBudget(budget *retryAfterBudget) {
+	tc.retryAfter = budget
+}
+
+func (s *Scenario) setRetryAfterBudget(budget *retryAfterBudget) {
+	for _, step := range s.Steps {
+		if honorer, ok := step.(retryAfterHonorer); ok {
+			honorer.setRetryAfterBudget(budget)
+		}
+	}
+}
+
+// retryAfterWait returns how long to wait before sending the test case's
+// request again, and whether it should be sent again at all.
+func (tc *HTTPTestCase) retryAfterWait(result *HTTPTestCaseResult) (time.Duration, bool) {
+	if tc.retryAfter == nil || result.Memoized || tc.batch != nil || tc.streamsBody() ||
+		len(result.RetryAfterWaits) >= maxRetryAfterAttempts {
+		return 0, false
+	}
+
+	if result.Status != http.StatusTooManyRequests && result.Status != http.StatusServiceUnavailable {
+		return 0, false
+	}
+
+	wait, ok := parseRetryAfter(result.Headers.Get("Retry-After"), time.Now())
+	if !ok {
+		return 0, false
+	}
+
+	if deadline, ok := tc.request.Context().Deadline(); ok && time.Until(deadline) <= wait {
+		return 0, false
+	}
+
+	return wait, tc.retryAfter.take(wait)
+}
+
+// waitToRetry records and waits out a wait before the test case's request is
+// sent again.
+func (tc *HTTPTestCase) waitToRetry(result *HTTPTestCaseResult, wait time.Duration) error {
+	result.RetryAfterWaits = append(result.RetryAfterWaits, wait)
+	result.addWarnings(fmt.Errorf("waited %s to retry after a %d response", wait, result.Status))
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-tc.request.Context().Done():
+		return fmt.Errorf("failed to retry after a %d response: %w", result.Status, tc.request.Context().Err())
+	}
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a
+// number of seconds or an HTTP date, into a wait from the given time.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	if wait := date.Sub(now); wait > 0 {
+		return wait, true
+	}
+
+	return 0, true
+}
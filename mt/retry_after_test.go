@@ -0,0 +1,66 @@
+package mt_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jefflinse/melatonin/mt"
+	"github.com/stretchr/testify/assert"
+)
+
+// throttledHandler responds with a 429 and a Retry-After header to the given
+// number of requests, and then with a 200.
+func throttledHandler(throttled int, retryAfter string) http.Handler {
+	requests := 0
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if throttled < 0 || requests <= throttled {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		handler    http.Handler
+		budget     time.Duration
+		wantStatus int
+		wantWaits  []time.Duration
+	}{
+		{"retried", throttledHandler(2, "0"), time.Second, http.StatusOK, []time.Duration{0, 0}},
+		{"not honored", throttledHandler(1, "0"), 0, http.StatusTooManyRequests, nil},
+		{"wait exceeds budget", throttledHandler(1, "1"), 500 * time.Millisecond, http.StatusTooManyRequests, nil},
+		{"attempts exhausted", throttledHandler(-1, "0"), time.Second, http.StatusTooManyRequests, []time.Duration{0, 0, 0, 0, 0}},
+		{"invalid header", throttledHandler(1, "soon"), time.Second, http.StatusTooManyRequests, nil},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := mt.NewHandlerContext(test.handler)
+			result := mt.NewTestRunner().WithRetryAfter(test.budget).RunTests(ctx.GET("/").ExpectStatus(http.StatusOK))
+
+			testResult := result.TestResults[0].TestResult.(*mt.HTTPTestCaseResult)
+			assert.Equal(t, test.wantStatus, testResult.Status)
+			assert.Equal(t, test.wantWaits, testResult.RetryAfterWaits)
+			assert.Len(t, testResult.Warnings(), len(test.wantWaits))
+		})
+	}
+}
+
+func TestRetryAfterWithAdvancedClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	throttled := throttledHandler(1, "0")
+	var seen []time.Time
+	ctx := mt.NewHandlerContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, mt.ClockFromContext(r.Context()).Now())
+		throttled.ServeHTTP(w, r)
+	})).WithClock(mt.NewFakeClock(start))
+
+	result := mt.NewTestRunner().WithRetryAfter(time.Second).RunTests(
+		ctx.GET("/").AdvanceClock(time.Hour).ExpectStatus(http.StatusOK),
+	)
+
+	assert.Equal(t, 1, result.Passed)
+	assert.Equal(t, []time.Time{start.Add(time.Hour), start.Add(time.Hour)}, seen)
+}
@@ -0,0 +1,84 @@
+package mt
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// RoleContexts are HTTP test contexts that authenticate as each of a set of
+// roles, by role name.
+type RoleContexts map[string]*HTTPTestContext
+
+// NewRoleContexts creates a context for each role that shares the configuration
+// of the base context, such as its base URL or handler and HTTP client, and
+// authenticates the requests of its test cases with the role's credentials.
+// Each role's context memoizes responses separately, if memoization is enabled.
+//
+//	roles := mt.NewRoleContexts(mt.NewURLContext(baseURL), map[string]mt.Credentials{
+//		"admin":  mt.BearerToken(adminToken),
+//		"viewer": mt.BearerToken(viewerToken),
+//	})
+//
+//	roles["admin"].GET("/reports").ExpectStatus(200)
+func NewRoleContexts(base *HTTPTestContext, roles map[string]Credentials) RoleContexts {
+	contexts := make(RoleContexts, len(roles))
+	for role, credentials := range roles {
+		c := *base
+		c.Credentials = credentials
+		if c.memo != nil {
+			c.memo = &memo{responses: map[string]*HTTPTestCaseResult{}}
+		}
+		contexts[role] = &c
+	}
+
+	return contexts
+}
+
+// Expect creates a test case for each of the given roles making the same
+// request, each with its role's expectations, in order of role names. The test
+// case of a role without a context fails when it's executed. This concisely
+// expresses how access differs between roles:
+//
+//	roles.Expect(http.MethodDelete, "/users/1", map[string]mt.ExpectFunc{
+//		"admin":  func(tc *mt.HTTPTestCase) { tc.ExpectStatus(204) },
+//		"viewer": mt.Forbidden,
+//	})
+func (rc RoleContexts) Expect(method, path string, expectations map[string]ExpectFunc) []TestCase {
+	roles := make([]string, 0, len(expectations))
+	for role := range expectations {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	tests := make([]TestCase, 0, len(roles))
+	for _, role := range roles {
+		c, ok := rc[role]
+		if !ok {
+			c = DefaultContext()
+		}
+
+		tc := c.newHTTPTestCase(method, path)
+		tc.Desc = fmt.Sprintf("%s as %s", tc.Description(), role)
+		if !ok {
+			tc.addDefinitionError(fmt.Errorf("no context for role %q", role))
+		}
+		if expectations[role] != nil {
+			expectations[role](tc)
+		}
+
+		tests = append(tests, tc)
+	}
+
+	return tests
+}
+
+// Forbidden expects a test case's response to have a 403 status.
+func Forbidden(tc *HTTPTestCase) {
+	tc.ExpectStatus(http.StatusForbidden)
+}
+
+// Unauthorized expects a test case's response to have a 401 status.
+func Unauthorized(tc *HTTPTestCase) {
+	tc.ExpectStatus(http.StatusUnauthorized)
+}
@@ -0,0 +1,35 @@
+package mt_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jefflinse/melatonin/mt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleContextsExpect(t *testing.T) {
+	base := mt.NewHandlerContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Role") != "admin" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	roles := mt.NewRoleContexts(base, map[string]mt.Credentials{
+		"admin":  mt.HeaderCredentials("X-Role", "admin"),
+		"viewer": mt.HeaderCredentials("X-Role", "viewer"),
+	})
+
+	tests := roles.Expect(http.MethodDelete, "/users/1", map[string]mt.ExpectFunc{
+		"admin":   func(tc *mt.HTTPTestCase) { tc.ExpectStatus(http.StatusOK) },
+		"auditor": mt.Forbidden,
+		"viewer":  mt.Forbidden,
+	})
+
+	result := mt.NewTestRunner().WithContinueOnFailure(true).RunTests(tests...)
+	assert.Equal(t, 2, result.Passed)
+	assert.Equal(t, 1, result.Failed)
+	failures := result.TestResults[1].TestResult.Failures()
+	if assert.Len(t, failures, 1) {
+		assert.EqualError(t, failures[0], `no context for role "auditor"`)
+	}
+}
@@ -1,6 +1,8 @@
 package mt
 
 import (
+	"context"
+	"fmt"
 	"testing"
 	"time"
 )
@@ -15,29 +17,201 @@ const (
 
 // A TestRunner runs a set of tests.
 type TestRunner struct {
+	// AfterAllHooks are called once the test run completes, even if it is
+	// aborted. See AfterAll().
+	AfterAllHooks []func() error
+
+	// BaselineFile is the path to a file listing the fingerprints of tests that
+	// are known to fail. See WithBaselineFile().
+	BaselineFile string
+
+	// Budget sets limits that every test in the test run is evaluated against.
+	// See WithBudget().
+	Budget *Budget
+
+	// CheckDeprecations indicates whether responses are checked for use of
+	// deprecated APIs. See WithDeprecationCheck().
+	CheckDeprecations bool
+
+	// CheckpointFile is the path to a file recording the fingerprints of tests
+	// that have passed, allowing an interrupted test run to resume. See
+	// WithCheckpoint().
+	CheckpointFile string
+
+	// Cleanups are called in reverse order once the test run completes, even if
+	// it is aborted. See Cleanup().
+	Cleanups []func() error
+
+	// Context aborts the test run when it is done. See WithContext().
+	Context context.Context
+
 	// ContinueOnFailure indicates whether the test runner should continue
 	// executing further tests after a test encounters a failure.
 	//
 	// Default is false.
 	ContinueOnFailure bool
 
+	// CrossResultExpectations are evaluated against the results of all tests
+	// once the test run completes. See ExpectAcrossResults().
+	CrossResultExpectations []func([]TestResult) error
+
+	// DeprecatedFields are the JSON paths of deprecated response fields.
+	DeprecatedFields []string
+
+	// DurationOrder is the order in which the tests of each group are executed,
+	// based on their historical duration. See WithDurationOrder().
+	//
+	// Default is DefinedOrder.
+	DurationOrder int
+
+	// DurationRegressionThreshold is the fraction by which a test may take
+	// longer than it did in the previous test run before it is flagged. See
+	// WithDurationRegressionThreshold().
+	DurationRegressionThreshold float64
+
+	// FixedTimestamp, if set, is recorded as the start and end time of every
+	// test in place of the actual times, keeping generated reports deterministic.
+	FixedTimestamp time.Time
+
+	// GatingSeverity is the least severe level of test failure that causes the
+	// test run to fail. Failures of less severe tests are reported but suppressed.
+	//
+	// Default is Minor, causing failures of all severities to fail the test run.
+	GatingSeverity Severity
+
 	// GroupExecutionPriority indicates whether the test runner should execute
 	// tests before or after subgroups.
 	GroupExecutionPriority int
 
+	// LogSource is the source from which server-side logs are captured for
+	// failed tests. See WithLogSource().
+	LogSource LogSource
+
+	// LogWindowPadding extends the time window for which server-side logs are
+	// captured on both sides of a failed test's run.
+	//
+	// Default is 1 second.
+	LogWindowPadding time.Duration
+
+	// Prewarm is the number of connections established to each distinct host
+	// before the test run begins. See WithPrewarm().
+	Prewarm int
+
+	// Quarantine is the set of fingerprints or descriptions of quarantined tests.
+	// See WithQuarantine().
+	Quarantine map[string]bool
+
+	// RecordRegression indicates whether the responses of the test run should
+	// be recorded to the regression file rather than compared against it.
+	//
+	// Default is false, unless the MELATONIN_RECORD_REGRESSION environment
+	// variable is set.
+	RecordRegression bool
+
+	// RegressionFile is the path to a file of recorded responses that responses
+	// are compared against. See WithRegressionFile().
+	RegressionFile string
+
+	// RegressionIgnore are the JSON paths of response body values that are
+	// excluded when comparing responses against their recordings.
+	RegressionIgnore []string
+
+	// ResourceRules are the rules for tracking resources created during the
+	// test run. See WithResourceTracking().
+	ResourceRules []ResourceRule
+
+	// RetryAfterBudget is the longest total time spent waiting on Retry-After
+	// headers of throttled responses during the test run. See WithRetryAfter().
+	//
+	// Default is 0, in which case throttled requests are not sent again.
+	RetryAfterBudget time.Duration
+
+	// RunMetadata describes the test run, such as the git SHA, environment, or
+	// build ID, in the header of every output format. See WithRunMetadata().
+	RunMetadata map[string]string
+
+	// ShardIndex is the index of the shard of tests run by the test runner,
+	// from 0 to ShardTotal-1. See WithShard().
+	ShardIndex int
+
+	// ShardTotal is the number of shards tests are partitioned into. If less
+	// than 2, all tests are run.
+	ShardTotal int
+
+	// SLO is the service level objective that the test run is evaluated against.
+	// See WithSLO().
+	SLO *ServiceLevelObjective
+
+	// Strict indicates whether the test run fails without running any tests if
+	// Lint() finds problems with any of them. See WithStrict().
+	Strict bool
+
+	// Strictness controls body comparisons for HTTP tests whose context does
+	// not set its own. See WithStrictness().
+	Strictness *Strictness
+
+	// SweepLeaks indicates whether tracked resources that were not deleted by
+	// any test should be deleted once the test run completes.
+	//
+	// Default is false, in which case leaked resources are only reported.
+	SweepLeaks bool
+
 	// TestTimeout the the amount of time to wait for any single test to complete.
 	//
 	// Default is 10 seconds.
 	TestTimeout time.Duration
+
+	// TimingFile is the path to a file of historical test durations. See
+	// WithTimingFile().
+	TimingFile string
+
+	// UpdateBaseline indicates whether the baseline file should be regenerated
+	// from the failures of the test run.
+	//
+	// Default is false, unless the MELATONIN_UPDATE_BASELINE environment
+	// variable is set.
+	UpdateBaseline bool
+
+	// VersionHeader is the response header identifying the version of the
+	// backend that responded, such as "X-App-Version". If set, each test result
+	// is tagged with the responding version.
+	VersionHeader string
+
+	baseline   map[string]bool
+	checkpoint map[string]bool
+	recordings map[string]recordedResponse
+	retryAfter *retryAfterBudget
+	shard      map[string]bool
+	stream     chan<- TestRunResult
+	timings    map[string]timingRecord
 }
 
 // A TestRunResult contains information about a completed test case run.
 type TestRunResult struct {
+	// Fingerprint is a stable identifier for the test case. See Fingerprint().
+	Fingerprint string `json:"fingerprint"`
+
 	TestCase   TestCase      `json:"test"`
 	TestResult TestResult    `json:"result"`
 	StartedAt  time.Time     `json:"started_at"`
 	EndedAt    time.Time     `json:"finished_at"`
 	Duration   time.Duration `json:"duration"`
+
+	// BackendVersion is the version of the backend that responded to the test.
+	// See TestRunner.VersionHeader.
+	BackendVersion string `json:"backend_version,omitempty"`
+
+	// ServerLogs are the server-side log lines captured for a failed test.
+	// See TestRunner.WithLogSource().
+	ServerLogs []string `json:"server_logs,omitempty"`
+
+	// Deprecations are the test's uses of deprecated APIs.
+	Deprecations []DeprecationNotice `json:"deprecations,omitempty"`
+
+	// Suppressed is the reason the test's failures were suppressed, such as
+	// "quarantined", or empty if they were not. Suppressed failures are reported
+	// but do not cause the test run to fail.
+	Suppressed string `json:"suppressed,omitempty"`
 }
 
 // A GroupRunResult contains information about a completed set of test cases run by a test runner.
@@ -60,20 +234,71 @@ type GroupRunResult struct {
 	// Skipped is the number of tests that were skipped.
 	Skipped int `json:"skipped"`
 
+	// Suppressed is the number of tests that failed but whose failures were
+	// suppressed, such as quarantined tests.
+	Suppressed int `json:"suppressed"`
+
+	// Resumed is the number of tests that were not run because they passed
+	// during a previous, interrupted test run. See WithCheckpoint().
+	Resumed int `json:"resumed"`
+
 	// Total is the total number of tests in the test group.
 	Total int `json:"total"`
 
 	// Duration is the total duration of all tests in the test group.
 	Duration time.Duration `json:"duration"`
+
+	// RunFailures are failures of the test run as a whole rather than of any
+	// single test, such as a missed service level objective or a failed group
+	// hook. They are recorded on the result of the top-level group, which
+	// includes the failures of its subgroups' hooks.
+	RunFailures []error `json:"-"`
+
+	// LeakedResources are the tracked resources created during the test run
+	// that were not deleted by any test. They are only recorded on the result of
+	// the top-level group.
+	LeakedResources []TrackedResource `json:"leaked_resources,omitempty"`
+
+	// BudgetViolations are the violations of the runner's budget by tests in
+	// the test run. They are only recorded on the result of the top-level group.
+	BudgetViolations []BudgetViolation `json:"budget_violations,omitempty"`
+
+	// Deprecations are the uses of deprecated APIs by tests in the test run,
+	// aggregated by endpoint. They are only recorded on the result of the
+	// top-level group.
+	Deprecations []DeprecationNotice `json:"deprecations,omitempty"`
+
+	// DurationRegressions are the tests in the test run that took longer than
+	// they did in the previous test run by more than the runner's threshold.
+	// They are only recorded on the result of the top-level group.
+	DurationRegressions []DurationRegression `json:"duration_regressions,omitempty"`
+
+	// SLO is the evaluation of the test run against the runner's service level
+	// objective, if any.
+	SLO *SLOReport `json:"slo,omitempty"`
+
+	// Metadata describes the test run. It is only recorded on the result of
+	// the top-level group. See WithRunMetadata().
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 // NewTestRunner creates a new TestRunner with default configuration.
 func NewTestRunner() *TestRunner {
-	return &TestRunner{
+	r := &TestRunner{
 		ContinueOnFailure:      cfg.ContinueOnFailure,
+		GatingSeverity:         Minor,
 		GroupExecutionPriority: ExecuteTestsFirst,
+		LogWindowPadding:       1 * time.Second,
+		RecordRegression:       cfg.RecordRegression,
+		UpdateBaseline:         cfg.UpdateBaseline,
 		TestTimeout:            10 * time.Second,
 	}
+
+	if cfg.CheckpointFile != "" {
+		r.WithCheckpoint(cfg.CheckpointFile)
+	}
+
+	return r
 }
 
 // WithContinueOnFailure sets the ContinueOnFailure field of the TestRunner and
@@ -83,6 +308,24 @@ func (r *TestRunner) WithContinueOnFailure(continueOnFailure bool) *TestRunner {
 	return r
 }
 
+// ExpectAcrossResults adds an expectation that is evaluated against the results
+// of all tests once the test run completes, and returns the
+// TestRunner. This allows asserting invariants over a whole run, for example
+// that no two created resources share an ID.
+//
+// A returned error fails the test run as a whole.
+func (r *TestRunner) ExpectAcrossResults(expectation func(results []TestResult) error) *TestRunner {
+	r.CrossResultExpectations = append(r.CrossResultExpectations, expectation)
+	return r
+}
+
+// WithFixedTimestamp sets the FixedTimestamp field of the TestRunner and returns
+// the TestRunner.
+func (r *TestRunner) WithFixedTimestamp(timestamp time.Time) *TestRunner {
+	r.FixedTimestamp = timestamp
+	return r
+}
+
 // WithRequestTimeout sets the RequestTimeout field of the TestRunner and returns
 // the TestRunner.
 func (r *TestRunner) WithRequestTimeout(timeout time.Duration) *TestRunner {
@@ -116,35 +359,163 @@ func (r *TestRunner) RunTestGroup(group *TestGroup) *GroupRunResult {
 //
 // To run tests as a standalone binary without a testing context, use RunTests().
 func (r *TestRunner) RunTestGroupT(t *testing.T, group *TestGroup) *GroupRunResult {
+	return r.run(t, group)
+}
+
+// RunTestsStream runs a set of tests, returning a channel on which the result of
+// each test is sent as soon as the test completes. The channel is closed once the
+// test run completes.
+//
+// The test run proceeds only as fast as results are received, so the channel
+// must be drained.
+func (r *TestRunner) RunTestsStream(tests ...TestCase) <-chan TestRunResult {
+	return r.RunTestGroupStream(NewTestGroup("").AddTests(tests...))
+}
+
+// RunTestGroupStream runs a test group, returning a channel on which the result
+// of each test is sent as soon as the test completes. See RunTestsStream().
+func (r *TestRunner) RunTestGroupStream(group *TestGroup) <-chan TestRunResult {
+	stream := make(chan TestRunResult)
+	streamer := *r
+	streamer.stream = stream
+	go func() {
+		defer close(stream)
+		streamer.run(nil, group)
+	}()
+
+	return stream
+}
+
+// start prepares the TestRunner to run a test group.
+func (r *TestRunner) start(group *TestGroup) {
+	r.assignShard(group)
+	r.prewarm(group)
+
+	r.retryAfter = nil
+	if r.RetryAfterBudget > 0 {
+		r.retryAfter = &retryAfterBudget{total: r.RetryAfterBudget, remaining: r.RetryAfterBudget}
+	}
+}
+
+// runGroup runs a test group and, recursively, its subgroups.
+func (r *TestRunner) runGroup(t *testing.T, group *TestGroup) *GroupRunResult {
 	groupResult := &GroupRunResult{
 		Group: group,
 	}
 
+	if r.aborted() {
+		groupResult.Skipped = r.runnableTests(groupTests(group))
+		return groupResult
+	}
+
 	if group.BeforeFunc != nil {
-		group.BeforeFunc()
+		if err := callSafely(func() error { group.BeforeFunc(); return nil }); err != nil {
+			groupResult.RunFailures = append(groupResult.RunFailures, fmt.Errorf("group %q before hook: %w", group.Name, err))
+		}
 	}
 
+	// the group's AfterFunc is deferred so that it is called even if the test
+	// run is aborted by a panic or a call to t.FailNow()
+	defer func() {
+		if group.AfterFunc != nil {
+			if err := callSafely(func() error { group.AfterFunc(); return nil }); err != nil {
+				groupResult.RunFailures = append(groupResult.RunFailures, fmt.Errorf("group %q after hook: %w", group.Name, err))
+			}
+		}
+	}()
+
 	if r.GroupExecutionPriority == ExecuteSubgroupsFirst {
 		r.runSubgroups(t, groupResult)
 	}
 
-	for _, test := range group.Tests {
+	tests := r.orderTests(group.Tests)
+	for i, test := range tests {
+		if r.aborted() {
+			groupResult.Skipped += r.runnableTests(tests[i:])
+			break
+		}
+
+		fingerprint := Fingerprint(test)
+		if !r.inShard(fingerprint) {
+			continue
+		}
+
+		if r.isCheckpointed(fingerprint) {
+			groupResult.Resumed++
+			continue
+		}
+
+		if defaulter, ok := test.(strictnessDefaulter); ok && r.Strictness != nil {
+			defaulter.setDefaultStrictness(r.Strictness)
+		}
+
+		if honorer, ok := test.(retryAfterHonorer); ok && r.retryAfter != nil {
+			honorer.setRetryAfterBudget(r.retryAfter)
+		}
+
 		start := time.Now()
-		testResult := test.Execute()
+		testResult := executeSafely(test)
 		end := time.Now()
 		runResult := TestRunResult{
-			TestCase:   test,
-			TestResult: testResult,
-			StartedAt:  start,
-			EndedAt:    end,
-			Duration:   end.Sub(start),
+			Fingerprint: fingerprint,
+			TestCase:    test,
+			TestResult:  testResult,
+			StartedAt:   start,
+			EndedAt:     end,
+			Duration:    end.Sub(start),
+		}
+
+		if r.recordings != nil && !r.RecordRegression {
+			r.compareRecording(runResult)
+		}
+
+		if r.CheckDeprecations {
+			r.sniffDeprecations(&runResult)
+		}
+
+		if r.VersionHeader != "" {
+			runResult.BackendVersion = backendVersion(testResult, r.VersionHeader)
+		}
+
+		if len(testResult.Failures()) > 0 && r.LogSource != nil {
+			runResult.ServerLogs = r.captureServerLogs(runResult)
+		}
+
+		if !r.FixedTimestamp.IsZero() {
+			runResult.StartedAt = r.FixedTimestamp
+			runResult.EndedAt = r.FixedTimestamp
+		}
+
+		if len(testResult.Failures()) > 0 {
+			if r.isQuarantined(runResult) {
+				runResult.Suppressed = "quarantined"
+			} else if r.isBaselined(runResult) {
+				runResult.Suppressed = "baselined"
+			} else if !r.isGating(test) {
+				runResult.Suppressed = fmt.Sprintf("%s severity", testSeverity(test))
+			}
 		}
 
 		groupResult.TestResults = append(groupResult.TestResults, runResult)
+		if r.stream != nil {
+			r.stream <- runResult
+		}
 		groupResult.Total++
 		groupResult.Duration += runResult.Duration
 
-		if len(testResult.Failures()) > 0 {
+		switch {
+		case len(testResult.Failures()) > 0 && runResult.Suppressed != "":
+			groupResult.Suppressed++
+			if t != nil {
+				t.Run(test.Description(), func(t *testing.T) {
+					t.Logf("failures suppressed (%s):", runResult.Suppressed)
+					for _, err := range testResult.Failures() {
+						t.Log(err)
+					}
+				})
+			}
+
+		case len(testResult.Failures()) > 0:
 			groupResult.Failed++
 			if t != nil {
 				t.Run(test.Description(), func(t *testing.T) {
@@ -156,40 +527,161 @@ func (r *TestRunner) RunTestGroupT(t *testing.T, group *TestGroup) *GroupRunResu
 				})
 			}
 
-			if !r.ContinueOnFailure {
-				groupResult.Skipped = len(group.Tests) - groupResult.Total
-				break
+		default:
+			groupResult.Passed++
+			var checkpointErr error
+			if r.CheckpointFile != "" {
+				checkpointErr = r.recordCheckpoint(runResult)
 			}
 
-		} else {
-			groupResult.Passed++
 			if t != nil {
 				t.Run(test.Description(), func(t *testing.T) {
 					t.Log(testResult.TestCase().Description())
+					if checkpointErr != nil {
+						t.Logf("failed to record checkpoint: %s", checkpointErr)
+					}
+					for _, warning := range testWarnings(testResult) {
+						t.Logf("warning: %s", warning)
+					}
 				})
 			}
 		}
+
+		if len(testResult.Failures()) > 0 && runResult.Suppressed == "" && !r.ContinueOnFailure {
+			groupResult.Skipped += r.runnableTests(tests[i+1:])
+			break
+		}
 	}
 
 	if r.GroupExecutionPriority == ExecuteTestsFirst {
 		r.runSubgroups(t, groupResult)
 	}
 
-	if group.AfterFunc != nil {
-		group.AfterFunc()
+	return groupResult
+}
+
+// runnableTests returns the number of tests that would be run, which excludes
+// those belonging to other shards and those that already passed during a
+// previous, interrupted test run.
+func (r *TestRunner) runnableTests(tests []TestCase) int {
+	n := 0
+	for _, test := range tests {
+		fingerprint := Fingerprint(test)
+		if r.inShard(fingerprint) && !r.isCheckpointed(fingerprint) {
+			n++
+		}
 	}
 
-	return groupResult
+	return n
 }
 
 func (r *TestRunner) runSubgroups(t *testing.T, groupResult *GroupRunResult) {
 	for _, subgroup := range groupResult.Group.Subgroups {
-		result := r.RunTestGroupT(t, subgroup)
+		result := r.runGroup(t, subgroup)
 		groupResult.SubgroupResults = append(groupResult.SubgroupResults, result)
 		groupResult.Passed += result.Passed
 		groupResult.Failed += result.Failed
+		groupResult.Skipped += result.Skipped
+		groupResult.Suppressed += result.Suppressed
+		groupResult.Resumed += result.Resumed
 		groupResult.Total += result.Total
 		groupResult.Duration += result.Duration
+		groupResult.RunFailures = append(groupResult.RunFailures, result.RunFailures...)
+	}
+}
+
+// finish performs any actions that take place after an entire test run is
+// complete.
+func (r *TestRunner) finish(t *testing.T, result *GroupRunResult) {
+	result.Metadata = r.runMetadata()
+
+	if r.UpdateBaseline && r.BaselineFile != "" {
+		if err := writeBaselineFile(r.BaselineFile, result); err != nil {
+			result.RunFailures = append(result.RunFailures,
+				fmt.Errorf("failed to update baseline file %q: %w", r.BaselineFile, err))
+		}
+	}
+
+	if r.RecordRegression && r.RegressionFile != "" {
+		if err := writeRegressionFile(r.RegressionFile, result); err != nil {
+			result.RunFailures = append(result.RunFailures,
+				fmt.Errorf("failed to record regression file %q: %w", r.RegressionFile, err))
+		}
+	}
+
+	if len(r.CrossResultExpectations) > 0 {
+		results := []TestResult{}
+		forEachTestRunResult(result, func(runResult TestRunResult) {
+			results = append(results, runResult.TestResult)
+		})
+
+		for _, expectation := range r.CrossResultExpectations {
+			if err := expectation(results); err != nil {
+				result.RunFailures = append(result.RunFailures, fmt.Errorf("cross-result expectation: %w", err))
+			}
+		}
+	}
+
+	if r.CheckDeprecations {
+		result.Deprecations = collectDeprecations(result)
+	}
+
+	if len(r.ResourceRules) > 0 {
+		result.LeakedResources = r.findLeakedResources(result)
+	}
+
+	if r.Budget != nil {
+		result.BudgetViolations = r.evaluateBudget(result)
+		if r.Budget.Enforce {
+			for _, violation := range result.BudgetViolations {
+				result.RunFailures = append(result.RunFailures, fmt.Errorf("budget: %s", violation))
+			}
+		}
+	}
+
+	if r.SLO != nil {
+		result.SLO = r.evaluateSLO(result)
+		if !r.SLO.WarnOnly {
+			for _, violation := range result.SLO.Violations {
+				result.RunFailures = append(result.RunFailures, fmt.Errorf("SLO: %s", violation))
+			}
+		}
+	}
+
+	if r.TimingFile != "" {
+		if r.DurationRegressionThreshold > 0 {
+			result.DurationRegressions = r.findDurationRegressions(result)
+		}
+
+		if err := r.updateTimingFile(result); err != nil {
+			result.RunFailures = append(result.RunFailures,
+				fmt.Errorf("failed to update timing file %q: %w", r.TimingFile, err))
+		}
+	}
+
+	if r.CheckpointFile != "" && result.Failed == 0 && len(result.RunFailures) == 0 {
+		if err := r.clearCheckpoint(); err != nil {
+			result.RunFailures = append(result.RunFailures,
+				fmt.Errorf("failed to remove checkpoint file %q: %w", r.CheckpointFile, err))
+		}
+	}
+
+	if t != nil {
+		for _, err := range result.RunFailures {
+			t.Error(err)
+		}
+	}
+}
+
+// forEachTestRunResult calls fn for each test run result in a group run result
+// and, recursively, its subgroup results.
+func forEachTestRunResult(result *GroupRunResult, fn func(TestRunResult)) {
+	for _, testResult := range result.TestResults {
+		fn(testResult)
+	}
+
+	for _, subgroupResult := range result.SubgroupResults {
+		forEachTestRunResult(subgroupResult, fn)
 	}
 }
 
@@ -0,0 +1,292 @@
+package mt
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+
+	"github.com/jefflinse/melatonin/expect"
+)
+
+// A Scenario is an ordered set of test cases that are executed together as a
+// single unit, such as a multi-step user journey.
+//
+// All steps in a scenario share a cookie jar and a variable store. Steps are
+// executed in order, and once a step fails, all subsequent steps are skipped.
+type Scenario struct {
+	// Name is the name of the scenario.
+	Name string
+
+	// BeforeFunc is an optional function that is run before the first step of
+	// the scenario. Any error returned by BeforeFunc is treated as a failure
+	// and causes all steps to be skipped, although the scenario's end hooks and
+	// AfterFunc are still run.
+	BeforeFunc func() error
+
+	// AfterFunc is an optional function that is run after the last step of the
+	// scenario, even if a step failed. Any error returned by AfterFunc is treated
+	// as a failure.
+	AfterFunc func() error
+
+	// RequirementIDs are the IDs of the requirements covered by the scenario.
+	RequirementIDs []string
+
+	// SeverityLevel is the severity of the scenario's failures. Default is Critical.
+	SeverityLevel Severity
+
+	// Steps are the test cases that make up the scenario.
+	Steps []TestCase
+
+	// EndHooks are functions that are run when the scenario ends, regardless of
+	// whether any step failed. See OnScenarioEnd.
+	EndHooks []ScenarioEndFunc
+
+	jar    http.CookieJar
+	vars   map[string]any
+	source sourceLocation
+
+	// Names of variables bound and referenced by the scenario's steps.
+	bound      map[string]bool
+	referenced map[string]bool
+}
+
+// A ScenarioEndFunc is run when a scenario ends. It receives the values bound
+// to the scenario's variable store and the result of the scenario, allowing it
+// to clean up anything that was created during the scenario.
+type ScenarioEndFunc func(vars map[string]any, result *ScenarioResult) error
+
+// A ScenarioResult represents the result of running a scenario.
+type ScenarioResult struct {
+	// StepResults are the results of each step that was executed.
+	StepResults []TestResult `json:"step_results"`
+
+	// Skipped is the number of steps that were skipped due to an earlier failure.
+	Skipped int `json:"skipped"`
+
+	scenario *Scenario
+	failures []error
+}
+
+var _ TestCase = &Scenario{}
+var _ TestResult = &ScenarioResult{}
+
+// NewScenario creates a new Scenario with the given name.
+func NewScenario(name string) *Scenario {
+	return &Scenario{
+		Name:   name,
+		Steps:  []TestCase{},
+		vars:   map[string]any{},
+		source: callerLocation(),
+	}
+}
+
+// Action returns a short, uppercase verb describing the action performed by the
+// scenario.
+func (s *Scenario) Action() string {
+	return "SCENARIO"
+}
+
+// AddSteps adds one or more steps to the scenario.
+func (s *Scenario) AddSteps(steps ...TestCase) *Scenario {
+	s.Steps = append(s.Steps, steps...)
+	return s
+}
+
+// After registers a function to be run after the last step of the scenario.
+func (s *Scenario) After(after func() error) *Scenario {
+	s.AfterFunc = after
+	return s
+}
+
+// Before registers a function to be run before the first step of the scenario.
+func (s *Scenario) Before(before func() error) *Scenario {
+	s.BeforeFunc = before
+	return s
+}
+
+// Bind creates a predicate that stores the value it is applied to in the
+// scenario's variable store under the given name.
+func (s *Scenario) Bind(name string) expect.Predicate {
+	if s.bound == nil {
+		s.bound = map[string]bool{}
+	}
+	s.bound[name] = true
+
+	return func(actual any) error {
+		s.vars[name] = actual
+		return nil
+	}
+}
+
+// Description returns a string describing the scenario.
+func (s *Scenario) Description() string {
+	return s.Name
+}
+
+// Execute runs each step of the scenario in order.
+func (s *Scenario) Execute() TestResult {
+	result := &ScenarioResult{
+		scenario: s,
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return result.addFailures(err)
+	}
+	s.jar = jar
+	s.vars = map[string]any{}
+
+	// a failed BeforeFunc skips the steps, but not the scenario's cleanup
+	steps := s.Steps
+	if s.BeforeFunc != nil {
+		if err := callSafely(s.BeforeFunc); err != nil {
+			result.Skipped = len(s.Steps)
+			result.addFailures(err)
+			steps = nil
+		}
+	}
+
+	for i, step := range steps {
+		if tc, ok := step.(*HTTPTestCase); ok {
+			tc.jar = s.jar
+		}
+
+		stepResult := executeSafely(step)
+		result.StepResults = append(result.StepResults, stepResult)
+		if len(stepResult.Failures()) > 0 {
+			for _, err := range stepResult.Failures() {
+				result.addFailures(fmt.Errorf("step %d (%s): %w", i+1, step.Description(), err))
+			}
+
+			result.Skipped = len(s.Steps) - i - 1
+			break
+		}
+	}
+
+	for i := len(s.EndHooks) - 1; i >= 0; i-- {
+		hook := s.EndHooks[i]
+		if err := callSafely(func() error { return hook(s.Vars(), result) }); err != nil {
+			result.addFailures(fmt.Errorf("scenario end hook: %w", err))
+		}
+	}
+
+	if s.AfterFunc != nil {
+		if err := callSafely(s.AfterFunc); err != nil {
+			result.addFailures(err)
+		}
+	}
+
+	return result
+}
+
+// OnScenarioEnd registers a function to be run when the scenario ends, even if
+// a step failed. Hooks are run in the reverse order they were registered, after
+// all steps have run but before the scenario's AfterFunc.
+//
+// Hooks can use the bound variables to remove resources created by earlier
+// steps, for example:
+//
+//	s.OnScenarioEnd(func(vars map[string]any, _ *mt.ScenarioResult) error {
+//		if id, ok := vars["user_id"]; ok {
+//			return deleteUser(id)
+//		}
+//		return nil
+//	})
+func (s *Scenario) OnScenarioEnd(hook ScenarioEndFunc) *Scenario {
+	s.EndHooks = append(s.EndHooks, hook)
+	return s
+}
+
+// CleanupWith registers an end hook that executes a cleanup test case built from
+// the bound variables, such as a DELETE request for a created resource. If build
+// returns nil, no cleanup is performed. Any failure of the cleanup test case is
+// reported as a failure of the scenario.
+func (s *Scenario) CleanupWith(build func(vars map[string]any) TestCase) *Scenario {
+	return s.OnScenarioEnd(func(vars map[string]any, _ *ScenarioResult) error {
+		tc := build(vars)
+		if tc == nil {
+			return nil
+		}
+
+		if step, ok := tc.(*HTTPTestCase); ok {
+			step.jar = s.jar
+		}
+
+		if failures := executeSafely(tc).Failures(); len(failures) > 0 {
+			return fmt.Errorf("cleanup %q failed: %w", tc.Description(), failures[0])
+		}
+
+		return nil
+	})
+}
+
+// Target returns a string representing the target of the scenario.
+func (s *Scenario) Target() string {
+	return fmt.Sprintf("%d steps", len(s.Steps))
+}
+
+// Var returns a deferred value that resolves to the scenario variable with the
+// given name when the step using it is executed.
+func (s *Scenario) Var(name string) func() (any, error) {
+	if s.referenced == nil {
+		s.referenced = map[string]bool{}
+	}
+	s.referenced[name] = true
+
+	return func() (any, error) {
+		v, ok := s.vars[name]
+		if !ok {
+			return nil, fmt.Errorf("scenario variable %q is not bound", name)
+		}
+
+		return v, nil
+	}
+}
+
+// Vars returns a copy of the scenario's variable store.
+func (s *Scenario) Vars() map[string]any {
+	vars := make(map[string]any, len(s.vars))
+	for k, v := range s.vars {
+		vars[k] = v
+	}
+
+	return vars
+}
+
+// closeIdleConnections closes the idle connections held by the scenario's steps.
+func (s *Scenario) closeIdleConnections() {
+	for _, step := range s.Steps {
+		if closer, ok := step.(idleConnectionCloser); ok {
+			closer.closeIdleConnections()
+		}
+	}
+}
+
+// Failures returns a list of scenario failures, including the failures of any
+// failed step.
+func (r *ScenarioResult) Failures() []error {
+	return r.failures
+}
+
+// TestCase returns a reference to the scenario that generated the result.
+func (r *ScenarioResult) TestCase() TestCase {
+	return r.scenario
+}
+
+// Warnings returns a list of problems reported by the scenario's steps without
+// causing the scenario to fail.
+func (r *ScenarioResult) Warnings() []error {
+	var warnings []error
+	for i, stepResult := range r.StepResults {
+		for _, err := range testWarnings(stepResult) {
+			warnings = append(warnings, fmt.Errorf("step %d (%s): %w", i+1, stepResult.TestCase().Description(), err))
+		}
+	}
+
+	return warnings
+}
+
+func (r *ScenarioResult) addFailures(errs ...error) *ScenarioResult {
+	r.failures = append(r.failures, errs...)
+	return r
+}
@@ -0,0 +1,99 @@
+package mt_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jefflinse/melatonin/mt"
+	"github.com/stretchr/testify/assert"
+)
+
+func sessionHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+	})
+	mux.HandleFunc("/me", func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "abc" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	return mux
+}
+
+func TestScenarioSharesCookies(t *testing.T) {
+	server := httptest.NewServer(sessionHandler())
+	defer server.Close()
+
+	for name, ctx := range map[string]*mt.HTTPTestContext{
+		"handler": mt.NewHandlerContext(sessionHandler()),
+		"url":     mt.NewURLContext(server.URL),
+	} {
+		t.Run(name, func(t *testing.T) {
+			cleanedUp := false
+			scenario := mt.NewScenario("session").AddSteps(
+				ctx.POST("/login").ExpectStatus(http.StatusOK),
+				ctx.GET("/me").ExpectStatus(http.StatusOK),
+			).CleanupWith(func(map[string]any) mt.TestCase {
+				cleanedUp = true
+				return ctx.GET("/me").ExpectStatus(http.StatusOK)
+			})
+
+			result := mt.NewTestRunner().RunTests(scenario)
+			assert.True(t, cleanedUp)
+			assert.Equal(t, 1, result.Passed)
+			assert.Empty(t, result.TestResults[0].TestResult.Failures())
+		})
+	}
+}
+
+func TestScenarioCleanupPanic(t *testing.T) {
+	ctx := mt.NewHandlerContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/cleanup" {
+			panic("boom")
+		}
+	}))
+
+	scenario := mt.NewScenario("cleanup").AddSteps(
+		ctx.GET("/step").ExpectStatus(http.StatusOK),
+	).CleanupWith(func(map[string]any) mt.TestCase {
+		return ctx.DELETE("/cleanup")
+	})
+
+	result := mt.NewTestRunner().RunTests(scenario)
+	assert.Equal(t, 1, result.Failed)
+	failures := result.TestResults[0].TestResult.Failures()
+	if assert.Len(t, failures, 1) {
+		assert.Contains(t, failures[0].Error(), "panic: boom")
+	}
+}
+
+func TestScenarioBeforePanic(t *testing.T) {
+	ctx := mt.NewHandlerContext(statusHandler(http.StatusOK))
+
+	endHookCalled, afterCalled := false, false
+	scenario := mt.NewScenario("before").AddSteps(
+		ctx.GET("/step").ExpectStatus(http.StatusOK),
+	).Before(func() error {
+		panic("boom")
+	}).After(func() error {
+		afterCalled = true
+		return nil
+	}).OnScenarioEnd(func(map[string]any, *mt.ScenarioResult) error {
+		endHookCalled = true
+		return nil
+	})
+
+	result := mt.NewTestRunner().RunTests(scenario)
+	assert.Equal(t, 1, result.Failed)
+	assert.True(t, endHookCalled)
+	assert.True(t, afterCalled)
+
+	scenarioResult := result.TestResults[0].TestResult.(*mt.ScenarioResult)
+	assert.Equal(t, 1, scenarioResult.Skipped)
+	assert.Empty(t, scenarioResult.StepResults)
+	if failures := scenarioResult.Failures(); assert.Len(t, failures, 1) {
+		assert.Contains(t, failures[0].Error(), "panic: boom")
+	}
+}
@@ -0,0 +1,88 @@
+package mt
+
+import "fmt"
+
+// A Severity indicates how severe the failure of a test case is.
+//
+// Test cases are Critical unless specified otherwise.
+type Severity int
+
+const (
+	// Critical failures indicate the API is broken.
+	Critical Severity = iota
+
+	// Major failures indicate significant but non-blocking misbehavior.
+	Major
+
+	// Minor failures indicate cosmetic mismatches.
+	Minor
+)
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case Critical:
+		return "critical"
+	case Major:
+		return "major"
+	case Minor:
+		return "minor"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// MarshalText returns the name of the severity, so that severities are named in
+// JSON output.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+type severityLeveler interface {
+	severityLevel() Severity
+}
+
+// Severity sets the severity of the test case's failures.
+func (tc *HTTPTestCase) Severity(severity Severity) *HTTPTestCase {
+	tc.SeverityLevel = severity
+	return tc
+}
+
+func (tc *HTTPTestCase) severityLevel() Severity {
+	return tc.SeverityLevel
+}
+
+// Severity sets the severity of the scenario's failures.
+func (s *Scenario) Severity(severity Severity) *Scenario {
+	s.SeverityLevel = severity
+	return s
+}
+
+func (s *Scenario) severityLevel() Severity {
+	return s.SeverityLevel
+}
+
+// WithGatingSeverity sets the GatingSeverity field of the TestRunner and returns
+// the TestRunner.
+//
+// For example, to keep Minor failures from failing the test run:
+//
+//	runner := mt.NewTestRunner().WithGatingSeverity(mt.Major)
+func (r *TestRunner) WithGatingSeverity(severity Severity) *TestRunner {
+	r.GatingSeverity = severity
+	return r
+}
+
+// testSeverity returns the severity of a test case.
+func testSeverity(tc TestCase) Severity {
+	if leveler, ok := tc.(severityLeveler); ok {
+		return leveler.severityLevel()
+	}
+
+	return Critical
+}
+
+// isGating returns true if failures of a test case should fail the test run.
+func (r *TestRunner) isGating(tc TestCase) bool {
+	return testSeverity(tc) <= r.GatingSeverity
+}
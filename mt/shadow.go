@@ -0,0 +1,111 @@
+package mt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/jefflinse/melatonin/expect"
+	mtjson "github.com/jefflinse/melatonin/json"
+)
+
+// A shadowResponse is the response to a request sent to a shadow target.
+type shadowResponse struct {
+	status int
+	body   []byte
+	err    error
+}
+
+// WithShadow sets a shadow target that receives a copy of the request of every
+// test case created from the context, and returns the context.
+//
+// Each request is sent to the shadow target at the same time as it is sent to
+// the primary target, and to the same path. Expectations are only evaluated
+// against the primary target's response. Any difference in status or body
+// between the primary and shadow responses is reported as a warning, which
+// allows a rewritten service to be validated safely against the one it
+// replaces. Values at any of the ignored JSON paths, such as "data.*.id", are
+// excluded from the comparison.
+func (c *HTTPTestContext) WithShadow(shadow *HTTPTestContext, ignore ...string) *HTTPTestContext {
+	c.Shadow = shadow
+	c.ShadowIgnore = ignore
+	return c
+}
+
+// sendShadow sends a copy of the test case's request to the context's shadow
+// target, if any, returning a channel on which the shadow response is sent.
+func (tc *HTTPTestCase) sendShadow(body []byte) <-chan shadowResponse {
+	shadow := tc.tctx.Shadow
+	if shadow == nil || tc.streamsBody() {
+		return nil
+	}
+
+	req := tc.request.Clone(tc.request.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	responses := make(chan shadowResponse, 1)
+	if shadow.Handler == nil {
+		base, err := url.ParseRequestURI(shadow.BaseURL)
+		if err != nil {
+			responses <- shadowResponse{err: fmt.Errorf("invalid base URL %q: %s", shadow.BaseURL, err)}
+			return responses
+		}
+
+		req.URL.Scheme = base.Scheme
+		req.URL.Host = base.Host
+		req.Host = base.Host
+	}
+
+	go func() {
+		var resp *http.Response
+		var b []byte
+		var err error
+		if shadow.Handler != nil {
+			resp, b, err = handleRequest(shadow.Handler, req)
+		} else {
+			client := shadow.Client
+			if client == nil {
+				client = http.DefaultClient
+			}
+			resp, b, err = doRequest(client, req)
+		}
+
+		if err != nil {
+			responses <- shadowResponse{err: err}
+			return
+		}
+
+		responses <- shadowResponse{status: resp.StatusCode, body: b}
+	}()
+
+	return responses
+}
+
+// compareShadow adds a warning to the result for each difference between the
+// primary response and the shadow response.
+func (r *HTTPTestCaseResult) compareShadow(shadow shadowResponse) {
+	if shadow.err != nil {
+		r.addWarnings(fmt.Errorf("shadow: failed to execute HTTP request: %w", shadow.err))
+		return
+	}
+
+	if shadow.status != r.Status {
+		r.addWarnings(fmt.Errorf("shadow: primary status %d, shadow status %d", r.Status, shadow.status))
+	}
+
+	ignore := r.testCase.tctx.ShadowIgnore
+	primary := mtjson.Without(toInterface(r.Body), ignore...)
+	secondary := mtjson.Without(toInterface(shadow.body), ignore...)
+	if primary == nil || secondary == nil {
+		if primary != nil || secondary != nil {
+			r.addWarnings(fmt.Errorf("shadow: primary body %+v, shadow body %+v", primary, secondary))
+		}
+		return
+	}
+
+	for _, err := range expect.CompareValues(primary, secondary, true) {
+		err.PushField("")
+		r.addWarnings(fmt.Errorf("shadow: %w", err))
+	}
+}
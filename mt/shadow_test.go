@@ -0,0 +1,51 @@
+package mt_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jefflinse/melatonin/mt"
+	"github.com/stretchr/testify/assert"
+)
+
+func jsonHandler(status int, body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	})
+}
+
+func TestShadow(t *testing.T) {
+	for _, test := range []struct {
+		name         string
+		shadow       http.Handler
+		wantWarnings []string
+	}{
+		{"identical", jsonHandler(http.StatusOK, `{"id":2,"name":"Ada"}`), nil},
+		{"different status", jsonHandler(http.StatusCreated, `{"id":1,"name":"Ada"}`), []string{
+			"shadow: primary status 200, shadow status 201",
+		}},
+		{"different body", jsonHandler(http.StatusOK, `{"id":1,"name":"Bob"}`), []string{
+			"shadow: .name: expected Ada, got Bob",
+		}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := mt.NewHandlerContext(jsonHandler(http.StatusOK, `{"id":1,"name":"Ada"}`)).
+				WithShadow(mt.NewHandlerContext(test.shadow), "id")
+
+			result := ctx.GET("/users/1").ExpectStatus(http.StatusOK).Execute().(*mt.HTTPTestCaseResult)
+			assert.Empty(t, result.Failures())
+
+			warnings := []string{}
+			for _, warning := range result.Warnings() {
+				warnings = append(warnings, warning.Error())
+			}
+			if test.wantWarnings == nil {
+				assert.Empty(t, warnings)
+			} else {
+				assert.Equal(t, test.wantWarnings, warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package mt
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"io"
+	"log"
+	"sort"
+	"time"
+)
+
+// WithShard configures the TestRunner to run only the tests belonging to one of
+// several shards, and returns the TestRunner. Shards are numbered from 0 to
+// total-1.
+//
+// Tests are partitioned deterministically, so running every shard of the same
+// tests runs each test exactly once. By default, tests are assigned to shards
+// by fingerprint. If a timing file is provided using WithTimingFile(), tests are
+// instead distributed so that each shard's total historical duration is as even
+// as possible; tests without a recorded duration are assumed to take the
+// average recorded duration.
+//
+// The JSON reports of each shard can be combined using MergeJSONResults().
+func (r *TestRunner) WithShard(index, total int) *TestRunner {
+	if total < 1 || index < 0 || index >= total {
+		log.Fatalf("invalid shard %d of %d", index, total)
+	}
+
+	r.ShardIndex = index
+	r.ShardTotal = total
+	return r
+}
+
+// isSharded returns true if the TestRunner runs only some of the tests.
+func (r *TestRunner) isSharded() bool {
+	return r.ShardTotal > 1
+}
+
+// inShard returns true if a test belongs to the TestRunner's shard.
+func (r *TestRunner) inShard(fingerprint string) bool {
+	if !r.isSharded() {
+		return true
+	}
+
+	if r.shard != nil {
+		return r.shard[fingerprint]
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(fingerprint))
+	return int(h.Sum32()%uint32(r.ShardTotal)) == r.ShardIndex
+}
+
+// assignShard determines which tests of a group belong to the TestRunner's
+// shard, balancing shards by historical duration.
+func (r *TestRunner) assignShard(group *TestGroup) {
+	r.shard = nil
+	if !r.isSharded() || len(r.timings) == 0 {
+		return
+	}
+
+	var fingerprints []string
+	seen := map[string]bool{}
+	var collect func(*TestGroup)
+	collect = func(g *TestGroup) {
+		for _, test := range g.Tests {
+			fingerprint := Fingerprint(test)
+			if !seen[fingerprint] {
+				seen[fingerprint] = true
+				fingerprints = append(fingerprints, fingerprint)
+			}
+		}
+
+		for _, subgroup := range g.Subgroups {
+			collect(subgroup)
+		}
+	}
+	collect(group)
+
+	var total time.Duration
+	for _, timing := range r.timings {
+		total += timing.Duration
+	}
+	average := total / time.Duration(len(r.timings))
+
+	durations := make(map[string]time.Duration, len(fingerprints))
+	for _, fingerprint := range fingerprints {
+		durations[fingerprint] = average
+		if timing, ok := r.timings[fingerprint]; ok {
+			durations[fingerprint] = timing.Duration
+		}
+	}
+
+	// assign the longest tests first, each to the least loaded shard
+	sort.Slice(fingerprints, func(i, j int) bool {
+		if durations[fingerprints[i]] != durations[fingerprints[j]] {
+			return durations[fingerprints[i]] > durations[fingerprints[j]]
+		}
+		return fingerprints[i] < fingerprints[j]
+	})
+
+	loads := make([]time.Duration, r.ShardTotal)
+	r.shard = map[string]bool{}
+	for _, fingerprint := range fingerprints {
+		least := 0
+		for i := range loads {
+			if loads[i] < loads[least] {
+				least = i
+			}
+		}
+
+		loads[least] += durations[fingerprint]
+		if least == r.ShardIndex {
+			r.shard[fingerprint] = true
+		}
+	}
+}
+
+// MergeJSONResults combines the JSON reports of several shards, as printed when
+// MELATONIN_OUTPUT=json, into a single JSON report written to w.
+//
+// Groups with the same name are combined into one group. Their results, run
+// failures, leaked resources, deprecations, budget violations, and duration
+// regressions are concatenated, their run metadata is combined, keeping the
+// first shard's value of any conflicting key, and the duration of the combined
+// group is that of the longest-running shard. Since service level objectives
+// cannot be evaluated from a partial test run, SLO reports are omitted from
+// combined groups.
+func MergeJSONResults(w io.Writer, reports ...io.Reader) error {
+	type jsonShardGroup struct {
+		Name        string            `json:"name"`
+		Metadata    map[string]string `json:"metadata,omitempty"`
+		Duration    time.Duration     `json:"duration"`
+		Results     []json.RawMessage `json:"results"`
+		RunFailures []string          `json:"run_failures,omitempty"`
+		Leaked      []json.RawMessage `json:"leaked_resources,omitempty"`
+		Deprecated  []json.RawMessage `json:"deprecations,omitempty"`
+		Budget      []json.RawMessage `json:"budget_violations,omitempty"`
+		Slower      []json.RawMessage `json:"duration_regressions,omitempty"`
+		SLO         json.RawMessage   `json:"slo,omitempty"`
+	}
+
+	var merged []*jsonShardGroup
+	byName := map[string]*jsonShardGroup{}
+	for _, report := range reports {
+		var output struct {
+			Groups []*jsonShardGroup `json:"groups"`
+		}
+		if err := json.NewDecoder(report).Decode(&output); err != nil {
+			return err
+		}
+
+		for _, group := range output.Groups {
+			existing, ok := byName[group.Name]
+			if !ok {
+				byName[group.Name] = group
+				merged = append(merged, group)
+				continue
+			}
+
+			if group.Duration > existing.Duration {
+				existing.Duration = group.Duration
+			}
+			for key, value := range group.Metadata {
+				if _, ok := existing.Metadata[key]; !ok {
+					if existing.Metadata == nil {
+						existing.Metadata = map[string]string{}
+					}
+					existing.Metadata[key] = value
+				}
+			}
+			existing.Results = append(existing.Results, group.Results...)
+			existing.RunFailures = append(existing.RunFailures, group.RunFailures...)
+			existing.Leaked = append(existing.Leaked, group.Leaked...)
+			existing.Deprecated = append(existing.Deprecated, group.Deprecated...)
+			existing.Budget = append(existing.Budget, group.Budget...)
+			existing.Slower = append(existing.Slower, group.Slower...)
+			existing.SLO = nil
+		}
+	}
+
+	if merged == nil {
+		merged = []*jsonShardGroup{}
+	}
+
+	return json.NewEncoder(w).Encode(struct {
+		Groups []*jsonShardGroup `json:"groups"`
+	}{merged})
+}
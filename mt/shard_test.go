@@ -0,0 +1,28 @@
+package mt_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jefflinse/melatonin/mt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardsCountOnlyTheirOwnTests(t *testing.T) {
+	ctx := mt.NewHandlerContext(statusHandler(http.StatusOK))
+	tests := []mt.TestCase{}
+	for i := 0; i < 20; i++ {
+		tests = append(tests, ctx.GET(fmt.Sprintf("/%d", i)).ExpectStatus(http.StatusTeapot))
+	}
+
+	failed, skipped := 0, 0
+	for shard := 0; shard < 2; shard++ {
+		result := mt.NewTestRunner().WithShard(shard, 2).RunTests(tests...)
+		assert.Equal(t, 1, result.Failed, "shard %d", shard)
+		failed += result.Failed
+		skipped += result.Skipped
+	}
+
+	assert.Equal(t, len(tests), failed+skipped)
+}
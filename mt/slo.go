@@ -0,0 +1,137 @@
+package mt
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// A ServiceLevelObjective defines the availability and latency objectives that
+// a test run is evaluated against, independent of individual test failures.
+type ServiceLevelObjective struct {
+	// Availability is the minimum fraction of requests, between 0 and 1, that
+	// must receive a non-5xx response.
+	Availability float64
+
+	// LatencyP99 is the maximum 99th percentile test duration.
+	LatencyP99 time.Duration
+
+	// WarnOnly indicates whether a missed objective should only be reported as a
+	// warning instead of failing the test run.
+	WarnOnly bool
+}
+
+// An SLOReport is the evaluation of a test run against its service level
+// objective.
+type SLOReport struct {
+	// Requests is the number of requests made during the test run.
+	Requests int `json:"requests"`
+
+	// Availability is the fraction of requests that received a non-5xx response.
+	Availability float64 `json:"availability"`
+
+	// LatencyP99 is the 99th percentile test duration.
+	LatencyP99 time.Duration `json:"latency_p99"`
+
+	// Violations describe each objective that was not met.
+	Violations []string `json:"violations,omitempty"`
+}
+
+// Met returns true if all objectives were met.
+func (r *SLOReport) Met() bool {
+	return len(r.Violations) == 0
+}
+
+// WithSLO sets the service level objective that the test run is evaluated
+// against, and returns the TestRunner. A zero latency disables the latency
+// objective.
+func (r *TestRunner) WithSLO(availability float64, latencyP99 time.Duration) *TestRunner {
+	r.SLO = &ServiceLevelObjective{
+		Availability: availability,
+		LatencyP99:   latencyP99,
+	}
+	return r
+}
+
+// WithSLOWarnOnly sets whether a missed service level objective only produces a
+// warning instead of failing the test run, and returns the TestRunner. It has
+// no effect unless an objective is set using WithSLO().
+func (r *TestRunner) WithSLOWarnOnly(warnOnly bool) *TestRunner {
+	if r.SLO != nil {
+		r.SLO.WarnOnly = warnOnly
+	}
+	return r
+}
+
+// evaluateSLO evaluates a test run against the runner's service level objective.
+func (r *TestRunner) evaluateSLO(result *GroupRunResult) *SLOReport {
+	report := &SLOReport{}
+	available := 0
+	durations := []time.Duration{}
+	forEachTestRunResult(result, func(testResult TestRunResult) {
+		durations = append(durations, testResult.Duration)
+		for _, httpResult := range httpResults(testResult.TestResult) {
+			report.Requests++
+			if httpResult.Status > 0 && httpResult.Status < 500 {
+				available++
+			}
+		}
+	})
+
+	if report.Requests > 0 {
+		report.Availability = float64(available) / float64(report.Requests)
+	}
+
+	report.LatencyP99 = percentile(durations, 0.99)
+
+	if report.Requests > 0 && report.Availability < r.SLO.Availability {
+		report.Violations = append(report.Violations, fmt.Sprintf(
+			"availability %.3f%% is below objective of %.3f%%",
+			report.Availability*100, r.SLO.Availability*100))
+	}
+
+	if r.SLO.LatencyP99 > 0 && report.LatencyP99 > r.SLO.LatencyP99 {
+		report.Violations = append(report.Violations, fmt.Sprintf(
+			"p99 latency %s exceeds objective of %s",
+			report.LatencyP99, r.SLO.LatencyP99))
+	}
+
+	return report
+}
+
+// httpResults returns the results of all HTTP requests made by a test,
+// including those made by the steps of a scenario.
+func httpResults(result TestResult) []*HTTPTestCaseResult {
+	switch result := result.(type) {
+	case *HTTPTestCaseResult:
+		return []*HTTPTestCaseResult{result}
+	case *ScenarioResult:
+		results := []*HTTPTestCaseResult{}
+		for _, stepResult := range result.StepResults {
+			results = append(results, httpResults(stepResult)...)
+		}
+		return results
+	default:
+		return nil
+	}
+}
+
+// percentile returns the pth percentile (0 < p <= 1) of a set of durations
+// using the nearest-rank method.
+func percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(p*float64(len(sorted))+0.999999) - 1
+	if rank < 0 {
+		rank = 0
+	} else if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+
+	return sorted[rank]
+}
@@ -0,0 +1,68 @@
+package mt
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const packagePath = "github.com/jefflinse/melatonin/mt"
+
+// A sourceLocation is the place in the source where a test case was defined.
+type sourceLocation struct {
+	file string
+	line int
+}
+
+// callerLocation returns the location of the first caller outside of this
+// package, which is where the user defined the test case being constructed.
+func callerLocation() sourceLocation {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, packagePath+".") {
+			return sourceLocation{file: frame.File, line: frame.Line}
+		}
+
+		if !more {
+			return sourceLocation{}
+		}
+	}
+}
+
+func (l sourceLocation) relativeFile() string {
+	if rel, err := filepath.Rel(cfg.WorkingDir, l.file); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+
+	return l.file
+}
+
+// SourceLocation returns the file and line where the test case was defined.
+func (tc *HTTPTestCase) SourceLocation() (string, int) {
+	return tc.source.relativeFile(), tc.source.line
+}
+
+// SourceLocation returns the file and line where the scenario was defined.
+func (s *Scenario) SourceLocation() (string, int) {
+	return s.source.relativeFile(), s.source.line
+}
+
+// testCaseLocation returns a file:line string describing where a test case was
+// defined, or an empty string if the location is unknown.
+func testCaseLocation(tc TestCase) string {
+	locator, ok := tc.(sourceLocator)
+	if !ok {
+		return ""
+	}
+
+	file, line := locator.SourceLocation()
+	if file == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s:%d", file, line)
+}
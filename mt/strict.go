@@ -0,0 +1,47 @@
+package mt
+
+import (
+	"fmt"
+	"testing"
+)
+
+// WithStrict sets the Strict field of the TestRunner and returns the
+// TestRunner.
+//
+// A strict test run lints all of its tests before running any of them, and
+// fails without running them if any authoring errors are found, such as a test
+// without expectations, a GET request with a body that wasn't allowed by
+// AllowGETBody(), or conflicting expectations such as ExpectBody() and
+// ExpectNoBody(). See Lint() for all of the checks.
+func (r *TestRunner) WithStrict(strict bool) *TestRunner {
+	r.Strict = strict
+	return r
+}
+
+// runCheckedGroup runs a test group, unless the test run is strict and the
+// group's tests have problems, in which case a result with a run failure for
+// each problem is returned.
+func (r *TestRunner) runCheckedGroup(t *testing.T, group *TestGroup) *GroupRunResult {
+	if r.Strict {
+		if diagnostics := Lint(groupTests(group)...); len(diagnostics) > 0 {
+			result := &GroupRunResult{Group: group}
+			for _, diagnostic := range diagnostics {
+				result.RunFailures = append(result.RunFailures, fmt.Errorf("strict: %s", diagnostic))
+			}
+
+			return result
+		}
+	}
+
+	return r.runGroup(t, group)
+}
+
+// groupTests returns the tests of a group and, recursively, its subgroups.
+func groupTests(group *TestGroup) []TestCase {
+	tests := append([]TestCase{}, group.Tests...)
+	for _, subgroup := range group.Subgroups {
+		tests = append(tests, groupTests(subgroup)...)
+	}
+
+	return tests
+}
@@ -0,0 +1,88 @@
+package mt
+
+import (
+	"github.com/jefflinse/melatonin/expect"
+)
+
+// Strictness controls which relaxations of body comparisons are tolerated,
+// allowing strictness to be dialed up for all tests of a context or run instead
+// of for each test case.
+type Strictness struct {
+	// UnknownFields determines how response body fields that are not expected
+	// are treated when the body is not matched exactly.
+	//
+	// Default is expect.AllowUnknownFields.
+	UnknownFields expect.UnknownFieldPolicy
+
+	// NullIsNotMissing indicates whether an expected null body value requires
+	// the field to be present with a null value, rather than also matching a
+	// missing field.
+	//
+	// Default is false.
+	NullIsNotMissing bool
+}
+
+// WithStrictness sets the body comparison strictness for all test cases
+// created from the context and returns the context. It takes precedence over
+// any strictness set on the test runner.
+func (c *HTTPTestContext) WithStrictness(strictness Strictness) *HTTPTestContext {
+	c.Strictness = &strictness
+	return c
+}
+
+// WithStrictness sets the body comparison strictness for all HTTP tests in the
+// test run whose context does not set its own, and returns the TestRunner.
+func (r *TestRunner) WithStrictness(strictness Strictness) *TestRunner {
+	r.Strictness = &strictness
+	return r
+}
+
+// A strictnessDefaulter is a test case that accepts a default strictness from
+// the test runner.
+type strictnessDefaulter interface {
+	setDefaultStrictness(strictness *Strictness)
+}
+
+func (tc *HTTPTestCase) setDefaultStrictness(strictness *Strictness) {
+	tc.defaultStrictness = strictness
+}
+
+func (s *Scenario) setDefaultStrictness(strictness *Strictness) {
+	for _, step := range s.Steps {
+		if defaulter, ok := step.(strictnessDefaulter); ok {
+			defaulter.setDefaultStrictness(strictness)
+		}
+	}
+}
+
+// compareOptions returns the options used to compare the expected and actual
+// response bodies of the test case.
+func (tc *HTTPTestCase) compareOptions(exact bool) expect.CompareOptions {
+	opts := expect.CompareOptions{ExactJSON: exact}
+	strictness := tc.defaultStrictness
+	if tc.tctx != nil && tc.tctx.Strictness != nil {
+		strictness = tc.tctx.Strictness
+	}
+
+	if strictness != nil {
+		opts.UnknownFields = strictness.UnknownFields
+		opts.NullIsNotMissing = strictness.NullIsNotMissing
+	}
+
+	return opts
+}
+
+// A warner is a test result that can report warnings, which are problems that
+// are reported without causing the test to fail.
+type warner interface {
+	Warnings() []error
+}
+
+// testWarnings returns the warnings of a test result, if it reports any.
+func testWarnings(result TestResult) []error {
+	if w, ok := result.(warner); ok {
+		return w.Warnings()
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package mt
+
+import (
+	"strings"
+	"time"
+)
+
+// A Summary aggregates the results of a test run for common reporting needs.
+// See GroupRunResult.Summary().
+type Summary struct {
+	// Total is the total number of tests.
+	Total int `json:"total"`
+
+	// Passed is the number of tests that passed.
+	Passed int `json:"passed"`
+
+	// Failed is the number of tests that failed.
+	Failed int `json:"failed"`
+
+	// Skipped is the number of tests that were skipped.
+	Skipped int `json:"skipped"`
+
+	// Suppressed is the number of tests that failed but whose failures were
+	// suppressed.
+	Suppressed int `json:"suppressed"`
+
+	// Resumed is the number of tests that were not run because they passed
+	// during a previous, interrupted test run.
+	Resumed int `json:"resumed"`
+
+	// Duration is the total duration of the test run.
+	Duration time.Duration `json:"duration"`
+
+	// TestDurations are statistics of the durations of the tests that were run.
+	TestDurations DurationStats `json:"test_durations"`
+
+	// FailedTests are the results of the tests that failed, in the order they
+	// were run.
+	FailedTests []TestRunResult `json:"failed_tests,omitempty"`
+
+	// FailuresByCategory is the number of failures of failed tests for each
+	// category of expectation, such as "status" or "body". Failures that are not
+	// of an expectation are counted as "other".
+	FailuresByCategory map[string]int `json:"failures_by_category,omitempty"`
+
+	// FailedByGroup are the results of the failed tests of each group, keyed by
+	// the names of the group and its parents, such as "users › create".
+	FailedByGroup map[string][]TestRunResult `json:"failed_by_group,omitempty"`
+
+	// FailedBySeverity are the results of the failed tests of each severity.
+	FailedBySeverity map[Severity][]TestRunResult `json:"failed_by_severity,omitempty"`
+}
+
+// DurationStats are statistics of a set of test durations.
+type DurationStats struct {
+	Count  int           `json:"count"`
+	Total  time.Duration `json:"total"`
+	Min    time.Duration `json:"min"`
+	Max    time.Duration `json:"max"`
+	Mean   time.Duration `json:"mean"`
+	Median time.Duration `json:"median"`
+	P95    time.Duration `json:"p95"`
+	P99    time.Duration `json:"p99"`
+}
+
+// Summary aggregates the result of a group run, including the results of all of
+// its subgroups, so that reports don't need to walk the results themselves.
+func (r *GroupRunResult) Summary() Summary {
+	summary := Summary{
+		Total:      r.Total,
+		Passed:     r.Passed,
+		Failed:     r.Failed,
+		Skipped:    r.Skipped,
+		Suppressed: r.Suppressed,
+		Resumed:    r.Resumed,
+		Duration:   r.Duration,
+	}
+
+	var durations []time.Duration
+	var walk func(result *GroupRunResult, groups []string)
+	walk = func(result *GroupRunResult, groups []string) {
+		if result.Group != nil && result.Group.Name != "" {
+			groups = append(groups, result.Group.Name)
+		}
+
+		for _, testResult := range result.TestResults {
+			durations = append(durations, testResult.Duration)
+
+			failures := testResult.TestResult.Failures()
+			if len(failures) == 0 || testResult.Suppressed != "" {
+				continue
+			}
+
+			summary.FailedTests = append(summary.FailedTests, testResult)
+			if summary.FailuresByCategory == nil {
+				summary.FailuresByCategory = map[string]int{}
+				summary.FailedByGroup = map[string][]TestRunResult{}
+				summary.FailedBySeverity = map[Severity][]TestRunResult{}
+			}
+
+			for _, failure := range failures {
+				category := "other"
+				if c, ok := CategoryOf(failure); ok {
+					category = c.String()
+				}
+				summary.FailuresByCategory[category]++
+			}
+
+			group := strings.Join(groups, " › ")
+			summary.FailedByGroup[group] = append(summary.FailedByGroup[group], testResult)
+
+			severity := testSeverity(testResult.TestCase)
+			summary.FailedBySeverity[severity] = append(summary.FailedBySeverity[severity], testResult)
+		}
+
+		for _, subgroupResult := range result.SubgroupResults {
+			walk(subgroupResult, groups)
+		}
+	}
+	walk(r, nil)
+
+	summary.TestDurations = durationStats(durations)
+	return summary
+}
+
+// durationStats computes statistics of a set of durations.
+func durationStats(durations []time.Duration) DurationStats {
+	stats := DurationStats{Count: len(durations)}
+	if len(durations) == 0 {
+		return stats
+	}
+
+	stats.Min, stats.Max = durations[0], durations[0]
+	for _, d := range durations {
+		stats.Total += d
+		if d < stats.Min {
+			stats.Min = d
+		}
+		if d > stats.Max {
+			stats.Max = d
+		}
+	}
+
+	stats.Mean = stats.Total / time.Duration(len(durations))
+	stats.Median = percentile(durations, 0.5)
+	stats.P95 = percentile(durations, 0.95)
+	stats.P99 = percentile(durations, 0.99)
+	return stats
+}
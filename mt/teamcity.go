@@ -0,0 +1,80 @@
+package mt
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+var teamCityEscaper = strings.NewReplacer(
+	"|", "||",
+	"'", "|'",
+	"\n", "|n",
+	"\r", "|r",
+	"[", "|[",
+	"]", "|]",
+)
+
+// FPrintTeamCityResults prints the results of a group run to the given io.Writer
+// as TeamCity service messages, so that each test is reported individually in
+// TeamCity builds.
+func FPrintTeamCityResults(w io.Writer, results *GroupRunResult) {
+	groupName := ""
+	if results.Group != nil {
+		groupName = results.Group.Name
+	}
+
+	fprintTeamCityMetadata(w, results.Metadata)
+	if groupName != "" {
+		fprintTeamCityMessage(w, "testSuiteStarted", "name", groupName)
+	}
+
+	for _, result := range results.TestResults {
+		name := result.TestCase.Description()
+		fprintTeamCityMessage(w, "testStarted", "name", name)
+
+		if failures := result.TestResult.Failures(); len(failures) > 0 && result.Suppressed != "" {
+			fprintTeamCityMessage(w, "testIgnored",
+				"name", name,
+				"message", fmt.Sprintf("failures suppressed (%s): %s", result.Suppressed, failures[0]))
+		} else if len(failures) > 0 {
+			details := make([]string, len(failures))
+			for i, failure := range failures {
+				details[i] = failure.Error()
+			}
+
+			if location := testCaseLocation(result.TestCase); location != "" {
+				details = append(details, "at "+location)
+			}
+
+			fprintTeamCityMessage(w, "testFailed",
+				"name", name,
+				"message", details[0],
+				"details", strings.Join(details, "\n"))
+		}
+
+		fprintTeamCityMessage(w, "testFinished",
+			"name", name,
+			"duration", fmt.Sprintf("%d", result.Duration.Milliseconds()))
+	}
+
+	for _, subgroupResult := range results.SubgroupResults {
+		FPrintTeamCityResults(w, subgroupResult)
+	}
+
+	if groupName != "" {
+		fprintTeamCityMessage(w, "testSuiteFinished", "name", groupName)
+	}
+}
+
+// fprintTeamCityMessage prints a single TeamCity service message with the given
+// attribute name/value pairs.
+func fprintTeamCityMessage(w io.Writer, messageName string, attrs ...string) {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "##teamcity[%s", messageName)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		fmt.Fprintf(b, " %s='%s'", attrs[i], teamCityEscaper.Replace(attrs[i+1]))
+	}
+	b.WriteString("]")
+	fmt.Fprintln(w, b.String())
+}
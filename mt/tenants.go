@@ -0,0 +1,39 @@
+package mt
+
+import "sort"
+
+// ForEachTenant creates a test group that runs an entire suite once per tenant,
+// with the results of each tenant's run grouped in a subgroup named after the
+// tenant. Tenants are run in order of their names.
+//
+// The suite function is called once for each tenant and should create the
+// suite's tests using the tenant's configuration, such as its credentials:
+//
+//	tenants := map[string]string{"acme": acmeToken, "globex": globexToken}
+//	group := mt.ForEachTenant("Tenant Suite", tenants, func(name, token string) *mt.TestGroup {
+//		api := mt.NewURLContext(baseURL)
+//		return mt.NewTestGroup("").AddTests(
+//			api.GET("/account").WithHeader("Authorization", "Bearer "+token).ExpectStatus(200),
+//		)
+//	})
+//	mt.RunTestGroup(group)
+func ForEachTenant[T any](name string, tenants map[string]T, suite func(tenant string, config T) *TestGroup) *TestGroup {
+	names := make([]string, 0, len(tenants))
+	for tenant := range tenants {
+		names = append(names, tenant)
+	}
+	sort.Strings(names)
+
+	group := NewTestGroup(name)
+	for _, tenant := range names {
+		tenantGroup := suite(tenant, tenants[tenant])
+		if tenantGroup == nil {
+			continue
+		}
+
+		tenantGroup.Name = tenant
+		group.AddGroups(tenantGroup)
+	}
+
+	return group
+}
@@ -0,0 +1,190 @@
+package mt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	// DefinedOrder causes the test runner to execute the tests of each group in
+	// the order they were added.
+	DefinedOrder = iota
+
+	// FastestTestsFirst causes the test runner to execute the tests of each
+	// group in order of their historical duration, fastest first.
+	FastestTestsFirst
+
+	// SlowestTestsFirst causes the test runner to execute the tests of each
+	// group in order of their historical duration, slowest first.
+	SlowestTestsFirst
+)
+
+// A timingRecord is the duration of a test case recorded during a previous
+// test run.
+type timingRecord struct {
+	Description string        `json:"description"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// A DurationRegression records a test taking longer than it did in a previous
+// test run by more than the runner's duration regression threshold.
+type DurationRegression struct {
+	// Test is the description of the test that regressed.
+	Test string `json:"test"`
+
+	// Previous is the duration of the test in the previous test run.
+	Previous time.Duration `json:"previous"`
+
+	// Duration is the duration of the test in this test run.
+	Duration time.Duration `json:"duration"`
+}
+
+func (d DurationRegression) String() string {
+	return fmt.Sprintf("%s: took %s, previously %s", d.Test, d.Duration, d.Previous)
+}
+
+// WithTimingFile sets a file of historical test durations and returns the
+// TestRunner.
+//
+// The timing file is a JSON object mapping test fingerprints to the duration
+// of each test in a previous test run. Historical durations are used to
+// balance shards, to order tests (see WithDurationOrder()), and to detect tests
+// that became slower (see WithDurationRegressionThreshold()). Once the test run
+// completes, the durations of the tests that were run are written back to the
+// file. A missing file is treated as having no timings.
+func (r *TestRunner) WithTimingFile(path string) *TestRunner {
+	timings, err := readTimingFile(path)
+	if err != nil {
+		log.Fatalf("failed to read timing file %q: %v", path, err)
+	}
+
+	r.TimingFile = path
+	r.timings = timings
+	return r
+}
+
+// WithDurationOrder sets the order in which the tests of each group are
+// executed, based on their historical duration, and returns the TestRunner.
+// Tests without a recorded duration are executed last, in the order they were
+// added.
+//
+// Requires a timing file. See WithTimingFile().
+func (r *TestRunner) WithDurationOrder(order int) *TestRunner {
+	r.DurationOrder = order
+	return r
+}
+
+// WithDurationRegressionThreshold sets the fraction by which a test may take
+// longer than it did in the previous test run before it is flagged, and returns
+// the TestRunner. For example, a threshold of 0.5 flags tests that take more
+// than 50% longer than before.
+//
+// Requires a timing file. See WithTimingFile().
+func (r *TestRunner) WithDurationRegressionThreshold(threshold float64) *TestRunner {
+	r.DurationRegressionThreshold = threshold
+	return r
+}
+
+// orderTests returns the tests of a group in the order they should be executed.
+func (r *TestRunner) orderTests(tests []TestCase) []TestCase {
+	if r.DurationOrder == DefinedOrder || len(r.timings) == 0 {
+		return tests
+	}
+
+	ordered := make([]TestCase, len(tests))
+	copy(ordered, tests)
+	durations := make(map[TestCase]time.Duration, len(tests))
+	for _, test := range tests {
+		durations[test] = -1
+		if timing, ok := r.timings[Fingerprint(test)]; ok {
+			durations[test] = timing.Duration
+		}
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		di, dj := durations[ordered[i]], durations[ordered[j]]
+		if di < 0 || dj < 0 {
+			return dj < 0 && di >= 0
+		}
+
+		if r.DurationOrder == SlowestTestsFirst {
+			return di > dj
+		}
+		return di < dj
+	})
+
+	return ordered
+}
+
+// findDurationRegressions returns the tests in a group run whose duration
+// exceeded their historical duration by more than the runner's threshold.
+func (r *TestRunner) findDurationRegressions(result *GroupRunResult) []DurationRegression {
+	regressions := []DurationRegression{}
+	forEachTestRunResult(result, func(runResult TestRunResult) {
+		previous, ok := r.timings[runResult.Fingerprint]
+		if !ok || previous.Duration <= 0 {
+			return
+		}
+
+		limit := time.Duration(float64(previous.Duration) * (1 + r.DurationRegressionThreshold))
+		if runResult.Duration > limit {
+			regressions = append(regressions, DurationRegression{
+				Test:     runResult.TestCase.Description(),
+				Previous: previous.Duration,
+				Duration: runResult.Duration,
+			})
+		}
+	})
+
+	return regressions
+}
+
+// updateTimingFile records the durations of the tests in a group run to the
+// runner's timing file, retaining the durations of tests that were not run.
+func (r *TestRunner) updateTimingFile(result *GroupRunResult) error {
+	timings := make(map[string]timingRecord, len(r.timings))
+	for fingerprint, timing := range r.timings {
+		timings[fingerprint] = timing
+	}
+
+	forEachTestRunResult(result, func(runResult TestRunResult) {
+		timings[runResult.Fingerprint] = timingRecord{
+			Description: runResult.TestCase.Description(),
+			Duration:    runResult.Duration,
+		}
+	})
+
+	b, err := json.MarshalIndent(timings, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(r.TimingFile, append(b, '\n'), 0644); err != nil {
+		return err
+	}
+
+	r.timings = timings
+	return nil
+}
+
+// readTimingFile reads a file of test durations, keyed by fingerprint.
+func readTimingFile(path string) (map[string]timingRecord, error) {
+	timings := map[string]timingRecord{}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return timings, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &timings); err != nil {
+		return nil, err
+	}
+
+	return timings, nil
+}
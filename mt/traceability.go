@@ -0,0 +1,159 @@
+package mt
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// A RequirementCoverage maps a requirement ID to the tests that cover it.
+type RequirementCoverage struct {
+	// Requirement is the requirement ID.
+	Requirement string `json:"requirement"`
+
+	// Tests are the latest results of each test covering the requirement.
+	Tests []CoveringTest `json:"tests"`
+}
+
+// A CoveringTest is the latest result of a test covering a requirement.
+type CoveringTest struct {
+	// Description is the description of the test.
+	Description string `json:"description"`
+
+	// Passed indicates whether the latest run of the test passed.
+	Passed bool `json:"passed"`
+}
+
+// Satisfied returns true if the requirement is covered by at least one test and
+// all covering tests passed.
+func (c RequirementCoverage) Satisfied() bool {
+	if len(c.Tests) == 0 {
+		return false
+	}
+
+	for _, test := range c.Tests {
+		if !test.Passed {
+			return false
+		}
+	}
+
+	return true
+}
+
+type requirementsCoverer interface {
+	Requirements() []string
+}
+
+// Covers declares that the test case covers one or more requirements, for use
+// in traceability reports.
+func (tc *HTTPTestCase) Covers(requirements ...string) *HTTPTestCase {
+	tc.RequirementIDs = append(tc.RequirementIDs, requirements...)
+	return tc
+}
+
+// Requirements returns the IDs of the requirements covered by the test case.
+func (tc *HTTPTestCase) Requirements() []string {
+	return tc.RequirementIDs
+}
+
+// Covers declares that the scenario covers one or more requirements, for use
+// in traceability reports.
+func (s *Scenario) Covers(requirements ...string) *Scenario {
+	s.RequirementIDs = append(s.RequirementIDs, requirements...)
+	return s
+}
+
+// Requirements returns the IDs of the requirements covered by the scenario.
+func (s *Scenario) Requirements() []string {
+	return s.RequirementIDs
+}
+
+// TraceabilityReport maps each requirement covered by the tests in a group run
+// to the tests covering it and their latest result. Requirements are sorted by ID.
+func TraceabilityReport(results *GroupRunResult) []RequirementCoverage {
+	type coveringTestKey struct {
+		requirement string
+		test        TestCase
+	}
+
+	type latestResult struct {
+		passed    bool
+		startedAt time.Time
+	}
+
+	order := []coveringTestKey{}
+	latest := map[coveringTestKey]latestResult{}
+	forEachTestRunResult(results, func(result TestRunResult) {
+		coverer, ok := result.TestCase.(requirementsCoverer)
+		if !ok {
+			return
+		}
+
+		for _, requirement := range coverer.Requirements() {
+			key := coveringTestKey{requirement, result.TestCase}
+			previous, seen := latest[key]
+			if !seen {
+				order = append(order, key)
+			} else if result.StartedAt.Before(previous.startedAt) {
+				continue
+			}
+
+			latest[key] = latestResult{
+				passed:    len(result.TestResult.Failures()) == 0,
+				startedAt: result.StartedAt,
+			}
+		}
+	})
+
+	byRequirement := map[string]*RequirementCoverage{}
+	for _, key := range order {
+		coverage, ok := byRequirement[key.requirement]
+		if !ok {
+			coverage = &RequirementCoverage{Requirement: key.requirement}
+			byRequirement[key.requirement] = coverage
+		}
+
+		coverage.Tests = append(coverage.Tests, CoveringTest{
+			Description: key.test.Description(),
+			Passed:      latest[key].passed,
+		})
+	}
+
+	report := make([]RequirementCoverage, 0, len(byRequirement))
+	for _, coverage := range byRequirement {
+		report = append(report, *coverage)
+	}
+
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].Requirement < report[j].Requirement
+	})
+
+	return report
+}
+
+// FPrintTraceabilityReport prints a traceability matrix for a group run to the
+// given io.Writer.
+func FPrintTraceabilityReport(w io.Writer, results *GroupRunResult) {
+	for _, coverage := range TraceabilityReport(results) {
+		status := greenFG("✔")
+		if !coverage.Satisfied() {
+			status = redFGBold("✘")
+		}
+
+		fmt.Fprintf(w, "%s %s\n", status, cyanFG(coverage.Requirement))
+		for _, test := range coverage.Tests {
+			result := greenFG("passed")
+			if !test.Passed {
+				result = redFG("failed")
+			}
+
+			fmt.Fprintf(w, "%s%s %s\n", faintFG(indentationPrefix), whiteFG(test.Description), result)
+		}
+	}
+}
+
+// PrintTraceabilityReport prints a traceability matrix for a group run to stdout.
+func PrintTraceabilityReport(results *GroupRunResult) {
+	FPrintTraceabilityReport(cfg.Stdout, results)
+}
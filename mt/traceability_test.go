@@ -0,0 +1,41 @@
+package mt_test
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/jefflinse/melatonin/mt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraceabilityReport(t *testing.T) {
+	ctx := mt.NewHandlerContext(statusHandler(http.StatusOK))
+	results := mt.NewTestRunner().WithContinueOnFailure(true).RunTests(
+		ctx.GET("/login", "log in").Covers("AUTH-1", "AUTH-2").ExpectStatus(http.StatusOK),
+		mt.NewScenario("log out").Covers("AUTH-2").AddSteps(
+			ctx.POST("/logout").ExpectStatus(http.StatusNoContent),
+		),
+		ctx.GET("/health", "health").ExpectStatus(http.StatusOK),
+	)
+
+	report := mt.TraceabilityReport(results)
+	assert.Equal(t, []mt.RequirementCoverage{
+		{Requirement: "AUTH-1", Tests: []mt.CoveringTest{{Description: "log in", Passed: true}}},
+		{Requirement: "AUTH-2", Tests: []mt.CoveringTest{
+			{Description: "log in", Passed: true},
+			{Description: "log out", Passed: false},
+		}},
+	}, report)
+	assert.True(t, report[0].Satisfied())
+	assert.False(t, report[1].Satisfied())
+	assert.False(t, mt.RequirementCoverage{Requirement: "AUTH-3"}.Satisfied())
+
+	w := &bytes.Buffer{}
+	mt.FPrintTraceabilityReport(w, results)
+	assert.Equal(t, "✔ AUTH-1\n"+
+		"│ log in passed\n"+
+		"✘ AUTH-2\n"+
+		"│ log in passed\n"+
+		"│ log out failed\n", w.String())
+}
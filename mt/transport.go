@@ -0,0 +1,90 @@
+package mt
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+)
+
+// ExpectProto sets the expected protocol version of the response, such as
+// "HTTP/1.1" or "HTTP/2.0".
+func (tc *HTTPTestCase) ExpectProto(proto string) *HTTPTestCase {
+	tc.Expectations.Proto = proto
+	return tc
+}
+
+// ExpectTLSCipherSuite sets the expected cipher suite negotiated for the TLS
+// connection, such as tls.TLS_AES_128_GCM_SHA256.
+func (tc *HTTPTestCase) ExpectTLSCipherSuite(suite uint16) *HTTPTestCase {
+	tc.Expectations.TLSCipherSuite = suite
+	return tc
+}
+
+// ExpectTLSVersion sets the expected version negotiated for the TLS connection,
+// such as tls.VersionTLS13.
+func (tc *HTTPTestCase) ExpectTLSVersion(version uint16) *HTTPTestCase {
+	tc.Expectations.TLSVersion = version
+	return tc
+}
+
+// ExpectTrailer adds a single expected HTTP response trailer for the test case.
+func (tc *HTTPTestCase) ExpectTrailer(key, value string) *HTTPTestCase {
+	if tc.Expectations.Trailers == nil {
+		tc.Expectations.Trailers = http.Header{}
+	}
+
+	tc.Expectations.Trailers.Add(key, value)
+	return tc
+}
+
+// validateTransport compares the protocol, TLS, and trailer metadata of the
+// response against a set of expectations and returns any failures.
+func (r *HTTPTestCaseResult) validateTransport(expectations expectatons) []error {
+	var errs []error
+	if expectations.Proto != "" && expectations.Proto != r.Proto {
+		errs = append(errs, fmt.Errorf("expected protocol %s, got %s", expectations.Proto, r.Proto))
+	}
+
+	if expectations.TLSVersion != 0 || expectations.TLSCipherSuite != 0 {
+		if r.TLS == nil {
+			errs = append(errs, fmt.Errorf("expected TLS connection, got unencrypted connection"))
+			return errs
+		}
+
+		if expectations.TLSVersion != 0 && expectations.TLSVersion != r.TLS.Version {
+			errs = append(errs, fmt.Errorf("expected TLS version %s, got %s",
+				tlsVersionName(expectations.TLSVersion), tlsVersionName(r.TLS.Version)))
+		}
+
+		if expectations.TLSCipherSuite != 0 && expectations.TLSCipherSuite != r.TLS.CipherSuite {
+			errs = append(errs, fmt.Errorf("expected TLS cipher suite %s, got %s",
+				tls.CipherSuiteName(expectations.TLSCipherSuite), tls.CipherSuiteName(r.TLS.CipherSuite)))
+		}
+	}
+
+	errs = append(errs, r.validateCertificate(expectations.Certificate)...)
+
+	if expectations.Trailers != nil {
+		for _, err := range compareHeaders(expectations.Trailers, r.Trailers) {
+			errs = append(errs, fmt.Errorf("trailers: %w", err))
+		}
+	}
+
+	return errs
+}
+
+// tlsVersionName returns the name of a TLS version.
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	default:
+		return fmt.Sprintf("0x%04X", version)
+	}
+}
@@ -0,0 +1,91 @@
+package mt
+
+import (
+	"io"
+)
+
+// A bodyReader is a request body streamed from a reader rather than held in
+// memory. See WithBodyReader().
+type bodyReader struct {
+	reader        io.Reader
+	contentLength int64
+}
+
+// An UploadProgressFunc is called as a streamed request body is sent, with the
+// number of bytes sent so far and the total length of the body, which is -1 if
+// it is unknown.
+type UploadProgressFunc func(sent, total int64)
+
+// WithBodyReader sets the request body to be streamed from a reader, such as a
+// large file opened from disk, rather than loaded into memory. If the content
+// length is negative, the length of the body is unknown and it is sent using
+// chunked transfer encoding.
+//
+// The reader is consumed by the first execution of the test case, so a streamed
+// body is never memoized or sent to a shadow. If the reader is also an
+// io.Closer, it is closed once the request has been sent.
+func (tc *HTTPTestCase) WithBodyReader(r io.Reader, contentLength int64) *HTTPTestCase {
+	tc.requestBody = &bodyReader{reader: r, contentLength: contentLength}
+	return tc
+}
+
+// WithUploadProgress adds a hook called as a request body set by
+// WithBodyReader() is sent, for observing the progress of large uploads.
+func (tc *HTTPTestCase) WithUploadProgress(progress UploadProgressFunc) *HTTPTestCase {
+	tc.uploadProgress = append(tc.uploadProgress, progress)
+	return tc
+}
+
+// streamsBody returns whether the test case's request body is streamed from a
+// reader.
+func (tc *HTTPTestCase) streamsBody() bool {
+	_, ok := tc.requestBody.(*bodyReader)
+	return ok
+}
+
+// prepareStreamedBody sets the underlying request to stream the test case's
+// body from its reader.
+func (tc *HTTPTestCase) prepareStreamedBody(body *bodyReader) {
+	var r io.Reader = body.reader
+	if len(tc.uploadProgress) > 0 {
+		r = &progressReader{reader: r, total: body.contentLength, hooks: tc.uploadProgress}
+	}
+
+	if closer, ok := body.reader.(io.Closer); ok {
+		tc.request.Body = readCloser{r, closer}
+	} else {
+		tc.request.Body = io.NopCloser(r)
+	}
+
+	tc.request.ContentLength = body.contentLength
+	tc.request.TransferEncoding = nil
+	if body.contentLength < 0 {
+		tc.request.ContentLength = -1
+		tc.request.TransferEncoding = []string{"chunked"}
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// A progressReader reports the progress of reading a request body.
+type progressReader struct {
+	reader io.Reader
+	sent   int64
+	total  int64
+	hooks  []UploadProgressFunc
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.sent += int64(n)
+		for _, hook := range r.hooks {
+			hook(r.sent, r.total)
+		}
+	}
+
+	return n, err
+}
@@ -0,0 +1,53 @@
+package mt
+
+import "fmt"
+
+// defaultUserAgent returns the User-Agent sent by test cases that don't set
+// one, identifying melatonin and, if set, the name of the test suite.
+func defaultUserAgent() string {
+	if cfg.SuiteName != "" {
+		return fmt.Sprintf("melatonin (%s)", cfg.SuiteName)
+	}
+
+	return "melatonin"
+}
+
+// WithUserAgent sets the User-Agent sent by all test cases created from the
+// context, and returns the context.
+//
+// By default, test cases identify themselves as "melatonin", followed by the
+// name of the test suite in parentheses if the MELATONIN_SUITE_NAME environment
+// variable is set, so that test traffic can be distinguished by the server.
+func (c *HTTPTestContext) WithUserAgent(userAgent string) *HTTPTestContext {
+	c.UserAgent = userAgent
+	return c
+}
+
+// WithUserAgent sets the User-Agent sent by the test case, overriding any set
+// by the context, and returns the test case.
+func (tc *HTTPTestCase) WithUserAgent(userAgent string) *HTTPTestCase {
+	tc.userAgent = userAgent
+	return tc
+}
+
+// applyUserAgent sets the User-Agent header of the test case's request unless
+// it was set explicitly, returning a function that restores the request's
+// original headers.
+func (tc *HTTPTestCase) applyUserAgent() func() {
+	if tc.request.Header.Get("User-Agent") != "" {
+		return func() {}
+	}
+
+	userAgent := tc.userAgent
+	if userAgent == "" {
+		userAgent = tc.tctx.UserAgent
+	}
+	if userAgent == "" {
+		userAgent = defaultUserAgent()
+	}
+
+	tc.request.Header.Set("User-Agent", userAgent)
+	return func() {
+		tc.request.Header.Del("User-Agent")
+	}
+}
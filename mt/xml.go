@@ -0,0 +1,112 @@
+package mt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"mime"
+	"strings"
+)
+
+// isXMLContentType returns true if a Content-Type header value is an XML media
+// type, such as "application/xml", "text/xml", or "application/soap+xml".
+func isXMLContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// decodeXML decodes an XML document into the same form as a decoded JSON
+// object, so that it can be compared the same way.
+//
+// The document is an object whose only key is the name of its root element. An
+// element with neither attributes nor child elements is its text; otherwise it
+// is an object of its attributes, prefixed with "@", its child elements, and its
+// text, if any, as "#text". Child elements that appear more than once are an
+// array of their values. Namespaces are ignored, and all values are strings.
+func decodeXML(body []byte) (map[string]any, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			value, err := decodeXMLElement(decoder, start)
+			if err != nil {
+				return nil, err
+			}
+
+			return map[string]any{start.Name.Local: value}, nil
+		}
+	}
+}
+
+// decodeXMLElement decodes the remainder of an element whose start tag has been
+// read.
+func decodeXMLElement(decoder *xml.Decoder, start xml.StartElement) (any, error) {
+	element := map[string]any{}
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		element["@"+attr.Name.Local] = attr.Value
+	}
+
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			child, err := decodeXMLElement(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+
+			name := t.Name.Local
+			switch existing := element[name].(type) {
+			case nil:
+				element[name] = child
+			case xmlRepeated:
+				element[name] = append(existing, child)
+			default:
+				element[name] = xmlRepeated{existing, child}
+			}
+
+		case xml.CharData:
+			text.Write(t)
+
+		case xml.EndElement:
+			for name, value := range element {
+				if repeated, ok := value.(xmlRepeated); ok {
+					element[name] = []any(repeated)
+				}
+			}
+
+			s := strings.TrimSpace(text.String())
+			if len(element) == 0 {
+				return s, nil
+			}
+
+			if s != "" {
+				element["#text"] = s
+			}
+
+			return element, nil
+		}
+	}
+}
+
+// xmlRepeated holds the values of a child element that appears more than once
+// while its parent element is being decoded.
+type xmlRepeated []any